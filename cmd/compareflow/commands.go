@@ -0,0 +1,401 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+// defaultConnectionsFile is read when -connections is not given
+const defaultConnectionsFile = "connections.yaml"
+
+// connectionsFlag defines -connections, defaulting to COMPAREFLOW_CONNECTIONS
+func connectionsFlag(fs *flag.FlagSet) *string {
+	path := os.Getenv("COMPAREFLOW_CONNECTIONS")
+	if path == "" {
+		path = defaultConnectionsFile
+	}
+	return fs.String("connections", path, "connections `file`; environment variables in it are substituted (env COMPAREFLOW_CONNECTIONS)")
+}
+
+// variablesFlag collects repeated -var name=value flags
+type variablesFlag map[string]string
+
+func (v variablesFlag) String() string {
+	pairs := make([]string, 0, len(v))
+	for name, value := range v {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v variablesFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("want name=value")
+	}
+	v[name] = value
+	return nil
+}
+
+// runCommand runs validations from YAML files, one after another
+func runCommand(ctx context.Context, fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	connectionsFile := connectionsFlag(fs)
+	variables := variablesFlag{}
+	fs.Var(variables, "var", "set a variable as `name=value`; repeatable")
+	date := fs.String("date", "", "logical run `date` as YYYY-MM-DD for {{run_date}} (default today in UTC)")
+	format := fs.String("format", "text", "output `format`: text or json")
+	show := fs.Int("show", 10, "number of differences listed per failed validation in text output")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageErrorf("no validation files given")
+	}
+	logicalDate, err := parseDate(*date)
+	if err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return usageErrorf("unknown format %q", *format)
+	}
+
+	connections, err := loadConnections(*connectionsFile)
+	if err != nil {
+		return err
+	}
+
+	// Every file is checked before anything runs
+	var specs []*ValidationSpec
+	for _, path := range fs.Args() {
+		loaded, err := loadValidations(path)
+		if err != nil {
+			return err
+		}
+		specs = append(specs, loaded...)
+	}
+
+	configs := make([]*validation.Config, len(specs))
+	used := make(map[string]bool)
+	for i, spec := range specs {
+		for _, name := range []string{spec.Source, spec.Target} {
+			if _, ok := connections[name]; !ok {
+				return fmt.Errorf("%s: validation %q: unknown connection %q", spec.file, spec.Name, name)
+			}
+		}
+		config, err := validation.ParseConfig(spec.Config)
+		if err != nil {
+			return fmt.Errorf("%s: validation %q: %w", spec.file, spec.Name, err)
+		}
+		// A -var applies to the validations that declare it
+		overrides := make(map[string]string)
+		for name, value := range variables {
+			if _, ok := config.Variables[name]; ok || name == validation.VarRunDate {
+				overrides[name] = value
+				used[name] = true
+			}
+		}
+		if err := config.ResolveVariables(logicalDate, overrides); err != nil {
+			return fmt.Errorf("%s: validation %q: %w", spec.file, spec.Name, err)
+		}
+		configs[i] = config
+	}
+	for name := range variables {
+		if !used[name] {
+			return usageErrorf("no validation declares variable %q", name)
+		}
+	}
+
+	results := make([]*validation.Result, len(specs))
+	for i, spec := range specs {
+		results[i] = runValidation(ctx, connections, spec, configs[i])
+		if *format == "text" {
+			printResult(stdout, spec.Name, results[i], *show)
+		}
+	}
+	return report(stdout, *format, specs, results)
+}
+
+// diffCommand compares two tables with an ad hoc data_match validation
+func diffCommand(ctx context.Context, fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	connectionsFile := connectionsFlag(fs)
+	key := fs.String("key", "", "comma separated key `columns`, named as on the source (required)")
+	columns := fs.String("columns", "", "comma separated `columns` to compare; all when empty")
+	exclude := fs.String("exclude", "", "comma separated `columns` not to compare")
+	filter := fs.String("filter", "", "SQL `condition` applied to the source, and to the target unless -target-filter is set")
+	targetFilter := fs.String("target-filter", "", "SQL `condition` applied to the target")
+	strategy := fs.String("strategy", validation.StrategyFull, "data_match `strategy`: full or hash")
+	maxDifferences := fs.Int("max-differences", 0, "stop collecting differences after `n`; 0 uses the engine default")
+	format := fs.String("format", "text", "output `format`: text or json")
+	show := fs.Int("show", 10, "number of differences listed in text output")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return usageErrorf("want a source and a target table")
+	}
+	if *key == "" {
+		return usageErrorf("-key is required")
+	}
+	if *format != "text" && *format != "json" {
+		return usageErrorf("unknown format %q", *format)
+	}
+	sourceConn, sourceTable, err := splitTable(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	targetConn, targetTable, err := splitTable(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	connections, err := loadConnections(*connectionsFile)
+	if err != nil {
+		return err
+	}
+
+	raw := map[string]interface{}{
+		"comparison_type": validation.TypeDataMatch,
+		"source_table":    sourceTable,
+		"target_table":    targetTable,
+		"key_columns":     splitList(*key),
+		"include_columns": splitList(*columns),
+		"exclude_columns": splitList(*exclude),
+		"filter":          *filter,
+		"target_filter":   *targetFilter,
+		"strategy":        *strategy,
+		"performance":     map[string]interface{}{"max_differences": *maxDifferences},
+	}
+	config, err := validation.ParseConfig(raw)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+	if err := config.ResolveVariables(time.Now().UTC(), nil); err != nil {
+		return err
+	}
+
+	spec := &ValidationSpec{Name: fs.Arg(0) + " vs " + fs.Arg(1), Source: sourceConn, Target: targetConn}
+	result := runValidation(ctx, connections, spec, config)
+	if *format == "text" {
+		printResult(stdout, spec.Name, result, *show)
+	}
+	return report(stdout, *format, []*ValidationSpec{spec}, []*validation.Result{result})
+}
+
+// testConnectionCommand connects to the named connections, or all of them
+func testConnectionCommand(ctx context.Context, fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	connectionsFile := connectionsFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	connections, err := loadConnections(*connectionsFile)
+	if err != nil {
+		return err
+	}
+	names := fs.Args()
+	if len(names) == 0 {
+		for name := range connections {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%s: no connections", *connectionsFile)
+	}
+
+	failed := false
+	for _, name := range names {
+		connector, config, err := connection(connections, name)
+		if err == nil {
+			err = connector.TestConnection(config)
+		}
+		if err != nil {
+			fmt.Fprintf(stdout, "FAIL  %s: %v\n", name, err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(stdout, "OK    %s\n", name)
+	}
+	if failed {
+		return errFailed
+	}
+	return nil
+}
+
+// listTablesCommand prints the tables of a connection, one per line
+func listTablesCommand(ctx context.Context, fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	connectionsFile := connectionsFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageErrorf("want one connection")
+	}
+
+	connections, err := loadConnections(*connectionsFile)
+	if err != nil {
+		return err
+	}
+	side, err := openSide(connections, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer side.DB.Close()
+
+	tables, err := side.Connector.GetTables(side.DB)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+	for _, table := range tables {
+		fmt.Fprintln(stdout, table)
+	}
+	return nil
+}
+
+// runValidation runs one validation. Like the server, it reports connection
+// failures as a result with status error.
+func runValidation(ctx context.Context, connections map[string]ConnectionSpec, spec *ValidationSpec, config *validation.Config) *validation.Result {
+	source, err := openSide(connections, spec.Source)
+	if err != nil {
+		return validation.ErrorResult(fmt.Errorf("source: %w", err))
+	}
+	defer source.DB.Close()
+
+	target, err := openSide(connections, spec.Target)
+	if err != nil {
+		return validation.ErrorResult(fmt.Errorf("target: %w", err))
+	}
+	defer target.DB.Close()
+
+	return validation.Run(ctx, config, source, target)
+}
+
+// report writes the totals, or all results as JSON, and returns errFailed
+// unless every validation passed
+func report(stdout io.Writer, format string, specs []*ValidationSpec, results []*validation.Result) error {
+	passed, failed, errored := 0, 0, 0
+	for _, result := range results {
+		switch result.Status {
+		case validation.StatusSuccess:
+			passed++
+		case validation.StatusFailure:
+			failed++
+		default:
+			errored++
+		}
+	}
+
+	if format == "json" {
+		type entry struct {
+			Name   string             `json:"name"`
+			File   string             `json:"file,omitempty"`
+			Result *validation.Result `json:"result"`
+		}
+		entries := make([]entry, len(specs))
+		for i, spec := range specs {
+			entries[i] = entry{Name: spec.Name, File: spec.file, Result: results[i]}
+		}
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(entries); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(stdout, "\n%d passed, %d failed, %d errored\n", passed, failed, errored)
+	}
+
+	if passed < len(results) {
+		return errFailed
+	}
+	return nil
+}
+
+// printResult writes a line for a result, followed by its errors or its
+// first differences
+func printResult(w io.Writer, name string, result *validation.Result, show int) {
+	duration := (time.Duration(result.DurationMS) * time.Millisecond).String()
+	summary := result.Summary
+	switch result.Status {
+	case validation.StatusSuccess:
+		fmt.Fprintf(w, "PASS   %s (%s): %d source rows, %d target rows\n", name, duration, summary.SourceRowCount, summary.TargetRowCount)
+	case validation.StatusFailure:
+		differing := summary.MismatchedRows + summary.MissingInTarget + summary.ExtraInTarget + summary.DuplicateRows
+		fmt.Fprintf(w, "FAIL   %s (%s): %d rows differ (%d mismatched, %d missing in target, %d extra in target, %d duplicate keys)\n",
+			name, duration, differing, summary.MismatchedRows, summary.MissingInTarget, summary.ExtraInTarget, summary.DuplicateRows)
+	default:
+		fmt.Fprintf(w, "ERROR  %s (%s)\n", name, duration)
+	}
+
+	for _, e := range result.Errors {
+		fmt.Fprintf(w, "       %s\n", e.Message)
+	}
+	differences := result.Details.Differences
+	for i, d := range differences {
+		if i == show {
+			fmt.Fprintf(w, "       ... and %d more\n", len(differences)-show)
+			break
+		}
+		line := fmt.Sprintf("       %s %s", d.Type, formatKey(d.Key))
+		if len(d.Columns) > 0 {
+			line += ": " + strings.Join(d.Columns, ", ")
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// formatKey formats a row key as sorted column=value pairs
+func formatKey(key map[string]interface{}) string {
+	columns := make([]string, 0, len(key))
+	for column := range key {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	pairs := make([]string, len(columns))
+	for i, column := range columns {
+		pairs[i] = fmt.Sprintf("%s=%v", column, key[column])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// parseDate parses a -date flag; empty is today in UTC
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Now().UTC(), nil
+	}
+	date, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, usageErrorf("-date must be a date as YYYY-MM-DD")
+	}
+	return date, nil
+}
+
+// splitTable splits CONNECTION.TABLE at the first dot; the table may itself
+// be qualified, as in warehouse.sales.orders
+func splitTable(s string) (string, string, error) {
+	connection, table, ok := strings.Cut(s, ".")
+	if !ok || connection == "" || table == "" {
+		return "", "", usageErrorf("%q is not CONNECTION.TABLE", s)
+	}
+	return connection, table, nil
+}
+
+// splitList splits a comma separated flag, dropping empty entries
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
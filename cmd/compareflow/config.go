@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+// ConnectionSpec is a connection as declared in the connections file. The
+// config is given either as fields or as a connection string.
+type ConnectionSpec struct {
+	Type   string                 `yaml:"type"`
+	DSN    string                 `yaml:"dsn"`
+	Config map[string]interface{} `yaml:"config"`
+}
+
+// ValidationSpec is a validation as declared in a validation file
+type ValidationSpec struct {
+	Name   string                 `yaml:"name"`
+	Source string                 `yaml:"source"` // connection name
+	Target string                 `yaml:"target"` // connection name
+	Config map[string]interface{} `yaml:"config"`
+
+	file string
+}
+
+// loadConnections reads a connections file, substituting environment
+// variables in its values
+func loadConnections(path string) (map[string]ConnectionSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := expandEnv(&doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var file struct {
+		Connections map[string]ConnectionSpec `yaml:"connections"`
+	}
+	if err := doc.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for name, spec := range file.Connections {
+		if spec.Type == "" {
+			return nil, fmt.Errorf("%s: connection %q: type is required", path, name)
+		}
+		if spec.DSN != "" && spec.Config != nil {
+			return nil, fmt.Errorf("%s: connection %q: set either dsn or config", path, name)
+		}
+	}
+	return file.Connections, nil
+}
+
+// expandEnv replaces $VAR and ${VAR} in the scalar values of a parsed YAML
+// document with environment variables. $$ is a literal dollar sign. Keys and
+// comments are left alone, and a value cannot add YAML structure. A plain
+// value is typed by what it expands to, as if written literally; a quoted
+// one stays a string. Unset variables are an error, so that a missing secret
+// is not silently replaced by an empty string.
+func expandEnv(doc *yaml.Node) error {
+	var missing []string
+	var expand func(node *yaml.Node)
+	expand = func(node *yaml.Node) {
+		switch node.Kind {
+		case yaml.ScalarNode:
+			expanded := os.Expand(node.Value, func(name string) string {
+				if name == "$" {
+					return "$"
+				}
+				value, ok := os.LookupEnv(name)
+				if !ok {
+					missing = append(missing, name)
+				}
+				return value
+			})
+			if expanded != node.Value {
+				node.Value = expanded
+				if node.Style == 0 {
+					node.Tag = "" // resolve the type again
+				}
+			}
+		case yaml.MappingNode:
+			for i := 1; i < len(node.Content); i += 2 {
+				expand(node.Content[i])
+			}
+		default:
+			for _, child := range node.Content {
+				expand(child)
+			}
+		}
+	}
+	expand(doc)
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("environment variables not set: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// loadValidations reads the validations of a file. A file may hold several
+// validations as separate YAML documents.
+func loadValidations(path string) ([]*ValidationSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var specs []*ValidationSpec
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var spec ValidationSpec
+		err := decoder.Decode(&spec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		spec.file = path
+		if spec.Name == "" {
+			spec.Name = fmt.Sprintf("%s#%d", path, len(specs)+1)
+		}
+		if spec.Source == "" || spec.Target == "" {
+			return nil, fmt.Errorf("%s: validation %q: source and target are required", path, spec.Name)
+		}
+		specs = append(specs, &spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("%s: no validations", path)
+	}
+	return specs, nil
+}
+
+// connection returns the connector and parsed config of a named connection
+func connection(connections map[string]ConnectionSpec, name string) (connectors.Connector, interface{}, error) {
+	spec, ok := connections[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown connection %q", name)
+	}
+
+	connector, err := connectors.Get(spec.Type)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connection %q: %w", name, err)
+	}
+
+	raw := spec.Config
+	if spec.DSN != "" {
+		parser, ok := connector.(connectors.DSNParser)
+		if !ok {
+			return nil, nil, fmt.Errorf("connection %q: %s connections do not accept a dsn", name, spec.Type)
+		}
+		if raw, err = parser.ParseDSN(spec.DSN); err != nil {
+			return nil, nil, fmt.Errorf("connection %q: %w", name, err)
+		}
+	}
+
+	config, err := connector.ParseConfig(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connection %q: failed to parse config: %w", name, err)
+	}
+	return connector, config, nil
+}
+
+// openSide connects to a named connection for one end of a validation
+func openSide(connections map[string]ConnectionSpec, name string) (*validation.Side, error) {
+	connector, config, err := connection(connections, name)
+	if err != nil {
+		return nil, err
+	}
+	db, err := connector.Connect(config)
+	if err != nil {
+		return nil, fmt.Errorf("connection %q: failed to connect: %w", name, err)
+	}
+	return &validation.Side{Connector: connector, DB: db}, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("CF_TEST_HOST", "db.internal")
+	t.Setenv("CF_TEST_PORT", "5432")
+	t.Setenv("CF_TEST_PASSWORD", "s3cret\nport: 1 # not a key")
+	tests := []struct {
+		in      string
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{"host: ${CF_TEST_HOST}", map[string]interface{}{"host": "db.internal"}, ""},
+		{"host: $CF_TEST_HOST", map[string]interface{}{"host": "db.internal"}, ""},
+		{"port: ${CF_TEST_PORT}", map[string]interface{}{"port": 5432}, ""},
+		{`port: "${CF_TEST_PORT}"`, map[string]interface{}{"port": "5432"}, ""},
+		{"password: pa$$word", map[string]interface{}{"password": "pa$word"}, ""},
+		{"password: ${CF_TEST_PASSWORD}", map[string]interface{}{"password": "s3cret\nport: 1 # not a key"}, ""},
+		{"${CF_TEST_HOST}: x # ${CF_TEST_UNSET_C}", map[string]interface{}{"${CF_TEST_HOST}": "x"}, ""},
+		{"user: ${CF_TEST_UNSET_B}\npassword: ${CF_TEST_UNSET_A}", nil, "CF_TEST_UNSET_A, CF_TEST_UNSET_B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.in), &doc); err != nil {
+				t.Fatal(err)
+			}
+			err := expandEnv(&doc)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expandEnv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			var got map[string]interface{}
+			if err == nil {
+				err = doc.Decode(&got)
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandEnv() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Command compareflow runs the CompareFlow server with "compareflow serve".
+// Its other commands run validations from YAML files without the server or
+// its metadata database, for use in CI pipelines. It exits with status 1 when
+// a validation fails or cannot run and 2 on invalid usage.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	_ "github.com/compareflow/compareflow/internal/connectors/all"
+)
+
+// command is a subcommand of the CLI
+type command struct {
+	name    string
+	usage   string
+	summary string
+	// run defines its flags on fs, parses args with parseFlags and writes
+	// its report to stdout
+	run func(ctx context.Context, fs *flag.FlagSet, args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{"serve", "serve [flags]", "Run the API server and web UI, configured from the environment", serveCommand},
+	{"run", "run [flags] FILE...", "Run the validations in YAML files", runCommand},
+	{"diff", "diff [flags] SOURCE_CONNECTION.TABLE TARGET_CONNECTION.TABLE", "Compare two tables by key", diffCommand},
+	{"test-connection", "test-connection [flags] [CONNECTION...]", "Check that connections work", testConnectionCommand},
+	{"list-tables", "list-tables [flags] CONNECTION", "List the tables of a connection", listTablesCommand},
+}
+
+var (
+	// errFailed reports that a check failed after its output was written
+	errFailed = errors.New("failed")
+	// errUsage reports invalid flags after the flag package explained them
+	errUsage = errors.New("invalid usage")
+)
+
+// usageError is a mistake in the command line
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := cli(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// cli runs the command named by the first argument and returns the exit status
+func cli(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		fs.Usage = func() {
+			fmt.Fprintf(stderr, "usage: compareflow %s\n\n%s.\n\nFlags:\n", cmd.usage, cmd.summary)
+			fs.PrintDefaults()
+		}
+
+		err := cmd.run(ctx, fs, args[1:], stdout)
+		var usageErr *usageError
+		switch {
+		case err == nil:
+			return 0
+		case errors.Is(err, flag.ErrHelp):
+			return 0
+		case errors.Is(err, errFailed):
+			return 1
+		case errors.Is(err, errUsage):
+			return 2
+		case errors.As(err, &usageErr):
+			fmt.Fprintf(stderr, "compareflow %s: %v\nusage: compareflow %s\n", cmd.name, err, cmd.usage)
+			return 2
+		default:
+			fmt.Fprintf(stderr, "compareflow %s: %v\n", cmd.name, err)
+			return 1
+		}
+	}
+
+	fmt.Fprintf(stderr, "compareflow: unknown command %q\n\n", args[0])
+	printUsage(stderr)
+	return 2
+}
+
+// parseFlags parses args, leaving the explanation of invalid flags to the
+// flag package
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: compareflow COMMAND [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'compareflow COMMAND -h' for the flags of a command.")
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+)
+
+func init() {
+	connectors.Register("sqlite", func() connectors.Connector { return &sqliteConnector{} })
+}
+
+// sqliteConnector opens the SQLite file named by the "path" config field,
+// so that the commands can run validations end to end
+type sqliteConnector struct {
+	connectors.Connector
+}
+
+func (c *sqliteConnector) Type() string { return "sqlite" }
+
+func (c *sqliteConnector) ParseConfig(configMap map[string]interface{}) (interface{}, error) {
+	path, _ := configMap["path"].(string)
+	if path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	return path, nil
+}
+
+func (c *sqliteConnector) Connect(config interface{}) (*sql.DB, error) {
+	return sql.Open("sqlite3", config.(string)+"?mode=ro")
+}
+
+func (c *sqliteConnector) TestConnection(config interface{}) error {
+	db, err := c.Connect(config)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Ping()
+}
+
+func (c *sqliteConnector) GetTables(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+func (c *sqliteConnector) QuoteIdentifier(name string) string {
+	return `"` + name + `"`
+}
+
+func (c *sqliteConnector) Placeholder(n int) string { return "?" }
+
+func (c *sqliteConnector) NormalizeExpression(expr, databaseType string) string {
+	return "CAST(" + expr + " AS TEXT)"
+}
+
+func (c *sqliteConnector) HashExpression(texts []string, part int) string {
+	panic("not supported")
+}
+
+func TestCLI(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	database := filepath.Join(dir, "shop.db")
+	db, err := sql.Open("sqlite3", database)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, total INTEGER)",
+		"INSERT INTO orders VALUES (1, 10), (2, 20), (3, 30)",
+		"CREATE TABLE orders_copy AS SELECT * FROM orders",
+		"CREATE TABLE orders_changed AS SELECT * FROM orders",
+		"UPDATE orders_changed SET total = 99 WHERE id = 2",
+		"CREATE TABLE customers (id INTEGER PRIMARY KEY, region TEXT)",
+		"INSERT INTO customers VALUES (1, 'EU'), (2, 'EU'), (3, 'US'), (4, 'US')",
+		"CREATE TABLE customers_copy AS SELECT * FROM customers WHERE id <> 4",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	// Nothing listens on port 1 of the warehouse, so its runs end with status error
+	connections := write("connections.yaml", `
+connections:
+  shop:
+    type: sqlite
+    config:
+      path: ${CF_TEST_DATABASE}
+  warehouse:
+    type: postgresql
+    config:
+      host: 127.0.0.1
+      port: 1
+      database: shop
+      username: ${CF_TEST_USER}
+`)
+	warehouse := write("warehouse.yaml", `
+name: orders
+source: warehouse
+target: warehouse
+config:
+  comparison_type: row_count
+  source_table: orders
+  target_table: orders_copy
+---
+name: customers
+source: warehouse
+target: warehouse
+config:
+  comparison_type: row_count
+  source_query: SELECT * FROM customers WHERE region = {{region}}
+  target_query: SELECT * FROM customers_copy WHERE region = {{region}}
+  variables:
+    region: EU
+`)
+	// Only EU customers were copied, so customers passes for region EU alone
+	shop := write("shop.yaml", `
+name: orders
+source: shop
+target: shop
+config:
+  comparison_type: row_count
+  source_table: orders
+  target_table: orders_copy
+---
+name: customers
+source: shop
+target: shop
+config:
+  comparison_type: row_count
+  source_query: SELECT * FROM customers WHERE region = {{region}}
+  target_query: SELECT * FROM customers_copy WHERE region = {{region}}
+  variables:
+    region: US
+`)
+	unknown := write("unknown.yaml", `
+source: shop
+target: lake
+config:
+  comparison_type: row_count
+  source_table: a
+  target_table: b
+`)
+	t.Setenv("CF_TEST_USER", "ci")
+	t.Setenv("CF_TEST_DATABASE", database)
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+		out  string
+	}{
+		{"no command", nil, 2, ""},
+		{"help", []string{"help"}, 0, ""},
+		{"unknown command", []string{"check"}, 2, ""},
+		{"unknown flag", []string{"run", "-connections", connections, "-bogus", shop}, 2, ""},
+		{"no files", []string{"run", "-connections", connections}, 2, ""},
+		{"run errors", []string{"run", "-connections", connections, "-var", "region=US", warehouse}, 1, "0 passed, 0 failed, 2 errored"},
+		{"run fails", []string{"run", "-connections", connections, shop}, 1, "1 passed, 1 failed, 0 errored"},
+		{"variable applies to declaring validations only", []string{"run", "-connections", connections, "-var", "region=EU", shop}, 0, "2 passed, 0 failed, 0 errored"},
+		{"variable across files", []string{"run", "-connections", connections, "-var", "region=EU", shop, warehouse}, 1, "2 passed, 0 failed, 2 errored"},
+		{"undeclared variable", []string{"run", "-connections", connections, "-var", "country=NL", shop}, 2, ""},
+		{"invalid date", []string{"run", "-connections", connections, "-date", "yesterday", shop}, 2, ""},
+		{"unknown connection", []string{"run", "-connections", connections, unknown}, 1, ""},
+		{"diff without key", []string{"diff", "-connections", connections, "shop.orders", "shop.orders_copy"}, 2, ""},
+		{"diff without connection", []string{"diff", "-connections", connections, "-key", "id", "orders", "shop.orders_copy"}, 2, ""},
+		{"diff passes", []string{"diff", "-connections", connections, "-key", "id", "shop.orders", "shop.orders_copy"}, 0, "PASS"},
+		{"diff fails", []string{"diff", "-connections", connections, "-key", "id", "shop.orders", "shop.orders_changed"}, 1, "mismatch id=2: total"},
+		{"diff unknown connection", []string{"diff", "-connections", connections, "-key", "id", "lake.orders", "shop.orders"}, 1, `unknown connection "lake"`},
+		{"test-connection", []string{"test-connection", "-connections", connections, "shop"}, 0, "OK    shop"},
+		{"test-connection unknown", []string{"test-connection", "-connections", connections, "shop", "lake"}, 1, `FAIL  lake: unknown connection "lake"`},
+		{"list-tables", []string{"list-tables", "-connections", connections, "shop"}, 0, "customers\ncustomers_copy\norders\n"},
+		{"list-tables without connection", []string{"list-tables", "-connections", connections}, 2, ""},
+		{"list-tables unknown connection", []string{"list-tables", "-connections", connections, "lake"}, 1, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := cli(context.Background(), tt.args, &stdout, &stderr); got != tt.want {
+				t.Fatalf("exit status %d, want %d\nstdout: %s\nstderr: %s", got, tt.want, stdout.String(), stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.out) {
+				t.Errorf("stdout = %q, want it to contain %q", stdout.String(), tt.out)
+			}
+		})
+	}
+}
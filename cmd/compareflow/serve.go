@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/compareflow/compareflow/internal/api"
+	"github.com/compareflow/compareflow/internal/config"
+	"github.com/compareflow/compareflow/internal/database"
+)
+
+// shutdownTimeout bounds how long requests in flight may take to finish
+const shutdownTimeout = 30 * time.Second
+
+// serveCommand runs the API server and its background workers until ctx is done
+func serveCommand(ctx context.Context, fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	web := fs.String("web", "web/dist", "`directory` of the built web UI; the UI is not served when it has no index.html")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return usageErrorf("unexpected arguments")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	// The schema is migrated from the models, so no SQL files are embedded
+	db, err := database.Initialize(cfg.DatabaseURL, embed.FS{})
+	if err != nil {
+		return err
+	}
+
+	router := gin.Default()
+	workers := api.SetupRoutes(router, db, cfg)
+	if !serveUI(router, *web) {
+		log.Printf("web UI not found in %s; serving the API only", *web)
+	}
+
+	listener, err := net.Listen("tcp", ":"+cfg.Port)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: router}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	workers.Start()
+	fmt.Fprintf(stdout, "CompareFlow listening on %s\n", listener.Addr())
+
+	select {
+	case err = <-served:
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		err = server.Shutdown(shutdownCtx)
+		cancel()
+	}
+	workers.Stop()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// serveUI serves the web UI in dir for every path outside the API, falling
+// back to index.html so client side routes can be reloaded. It reports
+// whether dir holds a UI.
+func serveUI(router *gin.Engine, dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, "index.html")); err != nil {
+		return false
+	}
+
+	files := http.Dir(dir)
+	fileServer := http.FileServer(files)
+	router.NoRoute(func(c *gin.Context) {
+		if strings.HasPrefix(c.Request.URL.Path, "/api/") ||
+			(c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		if f, err := files.Open(path.Clean(c.Request.URL.Path)); err == nil {
+			f.Close()
+			fileServer.ServeHTTP(c.Writer, c.Request)
+			return
+		}
+		c.File(filepath.Join(dir, "index.html"))
+	})
+	return true
+}
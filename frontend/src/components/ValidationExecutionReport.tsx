@@ -221,7 +221,7 @@ export default function ValidationExecutionReport({
                 </Grid>
 
                 {/* Discrepancies */}
-                {(summary.mismatched_rows > 0 || summary.missing_in_target > 0 || summary.extra_in_target > 0) && (
+                {(summary.mismatched_rows > 0 || summary.missing_in_target > 0 || summary.extra_in_target > 0 || summary.duplicate_rows > 0) && (
                   <>
                     <Typography variant="h6" gutterBottom>
                       Discrepancies Found
@@ -254,6 +254,15 @@ export default function ValidationExecutionReport({
                           </Alert>
                         </Grid>
                       )}
+                      {summary.duplicate_rows > 0 && (
+                        <Grid item xs={12} md={4}>
+                          <Alert severity="warning">
+                            <Typography variant="subtitle2">
+                              Duplicate Keys: <strong>{summary.duplicate_rows}</strong>
+                            </Typography>
+                          </Alert>
+                        </Grid>
+                      )}
                     </Grid>
                   </>
                 )}
@@ -271,7 +280,7 @@ export default function ValidationExecutionReport({
                         </Typography>
                         <Paper variant="outlined" sx={{ p: 2, bgcolor: 'grey.50' }}>
                           <Typography variant="body2" component="pre" sx={{ fontFamily: 'monospace', fontSize: '0.85rem' }}>
-                            {validation.config.source_query || 'N/A'}
+                            {details.source_query || validation.config.source_query || 'N/A'}
                           </Typography>
                         </Paper>
                       </CardContent>
@@ -285,7 +294,7 @@ export default function ValidationExecutionReport({
                         </Typography>
                         <Paper variant="outlined" sx={{ p: 2, bgcolor: 'grey.50' }}>
                           <Typography variant="body2" component="pre" sx={{ fontFamily: 'monospace', fontSize: '0.85rem' }}>
-                            {validation.config.target_query || 'N/A'}
+                            {details.target_query || validation.config.target_query || 'N/A'}
                           </Typography>
                         </Paper>
                       </CardContent>
@@ -309,6 +318,152 @@ export default function ValidationExecutionReport({
                   </>
                 )}
 
+                {/* Variables the queries ran with */}
+                {results.variables && Object.keys(results.variables).length > 0 && (
+                  <Alert severity="info" sx={{ mb: 3 }}>
+                    Variables:{' '}
+                    {Object.entries(results.variables)
+                      .sort(([a], [b]) => a.localeCompare(b))
+                      .map(([name, value]) => `${name} = ${value}`)
+                      .join(', ')}
+                  </Alert>
+                )}
+
+                {/* Sampled runs */}
+                {details.sample && (
+                  <Alert severity="info" sx={{ mb: 3 }}>
+                    Sampled {details.sample.sampled_rows.toLocaleString()} source rows
+                    ({details.sample.rows ? `${details.sample.rows.toLocaleString()} rows` : `${details.sample.percent}%`},
+                    method {details.sample.method}, seed {details.sample.seed}). Rows only in the target are not checked.
+                  </Alert>
+                )}
+
+                {/* Unmapped columns (when unmapped_columns is "flag") */}
+                {details.unmapped_columns && (
+                  <Alert severity="warning" sx={{ mb: 3 }}>
+                    Columns not compared:
+                    {details.unmapped_columns.source.length > 0 && ` source ${details.unmapped_columns.source.join(', ')}`}
+                    {details.unmapped_columns.target.length > 0 && ` target ${details.unmapped_columns.target.join(', ')}`}
+                  </Alert>
+                )}
+
+                {/* Rows accepted by column rules */}
+                {details.rule_matches && details.rule_matches.length > 0 && (
+                  <>
+                    <Typography variant="h6" gutterBottom>
+                      Matched by Column Rules
+                    </Typography>
+                    <TableContainer component={Paper} sx={{ mb: 3 }}>
+                      <Table size="small">
+                        <TableHead>
+                          <TableRow>
+                            <TableCell>Column</TableCell>
+                            <TableCell>Rule</TableCell>
+                            <TableCell align="right">Rows</TableCell>
+                            <TableCell>Sample Keys</TableCell>
+                          </TableRow>
+                        </TableHead>
+                        <TableBody>
+                          {details.rule_matches.map((match) => (
+                            <TableRow key={`${match.column}-${match.rule}`}>
+                              <TableCell>{match.column}</TableCell>
+                              <TableCell>{match.rule}</TableCell>
+                              <TableCell align="right">{match.rows.toLocaleString()}</TableCell>
+                              <TableCell>{(match.sample_keys || []).map((key) => JSON.stringify(key)).join(', ')}</TableCell>
+                            </TableRow>
+                          ))}
+                        </TableBody>
+                      </Table>
+                    </TableContainer>
+                  </>
+                )}
+
+                {/* Column statistics (column_stats validations) */}
+                {details.column_stats && Object.keys(details.column_stats).length > 0 && (
+                  <>
+                    <Typography variant="h6" gutterBottom>
+                      Column Statistics
+                    </Typography>
+                    <TableContainer component={Paper} sx={{ mb: 3 }}>
+                      <Table size="small">
+                        <TableHead>
+                          <TableRow>
+                            <TableCell>Column</TableCell>
+                            <TableCell>Metric</TableCell>
+                            <TableCell align="right">Source</TableCell>
+                            <TableCell align="right">Target</TableCell>
+                          </TableRow>
+                        </TableHead>
+                        <TableBody>
+                          {Object.entries(details.column_stats).flatMap(([column, stats]) =>
+                            ['sum', 'min', 'max', 'avg', 'count_distinct', 'null_count']
+                              .filter((metric) => `source_${metric}` in stats || `target_${metric}` in stats)
+                              .map((metric) => (
+                                <TableRow key={`${column}-${metric}`}>
+                                  <TableCell>{column}</TableCell>
+                                  <TableCell>
+                                    {metric}
+                                    {stats.mismatched?.includes(metric) && (
+                                      <Chip label="mismatch" size="small" color="warning" sx={{ ml: 1 }} />
+                                    )}
+                                  </TableCell>
+                                  <TableCell align="right">{String((stats as any)[`source_${metric}`] ?? 'NULL')}</TableCell>
+                                  <TableCell align="right">{String((stats as any)[`target_${metric}`] ?? 'NULL')}</TableCell>
+                                </TableRow>
+                              ))
+                          )}
+                        </TableBody>
+                      </Table>
+                    </TableContainer>
+                  </>
+                )}
+
+                {/* Groups that differ (group_by) */}
+                {details.groups && details.groups.length > 0 && (
+                  <>
+                    <Typography variant="h6" gutterBottom>
+                      Differing Groups (First 10)
+                    </Typography>
+                    <TableContainer component={Paper} sx={{ mb: 3 }}>
+                      <Table size="small">
+                        <TableHead>
+                          <TableRow>
+                            <TableCell>Type</TableCell>
+                            <TableCell>Group</TableCell>
+                            <TableCell align="right">Source Rows</TableCell>
+                            <TableCell align="right">Target Rows</TableCell>
+                            <TableCell align="right">Difference</TableCell>
+                            <TableCell>Mismatched</TableCell>
+                          </TableRow>
+                        </TableHead>
+                        <TableBody>
+                          {details.groups.slice(0, 10).map((group, index) => (
+                            <TableRow key={index}>
+                              <TableCell>
+                                <Chip
+                                  label={group.type}
+                                  size="small"
+                                  color={group.type === 'missing' ? 'error' : group.type === 'extra' ? 'info' : 'warning'}
+                                />
+                              </TableCell>
+                              <TableCell>{JSON.stringify(group.group)}</TableCell>
+                              <TableCell align="right">{group.source_rows.toLocaleString()}</TableCell>
+                              <TableCell align="right">{group.target_rows.toLocaleString()}</TableCell>
+                              <TableCell align="right">{group.difference > 0 ? '+' : ''}{group.difference.toLocaleString()}</TableCell>
+                              <TableCell>
+                                {Object.entries(group.columns || {})
+                                  .filter(([, stats]) => stats.mismatched?.length)
+                                  .map(([column, stats]) => `${column}: ${stats.mismatched?.join(', ')}`)
+                                  .join('; ') || 'N/A'}
+                              </TableCell>
+                            </TableRow>
+                          ))}
+                        </TableBody>
+                      </Table>
+                    </TableContainer>
+                  </>
+                )}
+
                 {/* Detailed Differences (if available) */}
                 {details.differences && details.differences.length > 0 && (
                   <>
@@ -19,31 +19,64 @@ import {
 } from '@mui/material';
 import { createConnection, updateConnection, fetchConnection } from '../store/slices/connectionSlice';
 import { AppDispatch, RootState } from '../store';
+import { connectionService } from '../services/connectionService';
+import { ConfigSchemaProperty, ConnectorDescriptor } from '../types';
+
+const sortedProperties = (properties: Record<string, ConfigSchemaProperty> = {}) =>
+  Object.entries(properties).sort(
+    ([, a], [, b]) => (a.propertyOrder ?? 0) - (b.propertyOrder ?? 0)
+  );
+
+// defaultConfig builds a config object from the defaults declared in a schema
+const defaultConfig = (properties: Record<string, ConfigSchemaProperty> = {}) => {
+  const config: Record<string, any> = {};
+  sortedProperties(properties).forEach(([name, prop]) => {
+    if (prop.type === 'object') {
+      return; // Nested blocks are optional and start disabled
+    }
+    if (prop.default !== undefined) {
+      config[name] = prop.default;
+    } else if (prop.type === 'boolean') {
+      config[name] = false;
+    } else {
+      config[name] = '';
+    }
+  });
+  return config;
+};
 
 export default function ConnectionForm() {
   const navigate = useNavigate();
   const { id } = useParams();
   const dispatch = useDispatch<AppDispatch>();
   const { currentConnection, loading } = useSelector((state: RootState) => state.connections);
-  
+
+  const [connectors, setConnectors] = useState<ConnectorDescriptor[]>([]);
   const [formData, setFormData] = useState({
     name: '',
-    type: 'sqlserver' as 'sqlserver' | 'databricks',
-    config: {
-      server: '',
-      port: 1433,
-      database: '',
-      username: '',
-      password: '',
-      encrypt: false,
-      trust_server_certificate: true,
-      workspace: '',
-      http_path: '',
-      access_token: '',
-    },
+    type: '',
+    config: {} as Record<string, any>,
   });
 
   const [error, setError] = useState('');
+  const [dsn, setDsn] = useState('');
+  const [dsnMessage, setDsnMessage] = useState('');
+
+  useEffect(() => {
+    connectionService
+      .getConnectors()
+      .then((descriptors) => {
+        setConnectors(descriptors);
+        if (!id && descriptors.length > 0) {
+          setFormData((prev) => ({
+            ...prev,
+            type: descriptors[0].type,
+            config: defaultConfig(descriptors[0].config_schema.properties),
+          }));
+        }
+      })
+      .catch(() => setError('Failed to load connector types'));
+  }, [id]);
 
   useEffect(() => {
     if (id) {
@@ -53,31 +86,183 @@ export default function ConnectionForm() {
 
   useEffect(() => {
     if (currentConnection && id) {
+      const descriptor = connectors.find((c) => c.type === currentConnection.type);
       setFormData({
         name: currentConnection.name,
         type: currentConnection.type,
         config: {
-          ...formData.config,
+          ...defaultConfig(descriptor?.config_schema.properties),
           ...currentConnection.config,
         },
       });
     }
-  }, [currentConnection, id]);
+  }, [currentConnection, id, connectors]);
+
+  const descriptor = connectors.find((c) => c.type === formData.type);
 
   const handleChange = (e: React.ChangeEvent<HTMLInputElement | HTMLTextAreaElement> | any) => {
-    const { name, value, checked } = e.target;
-    
-    if (name === 'name' || name === 'type') {
-      setFormData({ ...formData, [name]: value });
-    } else if (name in formData.config) {
+    const { name, value } = e.target;
+
+    if (name === 'type') {
+      const selected = connectors.find((c) => c.type === value);
       setFormData({
         ...formData,
-        config: {
-          ...formData.config,
-          [name]: name === 'port' ? parseInt(value) || 0 : 
-                   (name === 'encrypt' || name === 'trust_server_certificate') ? checked : value,
-        },
+        type: value,
+        config: defaultConfig(selected?.config_schema.properties),
+      });
+    } else {
+      setFormData({ ...formData, [name]: value });
+    }
+  };
+
+  // setConfigValue updates a (possibly nested) config value addressed by path
+  const setConfigValue = (path: string[], value: any) => {
+    setFormData((prev) => {
+      const config = { ...prev.config };
+      let target: Record<string, any> = config;
+      path.slice(0, -1).forEach((key) => {
+        target[key] = { ...(target[key] || {}) };
+        target = target[key];
       });
+      target[path[path.length - 1]] = value;
+      return { ...prev, config };
+    });
+  };
+
+  const toggleBlock = (path: string[], prop: ConfigSchemaProperty, enabled: boolean) => {
+    setFormData((prev) => {
+      const config = { ...prev.config };
+      let target: Record<string, any> = config;
+      path.slice(0, -1).forEach((key) => {
+        target[key] = { ...(target[key] || {}) };
+        target = target[key];
+      });
+      const key = path[path.length - 1];
+      if (enabled) {
+        target[key] = defaultConfig(prop.properties);
+      } else {
+        delete target[key];
+      }
+      return { ...prev, config };
+    });
+  };
+
+  const renderFields = (
+    properties: Record<string, ConfigSchemaProperty> | undefined,
+    required: string[] = [],
+    values: Record<string, any>,
+    path: string[] = []
+  ): React.ReactNode =>
+    sortedProperties(properties).map(([name, prop]) => {
+      const fieldPath = [...path, name];
+      const key = fieldPath.join('.');
+      const value = values?.[name];
+      const unless = prop['x-required-unless'];
+      const isRequired = required.includes(name) || (!!unless && !values?.[unless]);
+
+      if (prop.type === 'object') {
+        const enabled = value !== undefined && value !== null;
+        return (
+          <Box key={key} sx={{ mt: 2 }}>
+            <FormControlLabel
+              control={
+                <Checkbox
+                  checked={enabled}
+                  onChange={(e) => toggleBlock(fieldPath, prop, e.target.checked)}
+                />
+              }
+              label={prop.title}
+            />
+            {prop.description && (
+              <Typography variant="body2" color="text.secondary">
+                {prop.description}
+              </Typography>
+            )}
+            {enabled && (
+              <Box sx={{ pl: 2, borderLeft: 2, borderColor: 'divider' }}>
+                {renderFields(prop.properties, prop.required, value, fieldPath)}
+              </Box>
+            )}
+          </Box>
+        );
+      }
+
+      if (prop.type === 'boolean') {
+        return (
+          <Box key={key}>
+            <FormControlLabel
+              control={
+                <Checkbox
+                  checked={!!value}
+                  onChange={(e) => setConfigValue(fieldPath, e.target.checked)}
+                />
+              }
+              label={prop.title}
+            />
+          </Box>
+        );
+      }
+
+      if (prop.enum) {
+        return (
+          <FormControl key={key} fullWidth margin="normal">
+            <InputLabel>{prop.title}</InputLabel>
+            <Select
+              value={value ?? ''}
+              onChange={(e) => setConfigValue(fieldPath, e.target.value)}
+              label={prop.title}
+              required={isRequired}
+            >
+              {prop.enum.map((option) => (
+                <MenuItem key={option} value={option}>
+                  {option}
+                </MenuItem>
+              ))}
+            </Select>
+          </FormControl>
+        );
+      }
+
+      return (
+        <TextField
+          key={key}
+          fullWidth
+          label={prop.title}
+          type={prop.writeOnly && !prop['x-multiline'] ? 'password' : prop.type === 'integer' ? 'number' : 'text'}
+          value={value ?? ''}
+          onChange={(e) =>
+            setConfigValue(
+              fieldPath,
+              prop.type === 'integer' ? parseInt(e.target.value) || 0 : e.target.value
+            )
+          }
+          margin="normal"
+          required={isRequired}
+          multiline={!!prop['x-multiline']}
+          minRows={prop['x-multiline'] ? 3 : undefined}
+          placeholder={prop.examples?.[0]}
+          helperText={prop.description}
+        />
+      );
+    });
+
+  // handleImportDSN fills the form from a pasted connection string
+  const handleImportDSN = async () => {
+    setError('');
+    setDsnMessage('');
+
+    try {
+      const parsed = await connectionService.parseDSN(formData.type, dsn);
+      setFormData((prev) => ({
+        ...prev,
+        config: { ...prev.config, ...parsed.config },
+      }));
+      setDsn('');
+      if (!parsed.valid) {
+        setDsnMessage(`Imported, but the configuration is incomplete: ${parsed.validation_error}`);
+      }
+    } catch (err: any) {
+      setError(err.response?.data?.error || 'Failed to parse connection string');
     }
   };
 
@@ -113,9 +298,9 @@ export default function ConnectionForm() {
         <Typography variant="h5" gutterBottom>
           {id ? 'Edit Connection' : 'New Connection'}
         </Typography>
-        
+
         {error && <Alert severity="error" sx={{ mb: 2 }}>{error}</Alert>}
-        
+
         <Box component="form" onSubmit={handleSubmit}>
           <TextField
             fullWidth
@@ -126,7 +311,7 @@ export default function ConnectionForm() {
             margin="normal"
             required
           />
-          
+
           <FormControl fullWidth margin="normal">
             <InputLabel>Connection Type</InputLabel>
             <Select
@@ -136,117 +321,37 @@ export default function ConnectionForm() {
               label="Connection Type"
               required
             >
-              <MenuItem value="sqlserver">SQL Server</MenuItem>
-              <MenuItem value="databricks">Databricks</MenuItem>
+              {connectors.map((connector) => (
+                <MenuItem key={connector.type} value={connector.type}>
+                  {connector.title}
+                </MenuItem>
+              ))}
             </Select>
           </FormControl>
 
-          {formData.type === 'sqlserver' && (
-            <>
-              <TextField
-                fullWidth
-                label="Server"
-                name="server"
-                value={formData.config.server}
-                onChange={handleChange}
-                margin="normal"
-                required
-              />
-              <TextField
-                fullWidth
-                label="Port"
-                name="port"
-                type="number"
-                value={formData.config.port}
-                onChange={handleChange}
-                margin="normal"
-                required
-              />
+          {descriptor?.capabilities.supports_dsn_import && (
+            <Box sx={{ mt: 2, display: 'flex', gap: 2, alignItems: 'flex-start' }}>
               <TextField
                 fullWidth
-                label="Database"
-                name="database"
-                value={formData.config.database}
-                onChange={handleChange}
-                margin="normal"
-                required
+                label="Import Connection String"
+                value={dsn}
+                onChange={(e) => setDsn(e.target.value)}
+                placeholder="Paste a JDBC, ADO.NET or URL connection string"
+                helperText={dsnMessage || 'Populates the fields below for review'}
+                error={!!dsnMessage}
               />
-              <TextField
-                fullWidth
-                label="Username"
-                name="username"
-                value={formData.config.username}
-                onChange={handleChange}
-                margin="normal"
-                required
-              />
-              <TextField
-                fullWidth
-                label="Password"
-                name="password"
-                type="password"
-                value={formData.config.password}
-                onChange={handleChange}
-                margin="normal"
-                required
-              />
-              <FormControlLabel
-                control={
-                  <Checkbox
-                    name="encrypt"
-                    checked={formData.config.encrypt}
-                    onChange={handleChange}
-                  />
-                }
-                label="Encrypt Connection"
-              />
-              <FormControlLabel
-                control={
-                  <Checkbox
-                    name="trust_server_certificate"
-                    checked={formData.config.trust_server_certificate}
-                    onChange={handleChange}
-                  />
-                }
-                label="Trust Server Certificate"
-              />
-            </>
+              <Button variant="outlined" onClick={handleImportDSN} disabled={!dsn} sx={{ mt: 1 }}>
+                Import
+              </Button>
+            </Box>
           )}
 
-          {formData.type === 'databricks' && (
-            <>
-              <TextField
-                fullWidth
-                label="Workspace URL"
-                name="workspace"
-                value={formData.config.workspace}
-                onChange={handleChange}
-                margin="normal"
-                required
-                placeholder="https://your-workspace.databricks.com"
-              />
-              <TextField
-                fullWidth
-                label="HTTP Path"
-                name="http_path"
-                value={formData.config.http_path}
-                onChange={handleChange}
-                margin="normal"
-                required
-                placeholder="/sql/1.0/endpoints/your-endpoint"
-              />
-              <TextField
-                fullWidth
-                label="Access Token"
-                name="access_token"
-                type="password"
-                value={formData.config.access_token}
-                onChange={handleChange}
-                margin="normal"
-                required
-              />
-            </>
-          )}
+          {descriptor &&
+            renderFields(
+              descriptor.config_schema.properties,
+              descriptor.config_schema.required,
+              formData.config
+            )}
 
           <Box sx={{ mt: 3, display: 'flex', gap: 2 }}>
             <Button
@@ -267,4 +372,4 @@ export default function ConnectionForm() {
       </Paper>
     </Container>
   );
-}
\ No newline at end of file
+}
@@ -50,7 +50,7 @@ export default function Connections() {
   }, [dispatch]);
 
   useEffect(() => {
-    if (testResult) {
+    if (testResult && !testResult.steps) {
       setSnackbarOpen(true);
     }
   }, [testResult]);
@@ -159,6 +159,72 @@ export default function Connections() {
         </DialogActions>
       </Dialog>
 
+      {/* Test Result Dialog */}
+      <Dialog open={!!testResult?.steps} onClose={() => dispatch(clearTestResult())} maxWidth="md" fullWidth>
+        <DialogTitle>Connection Test</DialogTitle>
+        <DialogContent>
+          <Alert severity={testResult?.success ? 'success' : 'error'} sx={{ mb: 2 }}>
+            {testResult?.message}
+          </Alert>
+          <Table size="small">
+            <TableHead>
+              <TableRow>
+                <TableCell>Step</TableCell>
+                <TableCell>Status</TableCell>
+                <TableCell>Latency</TableCell>
+                <TableCell>Details</TableCell>
+              </TableRow>
+            </TableHead>
+            <TableBody>
+              {testResult?.steps?.map((step) => (
+                <TableRow key={step.name}>
+                  <TableCell>{step.name}</TableCell>
+                  <TableCell>
+                    <Chip
+                      label={step.status}
+                      size="small"
+                      color={step.status === 'passed' ? 'success' : step.status === 'failed' ? 'error' : 'default'}
+                    />
+                  </TableCell>
+                  <TableCell>{step.status === 'skipped' ? '-' : `${step.latency_ms} ms`}</TableCell>
+                  <TableCell>
+                    {step.message}
+                    {step.hint && (
+                      <Typography variant="body2" color="text.secondary">
+                        {step.hint}
+                      </Typography>
+                    )}
+                  </TableCell>
+                </TableRow>
+              ))}
+            </TableBody>
+          </Table>
+          {testResult?.server && (
+            <Box sx={{ mt: 2 }}>
+              <Typography variant="subtitle2">Server</Typography>
+              {testResult.server.error ? (
+                <Typography variant="body2" color="text.secondary">{testResult.server.error}</Typography>
+              ) : (
+                <>
+                  <Typography variant="body2">{testResult.server.version}</Typography>
+                  <Typography variant="body2">
+                    {testResult.server.current_user} on {testResult.server.database}
+                  </Typography>
+                  <Box sx={{ mt: 1, display: 'flex', gap: 1, flexWrap: 'wrap' }}>
+                    {testResult.server.privileges?.map((privilege) => (
+                      <Chip key={privilege} label={privilege} size="small" variant="outlined" />
+                    ))}
+                  </Box>
+                </>
+              )}
+            </Box>
+          )}
+        </DialogContent>
+        <DialogActions>
+          <Button onClick={() => dispatch(clearTestResult())}>Close</Button>
+        </DialogActions>
+      </Dialog>
+
       {/* Test Result Snackbar */}
       <Snackbar
         open={snackbarOpen}
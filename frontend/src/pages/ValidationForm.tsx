@@ -14,10 +14,17 @@ import {
   MenuItem,
   CircularProgress,
   Alert,
+  Autocomplete,
+  Switch,
+  FormControlLabel,
+  Divider,
 } from '@mui/material';
 import { createValidation, updateValidation, fetchValidation } from '../store/slices/validationSlice';
 import { fetchConnections } from '../store/slices/connectionSlice';
 import { AppDispatch, RootState } from '../store';
+import { connectionService } from '../services/connectionService';
+import { validationService } from '../services/validationService';
+import { Schedule, Validation } from '../types';
 
 export default function ValidationForm() {
   const navigate = useNavigate();
@@ -33,12 +40,66 @@ export default function ValidationForm() {
     config: {
       source_query: '',
       target_query: '',
-      comparison_type: 'row_count' as 'row_count' | 'data_match' | 'schema',
+      source_table: '',
+      target_table: '',
+      exclude_columns: [] as string[],
+      filter: '',
+      comparison_type: 'row_count' as 'row_count' | 'data_match' | 'column_stats' | 'schema',
       key_columns: [] as string[],
+      strategy: 'full' as 'full' | 'hash',
+      stats: [] as NonNullable<Validation['config']['stats']>,
+      sample: {} as NonNullable<Validation['config']['sample']>,
+      group_by: [] as string[],
     },
   });
 
   const [error, setError] = useState('');
+  const [variablesText, setVariablesText] = useState('');
+  const [schedules, setSchedules] = useState<Schedule[]>([]);
+  const [newSchedule, setNewSchedule] = useState({ cron: '', timezone: 'UTC' });
+  const [scheduleError, setScheduleError] = useState('');
+  const [useTables, setUseTables] = useState(false);
+  const [sourceTables, setSourceTables] = useState<string[]>([]);
+  const [targetTables, setTargetTables] = useState<string[]>([]);
+
+  useEffect(() => {
+    if (useTables && formData.source_connection_id) {
+      connectionService.getTables(formData.source_connection_id).then(setSourceTables).catch(() => setSourceTables([]));
+    }
+  }, [useTables, formData.source_connection_id]);
+
+  useEffect(() => {
+    if (useTables && formData.target_connection_id) {
+      connectionService.getTables(formData.target_connection_id).then(setTargetTables).catch(() => setTargetTables([]));
+    }
+  }, [useTables, formData.target_connection_id]);
+
+  useEffect(() => {
+    if (id) {
+      validationService.getSchedules(parseInt(id)).then(setSchedules).catch(() => setSchedules([]));
+    }
+  }, [id]);
+
+  const addSchedule = async () => {
+    setScheduleError('');
+    try {
+      const schedule = await validationService.createSchedule(parseInt(id!), newSchedule);
+      setSchedules([...schedules, schedule]);
+      setNewSchedule({ ...newSchedule, cron: '' });
+    } catch (err: any) {
+      setScheduleError(err.response?.data?.error || err.message);
+    }
+  };
+
+  const toggleSchedule = async (schedule: Schedule) => {
+    const updated = await validationService.updateSchedule(parseInt(id!), schedule.id, { ...schedule, enabled: !schedule.enabled });
+    setSchedules(schedules.map((s) => (s.id === schedule.id ? updated : s)));
+  };
+
+  const deleteSchedule = async (schedule: Schedule) => {
+    await validationService.deleteSchedule(parseInt(id!), schedule.id);
+    setSchedules(schedules.filter((s) => s.id !== schedule.id));
+  };
 
   useEffect(() => {
     dispatch(fetchConnections());
@@ -56,10 +117,22 @@ export default function ValidationForm() {
         config: {
           source_query: currentValidation.config.source_query || '',
           target_query: currentValidation.config.target_query || '',
+          source_table: currentValidation.config.source_table || '',
+          target_table: currentValidation.config.target_table || '',
+          exclude_columns: currentValidation.config.exclude_columns || [],
+          filter: currentValidation.config.filter || '',
           comparison_type: currentValidation.config.comparison_type || 'row_count',
           key_columns: currentValidation.config.key_columns || [],
+          strategy: currentValidation.config.strategy || 'full',
+          stats: currentValidation.config.stats || [],
+          sample: currentValidation.config.sample || {},
+          group_by: currentValidation.config.group_by || [],
         },
       });
+      setUseTables(!!currentValidation.config.source_table);
+      setVariablesText(Object.entries(currentValidation.config.variables || {})
+        .map(([name, value]) => `${name}=${value}`)
+        .join(', '));
     }
   }, [currentValidation, id]);
 
@@ -89,6 +162,22 @@ export default function ValidationForm() {
     try {
       const data = {
         ...formData,
+        config: {
+          ...formData.config,
+          source_query: useTables ? '' : formData.config.source_query,
+          target_query: useTables ? '' : formData.config.target_query,
+          source_table: useTables ? formData.config.source_table : '',
+          target_table: useTables ? formData.config.target_table : '',
+          exclude_columns: useTables ? formData.config.exclude_columns.filter(Boolean) : [],
+          filter: useTables ? formData.config.filter : '',
+          key_columns: formData.config.key_columns.filter(Boolean),
+          stats: formData.config.stats.filter((stat) => stat.column),
+          group_by: formData.config.group_by.filter(Boolean),
+          variables: Object.fromEntries(variablesText.split(',')
+            .map((pair) => pair.split('=').map((part) => part.trim()))
+            .filter(([name]) => name)
+            .map(([name, ...value]) => [name, value.join('=')])),
+        },
         status: 'pending' as const,
       };
       
@@ -177,34 +266,238 @@ export default function ValidationForm() {
             >
               <MenuItem value="row_count">Row Count</MenuItem>
               <MenuItem value="data_match">Data Match</MenuItem>
+              <MenuItem value="column_stats">Column Statistics</MenuItem>
               <MenuItem value="schema">Schema Comparison</MenuItem>
             </Select>
           </FormControl>
 
-          <TextField
-            fullWidth
-            label="Source Query"
-            name="source_query"
-            value={formData.config.source_query}
-            onChange={handleChange}
-            margin="normal"
-            multiline
-            rows={4}
-            placeholder="SELECT * FROM table_name"
-          />
+          <FormControl fullWidth margin="normal">
+            <InputLabel>Compare</InputLabel>
+            <Select
+              value={useTables ? 'table' : 'query'}
+              onChange={(e) => setUseTables(e.target.value === 'table')}
+              label="Compare"
+            >
+              <MenuItem value="query">Queries</MenuItem>
+              <MenuItem value="table">Tables</MenuItem>
+            </Select>
+          </FormControl>
+
+          {useTables ? (
+            <>
+              <Autocomplete
+                freeSolo
+                options={sourceTables}
+                value={formData.config.source_table}
+                onInputChange={(_, value) => setFormData({
+                  ...formData,
+                  config: { ...formData.config, source_table: value },
+                })}
+                renderInput={(params) => <TextField {...params} label="Source Table" margin="normal" />}
+              />
+
+              <Autocomplete
+                freeSolo
+                options={targetTables}
+                value={formData.config.target_table}
+                onInputChange={(_, value) => setFormData({
+                  ...formData,
+                  config: { ...formData.config, target_table: value },
+                })}
+                renderInput={(params) => <TextField {...params} label="Target Table" margin="normal" />}
+              />
+
+              <TextField
+                fullWidth
+                label="Exclude Columns"
+                value={formData.config.exclude_columns.join(', ')}
+                onChange={(e) => setFormData({
+                  ...formData,
+                  config: {
+                    ...formData.config,
+                    exclude_columns: e.target.value.split(',').map((column) => column.trim()),
+                  },
+                })}
+                margin="normal"
+                helperText="Optional comma separated columns to leave out, e.g. audit timestamps"
+              />
+
+              <TextField
+                fullWidth
+                label="Filter"
+                name="filter"
+                value={formData.config.filter}
+                onChange={handleChange}
+                margin="normal"
+                placeholder="order_date >= '2024-01-01'"
+                helperText="Optional WHERE condition applied to both tables"
+              />
+            </>
+          ) : (
+            <>
+            <TextField
+              fullWidth
+              label="Source Query"
+              name="source_query"
+              value={formData.config.source_query}
+              onChange={handleChange}
+              margin="normal"
+              multiline
+              rows={4}
+              placeholder="SELECT * FROM table_name"
+            />
+
+            <TextField
+              fullWidth
+              label="Target Query"
+              name="target_query"
+              value={formData.config.target_query}
+              onChange={handleChange}
+              margin="normal"
+              multiline
+              rows={4}
+              placeholder="SELECT * FROM table_name"
+            />
+            </>
+          )}
 
           <TextField
             fullWidth
-            label="Target Query"
-            name="target_query"
-            value={formData.config.target_query}
-            onChange={handleChange}
+            label="Variables"
+            value={variablesText}
+            onChange={(e) => setVariablesText(e.target.value)}
             margin="normal"
-            multiline
-            rows={4}
-            placeholder="SELECT * FROM table_name"
+            placeholder="region=EMEA"
+            helperText="Optional comma separated name=default pairs referenced as {{name}} in queries and filters; {{run_date}} is built in"
           />
 
+          {formData.config.comparison_type === 'data_match' && (
+            <>
+              <TextField
+                fullWidth
+                label="Key Columns"
+                value={formData.config.key_columns.join(', ')}
+                onChange={(e) => setFormData({
+                  ...formData,
+                  config: {
+                    ...formData.config,
+                    key_columns: e.target.value.split(',').map((column) => column.trim()),
+                  },
+                })}
+                margin="normal"
+                helperText="Comma separated columns that identify a row"
+              />
+
+              <FormControl fullWidth margin="normal">
+                <InputLabel>Strategy</InputLabel>
+                <Select
+                  name="strategy"
+                  value={formData.config.strategy}
+                  onChange={handleChange}
+                  label="Strategy"
+                >
+                  <MenuItem value="full">Full (fetch all rows)</MenuItem>
+                  <MenuItem value="hash">Hash (compare buckets in-database)</MenuItem>
+                </Select>
+              </FormControl>
+
+              <TextField
+                fullWidth
+                type="number"
+                label="Sample Rows"
+                value={formData.config.sample.rows || ''}
+                onChange={(e) => setFormData({
+                  ...formData,
+                  config: {
+                    ...formData.config,
+                    sample: { ...formData.config.sample, rows: parseInt(e.target.value) || undefined },
+                  },
+                })}
+                margin="normal"
+                helperText="Optional: compare a deterministic sample of this many source rows (full strategy only)"
+              />
+            </>
+          )}
+
+          {formData.config.comparison_type === 'column_stats' && (
+            <TextField
+              fullWidth
+              label="Columns"
+              value={formData.config.stats.map((stat) => stat.column).join(', ')}
+              onChange={(e) => setFormData({
+                ...formData,
+                config: {
+                  ...formData.config,
+                  stats: e.target.value.split(',').map((column) => ({
+                    ...formData.config.stats.find((stat) => stat.column === column.trim()),
+                    column: column.trim(),
+                  })),
+                },
+              })}
+              margin="normal"
+              helperText="Comma separated columns to compute sum, min, max, avg, distinct and null counts for"
+            />
+          )}
+
+          {(formData.config.comparison_type === 'row_count' || formData.config.comparison_type === 'column_stats') && (
+            <TextField
+              fullWidth
+              label="Group By"
+              value={formData.config.group_by.join(', ')}
+              onChange={(e) => setFormData({
+                ...formData,
+                config: {
+                  ...formData.config,
+                  group_by: e.target.value.split(',').map((column) => column.trim()),
+                },
+              })}
+              margin="normal"
+              helperText="Optional comma separated columns, e.g. a date or region, to compare per group"
+            />
+          )}
+
+          {id && (
+            <>
+              <Divider sx={{ my: 3 }} />
+              <Typography variant="h6" gutterBottom>
+                Schedules
+              </Typography>
+              {scheduleError && <Alert severity="error" sx={{ mb: 2 }}>{scheduleError}</Alert>}
+              {schedules.map((schedule) => (
+                <Box key={schedule.id} sx={{ display: 'flex', alignItems: 'center', gap: 2, mb: 1 }}>
+                  <FormControlLabel
+                    control={<Switch checked={schedule.enabled} onChange={() => toggleSchedule(schedule)} />}
+                    label={`${schedule.cron} (${schedule.timezone})`}
+                  />
+                  <Typography variant="body2" color="text.secondary" sx={{ flexGrow: 1 }}>
+                    {schedule.enabled && schedule.next_run_at && `Next run ${new Date(schedule.next_run_at).toLocaleString()}`}
+                  </Typography>
+                  <Button size="small" color="error" onClick={() => deleteSchedule(schedule)}>
+                    Delete
+                  </Button>
+                </Box>
+              ))}
+              <Box sx={{ display: 'flex', gap: 2, alignItems: 'center' }}>
+                <TextField
+                  label="Cron"
+                  value={newSchedule.cron}
+                  onChange={(e) => setNewSchedule({ ...newSchedule, cron: e.target.value })}
+                  placeholder="0 6 * * *"
+                  size="small"
+                />
+                <TextField
+                  label="Time Zone"
+                  value={newSchedule.timezone}
+                  onChange={(e) => setNewSchedule({ ...newSchedule, timezone: e.target.value })}
+                  size="small"
+                />
+                <Button variant="outlined" onClick={addSchedule} disabled={!newSchedule.cron}>
+                  Add Schedule
+                </Button>
+              </Box>
+            </>
+          )}
+
           <Box sx={{ mt: 3, display: 'flex', gap: 2 }}>
             <Button
               variant="contained"
@@ -21,6 +21,7 @@ import {
   Chip,
   Box,
   Tooltip,
+  LinearProgress,
 } from '@mui/material';
 import {
   Add as AddIcon,
@@ -31,6 +32,8 @@ import {
 } from '@mui/icons-material';
 import { fetchValidations, deleteValidation, runValidation, fetchValidation } from '../store/slices/validationSlice';
 import { AppDispatch, RootState } from '../store';
+import { validationService } from '../services/validationService';
+import { ValidationProgress } from '../types';
 import ValidationExecutionReport from '../components/ValidationExecutionReport';
 
 export default function Validations() {
@@ -41,6 +44,7 @@ export default function Validations() {
   const [selectedValidation, setSelectedValidation] = useState<number | null>(null);
   const [reportOpen, setReportOpen] = useState(false);
   const [runningValidation, setRunningValidation] = useState<number | null>(null);
+  const [progress, setProgress] = useState<ValidationProgress | null>(null);
 
   useEffect(() => {
     dispatch(fetchValidations());
@@ -62,16 +66,32 @@ export default function Validations() {
     // Fetch the validation details first
     await dispatch(fetchValidation(id));
     
-    // Run the validation
+    // The run executes in the background; poll its progress until it ends
     const result = await dispatch(runValidation(id));
+    if (runValidation.fulfilled.match(result)) {
+      await new Promise<void>((resolve) => {
+        const poll = setInterval(async () => {
+          try {
+            const status = await validationService.getValidationStatus(id);
+            setProgress(status.progress || null);
+            if (status.status !== 'running' && status.status !== 'waiting') {
+              clearInterval(poll);
+              resolve();
+            }
+          } catch {
+            clearInterval(poll);
+            resolve();
+          }
+        }, 2000);
+      });
+    }
+    setProgress(null);
     
     // Refresh validations list
     await dispatch(fetchValidations());
     
     // Update current validation with results
-    if (result.payload) {
-      await dispatch(fetchValidation(id));
-    }
+    await dispatch(fetchValidation(id));
     
     setRunningValidation(null);
   };
@@ -88,6 +108,8 @@ export default function Validations() {
         return 'success';
       case 'running':
         return 'info';
+      case 'waiting':
+        return 'warning';
       case 'failed':
         return 'error';
       default:
@@ -132,6 +154,18 @@ export default function Validations() {
                     size="small"
                     color={getStatusColor(validation.status)}
                   />
+                  {runningValidation === validation.id && progress && progress.chunks_total > 1 && (
+                    <Box sx={{ mt: 1 }}>
+                      <LinearProgress
+                        variant="determinate"
+                        value={(progress.chunks_done / progress.chunks_total) * 100}
+                      />
+                      <Typography variant="caption" color="text.secondary">
+                        {progress.chunks_done}/{progress.chunks_total} chunks
+                        {progress.chunks?.some((chunk) => chunk.status === 'retrying') && ' (retrying)'}
+                      </Typography>
+                    </Box>
+                  )}
                 </TableCell>
                 <TableCell>
                   {validation.results?.summary?.success_rate
@@ -1,7 +1,12 @@
 import api from './api';
-import { Connection, ColumnInfo } from '../types';
+import { Connection, ColumnInfo, ConnectorDescriptor, ConnectionTestResult, ParsedDSN } from '../types';
 
 export const connectionService = {
+  async getConnectors(): Promise<ConnectorDescriptor[]> {
+    const response = await api.get('/connectors');
+    return response.data;
+  },
+
   async getConnections(): Promise<Connection[]> {
     const response = await api.get('/connections');
     return response.data;
@@ -26,8 +31,16 @@ export const connectionService = {
     await api.delete(`/connections/${id}`);
   },
 
-  async testConnection(id: number): Promise<{ success: boolean; message: string }> {
-    const response = await api.post(`/connections/${id}/test`);
+  async parseDSN(type: string, dsn: string): Promise<ParsedDSN> {
+    const response = await api.post('/connections/parse-dsn', { type, dsn });
+    return response.data;
+  },
+
+  async testConnection(id: number): Promise<ConnectionTestResult> {
+    // A failed test answers 400 with the same report
+    const response = await api.post(`/connections/${id}/test`, undefined, {
+      validateStatus: (status) => (status >= 200 && status < 300) || status === 400,
+    });
     return response.data;
   },
 
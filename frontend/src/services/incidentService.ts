@@ -0,0 +1,19 @@
+import api from './api';
+import { Incident } from '../types';
+
+export const incidentService = {
+  async getIncidents(status?: Incident['status'] | 'all'): Promise<Incident[]> {
+    const response = await api.get('/incidents', { params: { status } });
+    return response.data;
+  },
+
+  async getValidationIncidents(validationId: number): Promise<Incident[]> {
+    const response = await api.get(`/validations/${validationId}/incidents`);
+    return response.data;
+  },
+
+  async acknowledgeIncident(id: number, note?: string): Promise<Incident> {
+    const response = await api.post(`/incidents/${id}/acknowledge`, { note });
+    return response.data;
+  },
+};
\ No newline at end of file
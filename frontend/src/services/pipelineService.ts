@@ -0,0 +1,38 @@
+import api from './api';
+import { Pipeline, PipelineRun } from '../types';
+
+export const pipelineService = {
+  async getPipelines(): Promise<Pipeline[]> {
+    const response = await api.get('/pipelines');
+    return response.data;
+  },
+
+  async getPipeline(id: number): Promise<Pipeline> {
+    const response = await api.get(`/pipelines/${id}`);
+    return response.data;
+  },
+
+  async createPipeline(data: Omit<Pipeline, 'id' | 'status'>): Promise<Pipeline> {
+    const response = await api.post('/pipelines', data);
+    return response.data;
+  },
+
+  async updatePipeline(id: number, data: Omit<Pipeline, 'id' | 'status'>): Promise<Pipeline> {
+    const response = await api.put(`/pipelines/${id}`, data);
+    return response.data;
+  },
+
+  async deletePipeline(id: number): Promise<void> {
+    await api.delete(`/pipelines/${id}`);
+  },
+
+  async runPipeline(id: number): Promise<PipelineRun> {
+    const response = await api.post(`/pipelines/${id}/run`);
+    return response.data;
+  },
+
+  async getPipelineRuns(id: number): Promise<PipelineRun[]> {
+    const response = await api.get(`/pipelines/${id}/runs`);
+    return response.data;
+  },
+};
\ No newline at end of file
@@ -0,0 +1,43 @@
+import api from './api';
+import { Suite, SuiteRun } from '../types';
+
+export const suiteService = {
+  async getSuites(): Promise<Suite[]> {
+    const response = await api.get('/suites');
+    return response.data;
+  },
+
+  async getSuite(id: number): Promise<Suite> {
+    const response = await api.get(`/suites/${id}`);
+    return response.data;
+  },
+
+  async createSuite(data: Omit<Suite, 'id' | 'status'>): Promise<Suite> {
+    const response = await api.post('/suites', data);
+    return response.data;
+  },
+
+  async updateSuite(id: number, data: Omit<Suite, 'id' | 'status'>): Promise<Suite> {
+    const response = await api.put(`/suites/${id}`, data);
+    return response.data;
+  },
+
+  async deleteSuite(id: number): Promise<void> {
+    await api.delete(`/suites/${id}`);
+  },
+
+  async runSuite(id: number): Promise<SuiteRun> {
+    const response = await api.post(`/suites/${id}/run`);
+    return response.data;
+  },
+
+  async getSuiteRuns(id: number, days?: number): Promise<SuiteRun[]> {
+    const response = await api.get(`/suites/${id}/runs`, { params: { days } });
+    return response.data;
+  },
+
+  async getSuiteRun(id: number, runId: number | 'latest', status?: string): Promise<SuiteRun> {
+    const response = await api.get(`/suites/${id}/runs/${runId}`, { params: { status } });
+    return response.data;
+  },
+};
\ No newline at end of file
@@ -1,5 +1,5 @@
 import api from './api';
-import { Validation } from '../types';
+import { Schedule, Validation, ValidationRun, ValidationStatus } from '../types';
 
 export const validationService = {
   async getValidations(): Promise<Validation[]> {
@@ -26,12 +26,36 @@ export const validationService = {
     await api.delete(`/validations/${id}`);
   },
 
-  async runValidation(id: number): Promise<Validation> {
-    const response = await api.post(`/validations/${id}/run`);
+  async runValidation(id: number, parameters?: Record<string, string>): Promise<ValidationRun> {
+    const response = await api.post(`/validations/${id}/run`, parameters ? { parameters } : undefined);
     return response.data;
   },
 
-  async getValidationStatus(id: number): Promise<{ id: number; status: string; updated_at: string }> {
+  async getValidationHistory(id: number, days?: number): Promise<ValidationRun[]> {
+    const response = await api.get(`/validations/${id}/history`, { params: { days } });
+    return response.data;
+  },
+
+  async getSchedules(id: number): Promise<Schedule[]> {
+    const response = await api.get(`/validations/${id}/schedules`);
+    return response.data;
+  },
+
+  async createSchedule(id: number, data: Partial<Schedule>): Promise<Schedule> {
+    const response = await api.post(`/validations/${id}/schedules`, data);
+    return response.data;
+  },
+
+  async updateSchedule(id: number, scheduleId: number, data: Partial<Schedule>): Promise<Schedule> {
+    const response = await api.put(`/validations/${id}/schedules/${scheduleId}`, data);
+    return response.data;
+  },
+
+  async deleteSchedule(id: number, scheduleId: number): Promise<void> {
+    await api.delete(`/validations/${id}/schedules/${scheduleId}`);
+  },
+
+  async getValidationStatus(id: number): Promise<ValidationStatus> {
     const response = await api.get(`/validations/${id}/status`);
     return response.data;
   },
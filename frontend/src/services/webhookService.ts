@@ -0,0 +1,38 @@
+import api from './api';
+import { Webhook, WebhookDelivery } from '../types';
+
+export const webhookService = {
+  async getWebhooks(): Promise<Webhook[]> {
+    const response = await api.get('/webhooks');
+    return response.data;
+  },
+
+  async getWebhook(id: number): Promise<Webhook> {
+    const response = await api.get(`/webhooks/${id}`);
+    return response.data;
+  },
+
+  async createWebhook(data: Omit<Webhook, 'id'>): Promise<Webhook> {
+    const response = await api.post('/webhooks', data);
+    return response.data;
+  },
+
+  async updateWebhook(id: number, data: Omit<Webhook, 'id'>): Promise<Webhook> {
+    const response = await api.put(`/webhooks/${id}`, data);
+    return response.data;
+  },
+
+  async deleteWebhook(id: number): Promise<void> {
+    await api.delete(`/webhooks/${id}`);
+  },
+
+  async testWebhook(id: number): Promise<WebhookDelivery> {
+    const response = await api.post(`/webhooks/${id}/test`);
+    return response.data;
+  },
+
+  async getDeliveries(id: number, params?: { limit?: number; status?: string }): Promise<WebhookDelivery[]> {
+    const response = await api.get(`/webhooks/${id}/deliveries`, { params });
+    return response.data;
+  },
+};
\ No newline at end of file
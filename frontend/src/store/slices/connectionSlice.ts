@@ -1,13 +1,13 @@
 import { createSlice, createAsyncThunk } from '@reduxjs/toolkit';
 import { connectionService } from '../../services/connectionService';
-import { Connection } from '../../types';
+import { Connection, ConnectionTestResult } from '../../types';
 
 interface ConnectionState {
   connections: Connection[];
   currentConnection: Connection | null;
   loading: boolean;
   error: string | null;
-  testResult: { success: boolean; message: string } | null;
+  testResult: ConnectionTestResult | null;
 }
 
 const initialState: ConnectionState = {
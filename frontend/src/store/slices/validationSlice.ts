@@ -93,9 +93,9 @@ const validationSlice = createSlice({
       })
       // Update
       .addCase(updateValidation.fulfilled, (state, action) => {
-        const index = state.validations.findIndex((v) => v.id === action.payload.id);
+        const index = state.validations.findIndex((v) => v.id === action.payload.validation_id);
         if (index !== -1) {
-          state.validations[index] = action.payload;
+          state.validations[index].status = action.payload.status;
         }
       })
       // Delete
@@ -104,9 +104,9 @@ const validationSlice = createSlice({
       })
       // Run
       .addCase(runValidation.fulfilled, (state, action) => {
-        const index = state.validations.findIndex((v) => v.id === action.payload.id);
+        const index = state.validations.findIndex((v) => v.id === action.payload.validation_id);
         if (index !== -1) {
-          state.validations[index] = action.payload;
+          state.validations[index].status = action.payload.status;
         }
       });
   },
@@ -7,19 +7,83 @@ export interface User {
 export interface Connection {
   id: number;
   name: string;
-  type: 'sqlserver' | 'databricks';
-  config: {
-    server?: string;
-    port?: number;
-    database?: string;
-    username?: string;
-    password?: string;
-    encrypt?: boolean;
-    trust_server_certificate?: boolean;
-    workspace?: string;
-    http_path?: string;
-    access_token?: string;
-  };
+  type: string;
+  config: Record<string, any>;
+  execution_windows?: ExecutionWindow[];
+  max_concurrent_queries?: number;
+  created_at?: string;
+  updated_at?: string;
+}
+
+export interface ExecutionWindow {
+  days?: Array<'mon' | 'tue' | 'wed' | 'thu' | 'fri' | 'sat' | 'sun'>;
+  start: string;
+  end: string;
+  timezone?: string;
+}
+
+export interface ConfigSchemaProperty {
+  type: 'string' | 'integer' | 'boolean' | 'object';
+  title: string;
+  description?: string;
+  default?: any;
+  enum?: string[];
+  examples?: string[];
+  format?: string;
+  writeOnly?: boolean;
+  propertyOrder?: number;
+  'x-multiline'?: boolean;
+  'x-required-unless'?: string;
+  properties?: Record<string, ConfigSchemaProperty>;
+  required?: string[];
+}
+
+export interface ConfigSchema {
+  title: string;
+  type: 'object';
+  properties: Record<string, ConfigSchemaProperty>;
+  required?: string[];
+}
+
+export interface ConnectorCapabilities {
+  supports_catalogs: boolean;
+  supports_hash_pushdown: boolean;
+  supports_sampling: boolean;
+  supports_ssh_tunnel: boolean;
+  supports_client_cert_tls: boolean;
+  supports_dsn_import: boolean;
+}
+
+export interface ConnectorDescriptor {
+  type: string;
+  title: string;
+  config_schema: ConfigSchema;
+  capabilities: ConnectorCapabilities;
+}
+
+export interface NotificationSettings {
+  emails?: string[]; // alerted when a run fails
+  digest?: boolean; // also send emails the daily digest
+  mode?: 'failure_only' | 'status_change' | 'always'; // runs announced by email and chat
+  repeat_after_runs?: number; // status_change reminders while a failure continues
+  repeat_after_minutes?: number;
+}
+
+export interface Incident {
+  id: number;
+  validation_id: number;
+  status: 'open' | 'acknowledged' | 'resolved';
+  first_run_id: number;
+  last_run_id: number;
+  failed_runs: number;
+  notified_at: string;
+  notified_runs: number;
+  acknowledged_at?: string;
+  acknowledged_by?: number;
+  note?: string;
+  opened_at: string;
+  resolved_at?: string;
+  resolved_run_id?: number;
   created_at?: string;
   updated_at?: string;
 }
@@ -34,15 +98,71 @@ export interface Validation {
   config: {
     source_query?: string;
     target_query?: string;
-    comparison_type?: 'row_count' | 'data_match' | 'schema';
+    source_table?: string;
+    target_table?: string;
+    include_columns?: string[];
+    exclude_columns?: string[];
+    filter?: string;
+    target_filter?: string;
+    comparison_type?: 'row_count' | 'data_match' | 'column_stats' | 'schema';
     key_columns?: string[];
+    stats?: Array<{
+      column: string;
+      metrics?: Array<'sum' | 'min' | 'max' | 'avg' | 'count_distinct' | 'null_count'>;
+    }>;
+    group_by?: string[];
+    sample?: {
+      percent?: number;
+      rows?: number;
+      seed?: number;
+      method?: 'hash_mod';
+    };
+    column_mapping?: Array<{
+      source: string;
+      target: string;
+      source_transform?: string;
+      target_transform?: string;
+    }>;
+    unmapped_columns?: 'ignore' | 'flag';
+    column_rules?: Record<string, {
+      absolute_tolerance?: number;
+      relative_tolerance?: number;
+      float_epsilon?: number;
+      truncate_to?: 'day' | 'hour' | 'minute' | 'second' | 'millisecond' | 'microsecond';
+      case_insensitive?: boolean;
+      ignore_whitespace?: boolean;
+      null_equals_empty?: boolean;
+    }>;
+    performance?: {
+      batch_size?: number;
+      timeout_seconds?: number;
+      max_differences?: number;
+      chunks?: number;
+      concurrency?: number;
+      chunk_retries?: number;
+    };
+    strategy?: 'full' | 'hash';
+    hash?: {
+      buckets?: number;
+      leaf_size?: number;
+    };
+    variables?: Record<string, string>;
+    retry?: {
+      max_attempts?: number;
+      backoff_seconds?: number;
+      backoff_multiplier?: number;
+      max_backoff_seconds?: number;
+      retry_on?: ErrorClass[];
+    };
   };
-  status: 'pending' | 'running' | 'completed' | 'failed';
+  notifications?: NotificationSettings;
+  status: 'pending' | 'running' | 'waiting' | 'completed' | 'failed';
   results?: {
     execution_id?: string;
     start_time?: string;
     end_time?: string;
     duration_ms?: number;
+    variables?: Record<string, string>;
     summary?: {
       source_row_count?: number;
       target_row_count?: number;
@@ -50,9 +170,40 @@ export interface Validation {
       mismatched_rows?: number;
       missing_in_target?: number;
       extra_in_target?: number;
+      duplicate_rows?: number;
       success_rate?: number;
     };
     details?: {
+      source_query?: string;
+      target_query?: string;
+      strategy?: 'full' | 'hash';
+      truncated?: boolean;
+      unmapped_columns?: {
+        source: string[];
+        target: string[];
+      };
+      rule_matches?: Array<{
+        column: string;
+        rule: string;
+        rows: number;
+        sample_keys?: any[];
+      }>;
+      chunks?: number;
+      sample?: {
+        method: string;
+        seed: number;
+        percent?: number;
+        rows?: number;
+        sampled_rows: number;
+      };
+      hash?: {
+        buckets: number;
+        levels: number;
+        buckets_compared: number;
+        buckets_mismatched: number;
+        rows_fetched: number;
+        queries: number;
+      };
       differences?: Array<{
         key: any;
         type: 'missing' | 'extra' | 'mismatch';
@@ -60,25 +211,221 @@ export interface Validation {
         target_data?: any;
         columns?: string[];
       }>;
-      column_stats?: Record<string, {
-        source_min?: any;
-        source_max?: any;
-        source_avg?: number;
-        target_min?: any;
-        target_max?: any;
-        target_avg?: number;
+      column_stats?: Record<string, ColumnStats>;
+      groups?: Array<{
+        group: Record<string, any>;
+        type: 'missing' | 'extra' | 'mismatch';
+        source_rows: number;
+        target_rows: number;
+        difference: number;
+        columns?: Record<string, ColumnStats>;
       }>;
     };
     errors?: Array<string | {
       timestamp?: string;
       message: string;
       details?: string;
+      class?: ErrorClass;
     }>;
   };
   created_at?: string;
   updated_at?: string;
 }
 
+export type ErrorClass = 'connection' | 'timeout' | 'deadlock' | 'unavailable' | 'other';
+
+export interface RunAttempt {
+  attempt: number;
+  execution_id: string;
+  status: 'success' | 'failure' | 'error';
+  error?: string;
+  error_class?: ErrorClass;
+  started_at: string;
+  finished_at: string;
+}
+
+export interface ValidationRun {
+  id: number;
+  validation_id: number;
+  execution_id?: string;
+  trigger: 'manual' | 'scheduled' | 'pipeline' | 'suite';
+  schedule_id?: number;
+  scheduled_for?: string;
+  pipeline_run_id?: number;
+  suite_run_id?: number;
+  status: 'pending' | 'running' | 'waiting' | 'completed' | 'failed';
+  status_reason?: string;
+  variables: Record<string, string>;
+  results?: Validation['results'];
+  attempts: RunAttempt[];
+  started_at: string;
+  finished_at?: string;
+  created_at?: string;
+}
+
+export type RunCondition = 'on_success' | 'on_failure' | 'always';
+
+export interface PipelineNode {
+  key: string;
+  validation_id: number;
+  depends_on?: Array<{
+    node: string;
+    condition?: RunCondition;
+  }>;
+}
+
+export interface Pipeline {
+  id: number;
+  name: string;
+  description?: string;
+  nodes: PipelineNode[];
+  status: 'pending' | 'running' | 'completed' | 'failed';
+  created_at?: string;
+  updated_at?: string;
+}
+
+export interface PipelineRun {
+  id: number;
+  pipeline_id: number;
+  status: 'running' | 'completed' | 'failed';
+  nodes: Array<{
+    key: string;
+    validation_id: number;
+    status: 'pending' | 'running' | 'completed' | 'failed' | 'skipped';
+    run_id?: number;
+    reason?: string;
+    started_at?: string;
+    finished_at?: string;
+  }>;
+  started_at: string;
+  finished_at?: string;
+  created_at?: string;
+}
+
+export interface Suite {
+  id: number;
+  name: string;
+  description?: string;
+  validation_ids: number[];
+  notifications?: NotificationSettings;
+  status: 'pending' | 'running' | 'completed' | 'failed';
+  created_at?: string;
+  updated_at?: string;
+}
+
+export interface SuiteRun {
+  id: number;
+  suite_id: number;
+  status: 'running' | 'completed' | 'failed';
+  summary: {
+    total: number;
+    passed: number;
+    failed: number;
+    errored: number;
+    pending: number;
+  };
+  members: Array<{
+    validation_id: number;
+    name: string;
+    status: 'pending' | 'running' | 'waiting' | 'completed' | 'failed';
+    result?: 'success' | 'failure' | 'error';
+    run_id?: number;
+    error?: string;
+  }>;
+  started_at: string;
+  finished_at?: string;
+  created_at?: string;
+}
+
+export type WebhookEvent =
+  | 'run.completed'
+  | 'run.failed'
+  | 'run.threshold_breached'
+  | 'suite.completed'
+  | 'suite.failed'
+  | 'schedule.missed';
+
+export interface Webhook {
+  id: number;
+  name: string;
+  url: string;
+  type: 'json' | 'slack' | 'teams';
+  events: WebhookEvent[];
+  enabled: boolean;
+  secret?: string; // only returned when set
+  created_at?: string;
+  updated_at?: string;
+}
+
+export interface WebhookDelivery {
+  id: number;
+  webhook_id: number;
+  event: WebhookEvent | 'ping';
+  payload: string;
+  status: 'pending' | 'delivered' | 'failed';
+  attempts: number;
+  response_status?: number;
+  error?: string;
+  next_attempt_at?: string;
+  delivered_at?: string;
+  created_at: string;
+  updated_at?: string;
+}
+
+export interface Schedule {
+  id: number;
+  validation_id: number;
+  cron: string;
+  timezone: string;
+  enabled: boolean;
+  missed_policy: 'skip' | 'catch_up';
+  parameters?: Record<string, string>;
+  next_run_at?: string;
+  last_run_at?: string;
+  created_at?: string;
+  updated_at?: string;
+}
+
+export interface ColumnStats {
+  source_sum?: any;
+  target_sum?: any;
+  source_min?: any;
+  source_max?: any;
+  source_avg?: number;
+  target_min?: any;
+  target_max?: any;
+  target_avg?: number;
+  source_count_distinct?: number;
+  target_count_distinct?: number;
+  source_null_count?: number;
+  target_null_count?: number;
+  mismatched?: string[];
+}
+
+export interface ValidationProgress {
+  stage: 'planning' | 'comparing';
+  chunks_total: number;
+  chunks_done: number;
+  rows_processed: number;
+  chunks?: Array<{
+    index: number;
+    status: 'pending' | 'running' | 'retrying' | 'done' | 'failed';
+    attempts: number;
+    rows: number;
+    error?: string;
+  }>;
+}
+
+export interface ValidationStatus {
+  id: number;
+  name: string;
+  status: string;
+  status_reason?: string;
+  updated_at: string;
+  run_id?: number;
+  progress?: ValidationProgress;
+}
+
 export interface TableInfo {
   name: string;
   columns?: ColumnInfo[];
@@ -88,4 +435,32 @@ export interface ColumnInfo {
   name: string;
   data_type: string;
   nullable: boolean;
-}
\ No newline at end of file
+}
+
+export interface ParsedDSN {
+  type: string;
+  config: Record<string, any>;
+  valid: boolean;
+  validation_error?: string;
+}
+
+export interface DiagnosticStep {
+  name: string;
+  status: 'passed' | 'failed' | 'skipped';
+  latency_ms: number;
+  message?: string;
+  hint?: string;
+}
+
+export interface ConnectionTestResult {
+  success: boolean;
+  message: string;
+  steps?: DiagnosticStep[];
+  server?: {
+    version?: string;
+    current_user?: string;
+    database?: string;
+    privileges: string[] | null;
+    error?: string;
+  };
+}
@@ -24,9 +24,16 @@ func NewConnectionHandler(db *gorm.DB) *ConnectionHandler {
 }
 
 type CreateConnectionRequest struct {
-	Name   string                   `json:"name" binding:"required"`
-	Type   models.ConnectionType    `json:"type" binding:"required"`
-	Config models.ConnectionConfig  `json:"config" binding:"required"`
+	Name                 string                   `json:"name" binding:"required"`
+	Type                 models.ConnectionType    `json:"type" binding:"required"`
+	Config               models.ConnectionConfig  `json:"config" binding:"required"`
+	ExecutionWindows     models.ExecutionWindows  `json:"execution_windows"`
+	MaxConcurrentQueries int                      `json:"max_concurrent_queries" binding:"min=0"`
+}
+
+type ParseDSNRequest struct {
+	Type models.ConnectionType `json:"type" binding:"required"`
+	DSN  string                `json:"dsn" binding:"required"`
 }
 
 func (h *ConnectionHandler) List(c *gin.Context) {
@@ -70,12 +77,18 @@ func (h *ConnectionHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := req.ExecutionWindows.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	connection := &models.Connection{
-		Name:   req.Name,
-		Type:   req.Type,
-		Config: req.Config,
-		UserID: userID,
+		Name:                 req.Name,
+		Type:                 req.Type,
+		Config:               req.Config,
+		ExecutionWindows:     req.ExecutionWindows,
+		MaxConcurrentQueries: req.MaxConcurrentQueries,
+		UserID:               userID,
 	}
 
 	if err := h.db.Create(connection).Error; err != nil {
@@ -109,10 +122,16 @@ func (h *ConnectionHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := req.ExecutionWindows.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	connection.Name = req.Name
 	connection.Type = req.Type
 	connection.Config = req.Config
+	connection.ExecutionWindows = req.ExecutionWindows
+	connection.MaxConcurrentQueries = req.MaxConcurrentQueries
 
 	if err := h.db.Save(&connection).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update connection"})
@@ -144,6 +163,34 @@ func (h *ConnectionHandler) Delete(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Connection deleted successfully"})
 }
 
+// ParseDSN converts a connection string into a connection config for review.
+// The config is returned even when it is incomplete, along with the reason.
+func (h *ConnectionHandler) ParseDSN(c *gin.Context) {
+	var req ParseDSNRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	config, err := h.service.ParseDSN(req.Type, req.DSN)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"type":   req.Type,
+		"config": config,
+		"valid":  true,
+	}
+	if err := h.service.ValidateConnectionConfig(req.Type, config); err != nil {
+		response["valid"] = false
+		response["validation_error"] = err.Error()
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 func (h *ConnectionHandler) Test(c *gin.Context) {
 	userID := c.GetUint("user_id")
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -162,19 +209,21 @@ func (h *ConnectionHandler) Test(c *gin.Context) {
 		return
 	}
 
-	// Test the connection
-	if err := h.service.TestConnection(&connection); err != nil {
+	// Run the staged connection test; the steps are reported either way
+	report, err := h.service.DiagnoseConnection(c.Request.Context(), &connection)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": err.Error(),
 		})
 		return
 	}
+	if !report.Success {
+		c.JSON(http.StatusBadRequest, report)
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "Connection test successful",
-	})
+	c.JSON(http.StatusOK, report)
 }
 
 func (h *ConnectionHandler) GetTables(c *gin.Context) {
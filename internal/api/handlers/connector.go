@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/compareflow/compareflow/internal/services"
+)
+
+type ConnectorHandler struct {
+	service *services.ConnectionService
+}
+
+func NewConnectorHandler() *ConnectorHandler {
+	return &ConnectorHandler{
+		service: services.NewConnectionService(),
+	}
+}
+
+// List returns the config schema and capabilities of every registered connector
+func (h *ConnectorHandler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.DescribeConnectors())
+}
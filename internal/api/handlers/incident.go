@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+)
+
+type IncidentHandler struct {
+	db *gorm.DB
+}
+
+func NewIncidentHandler(db *gorm.DB) *IncidentHandler {
+	return &IncidentHandler{db: db}
+}
+
+// AcknowledgeRequest is the optional body of an acknowledgement
+type AcknowledgeRequest struct {
+	Note string `json:"note"`
+}
+
+// List returns the user's incidents, newest first. The status query
+// parameter filters them; by default only incidents that are not resolved
+// are returned.
+func (h *IncidentHandler) List(c *gin.Context) {
+	query := h.db.Joins("JOIN validations ON validations.id = incidents.validation_id").
+		Where("validations.user_id = ?", c.GetUint("user_id"))
+	switch status := c.Query("status"); status {
+	case "":
+		query = query.Where("incidents.status <> ?", models.IncidentResolved)
+	case "all":
+	default:
+		query = query.Where("incidents.status = ?", status)
+	}
+
+	var incidents []models.Incident
+	if err := query.Order("incidents.id DESC").Find(&incidents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incidents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, incidents)
+}
+
+// ValidationIncidents returns the incidents of a validation, newest first
+func (h *IncidentHandler) ValidationIncidents(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid validation ID"})
+		return
+	}
+
+	var count int64
+	if err := h.db.Model(&models.Validation{}).Where("id = ? AND user_id = ?", id, c.GetUint("user_id")).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch validation"})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Validation not found"})
+		return
+	}
+
+	var incidents []models.Incident
+	if err := h.db.Where("validation_id = ?", id).Order("id DESC").Find(&incidents).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incidents"})
+		return
+	}
+
+	c.JSON(http.StatusOK, incidents)
+}
+
+// Acknowledge silences the failure alerts of an open incident until the
+// validation recovers
+func (h *IncidentHandler) Acknowledge(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid incident ID"})
+		return
+	}
+
+	var req AcknowledgeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var incident models.Incident
+	if err := h.db.Joins("JOIN validations ON validations.id = incidents.validation_id").
+		Where("incidents.id = ? AND validations.user_id = ?", id, userID).First(&incident).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Incident not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incident"})
+		}
+		return
+	}
+	if incident.Status == models.IncidentResolved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Incident is already resolved"})
+		return
+	}
+
+	now := time.Now().UTC()
+	result := h.db.Model(&models.Incident{}).Where("id = ? AND status = ?", incident.ID, incident.Status).
+		Updates(map[string]interface{}{
+			"status":          models.IncidentAcknowledged,
+			"acknowledged_at": now,
+			"acknowledged_by": userID,
+			"note":            req.Note,
+		})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acknowledge incident"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Incident changed, try again"})
+		return
+	}
+
+	h.db.First(&incident, incident.ID)
+	c.JSON(http.StatusOK, incident)
+}
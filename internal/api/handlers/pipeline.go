@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/services"
+)
+
+type PipelineHandler struct {
+	db        *gorm.DB
+	pipelines *services.PipelineService
+	executor  *services.Executor
+}
+
+func NewPipelineHandler(db *gorm.DB, pipelines *services.PipelineService, executor *services.Executor) *PipelineHandler {
+	return &PipelineHandler{db: db, pipelines: pipelines, executor: executor}
+}
+
+type PipelineRequest struct {
+	Name        string               `json:"name" binding:"required"`
+	Description string               `json:"description"`
+	Nodes       models.PipelineNodes `json:"nodes" binding:"required"`
+}
+
+func (h *PipelineHandler) List(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var pipelines []models.Pipeline
+	if err := h.db.Where("user_id = ?", userID).Find(&pipelines).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pipelines"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pipelines)
+}
+
+func (h *PipelineHandler) Get(c *gin.Context) {
+	pipeline, ok := h.pipeline(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, pipeline)
+}
+
+func (h *PipelineHandler) Create(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req PipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkNodes(c, req.Nodes) {
+		return
+	}
+
+	pipeline := &models.Pipeline{
+		Name:        req.Name,
+		Description: req.Description,
+		Nodes:       req.Nodes,
+		UserID:      userID,
+		Status:      models.ValidationStatusPending,
+	}
+
+	if err := h.db.Create(pipeline).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create pipeline"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, pipeline)
+}
+
+func (h *PipelineHandler) Update(c *gin.Context) {
+	pipeline, ok := h.pipeline(c)
+	if !ok {
+		return
+	}
+
+	var req PipelineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkNodes(c, req.Nodes) {
+		return
+	}
+
+	pipeline.Name = req.Name
+	pipeline.Description = req.Description
+	pipeline.Nodes = req.Nodes
+
+	if err := h.db.Save(pipeline).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pipeline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pipeline)
+}
+
+func (h *PipelineHandler) Delete(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pipeline ID"})
+		return
+	}
+
+	result := h.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Pipeline{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete pipeline"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pipeline not found"})
+		return
+	}
+	h.db.Where("pipeline_id = ?", id).Delete(&models.PipelineRun{})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Pipeline deleted successfully"})
+}
+
+// Run records a run of the pipeline and returns it while the nodes run in
+// the background
+func (h *PipelineHandler) Run(c *gin.Context) {
+	pipeline, ok := h.pipeline(c)
+	if !ok {
+		return
+	}
+	if _, err := services.OrderPipeline(pipeline.Nodes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	run, err := h.pipelines.Start(pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record pipeline run"})
+		return
+	}
+
+	started := *run
+	started.Nodes = append(models.PipelineNodeRuns(nil), run.Nodes...)
+	h.executor.Go(func(ctx context.Context) {
+		h.pipelines.Execute(ctx, pipeline, run)
+	})
+
+	c.JSON(http.StatusAccepted, started)
+}
+
+// Runs lists the runs of a pipeline, most recent first
+func (h *PipelineHandler) Runs(c *gin.Context) {
+	pipeline, ok := h.pipeline(c)
+	if !ok {
+		return
+	}
+
+	var runs []models.PipelineRun
+	if err := h.db.Where("pipeline_id = ?", pipeline.ID).Order("id DESC").Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pipeline runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// checkNodes validates the graph and that every node's validation belongs
+// to the user
+func (h *PipelineHandler) checkNodes(c *gin.Context, nodes models.PipelineNodes) bool {
+	if _, err := services.OrderPipeline(nodes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	userID := c.GetUint("user_id")
+	for _, node := range nodes {
+		var count int64
+		if err := h.db.Model(&models.Validation{}).Where("id = ? AND user_id = ?", node.ValidationID, userID).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch validation"})
+			return false
+		}
+		if count == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid validation for node " + strconv.Quote(node.Key)})
+			return false
+		}
+	}
+	return true
+}
+
+// pipeline returns the user's pipeline named in the path
+func (h *PipelineHandler) pipeline(c *gin.Context) (*models.Pipeline, bool) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pipeline ID"})
+		return nil, false
+	}
+
+	var pipeline models.Pipeline
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&pipeline).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Pipeline not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pipeline"})
+		}
+		return nil, false
+	}
+	return &pipeline, true
+}
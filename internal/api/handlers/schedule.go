@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/services"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+type ScheduleHandler struct {
+	db *gorm.DB
+}
+
+func NewScheduleHandler(db *gorm.DB) *ScheduleHandler {
+	return &ScheduleHandler{db: db}
+}
+
+type ScheduleRequest struct {
+	Cron         string              `json:"cron" binding:"required"`
+	Timezone     string              `json:"timezone"`
+	Enabled      *bool               `json:"enabled"`
+	MissedPolicy string              `json:"missed_policy"`
+	Parameters   models.RunVariables `json:"parameters"`
+}
+
+func (h *ScheduleHandler) List(c *gin.Context) {
+	v, ok := h.validation(c)
+	if !ok {
+		return
+	}
+
+	var schedules []models.Schedule
+	if err := h.db.Where("validation_id = ?", v.ID).Find(&schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+func (h *ScheduleHandler) Create(c *gin.Context) {
+	v, ok := h.validation(c)
+	if !ok {
+		return
+	}
+
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule := models.Schedule{ValidationID: v.ID, Enabled: true}
+	if !h.apply(c, v, &schedule, &req) {
+		return
+	}
+
+	if err := h.db.Create(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create schedule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+func (h *ScheduleHandler) Update(c *gin.Context) {
+	v, schedule, ok := h.schedule(c)
+	if !ok {
+		return
+	}
+
+	var req ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.apply(c, v, schedule, &req) {
+		return
+	}
+
+	if err := h.db.Save(schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *ScheduleHandler) Delete(c *gin.Context) {
+	_, schedule, ok := h.schedule(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete schedule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted successfully"})
+}
+
+// apply validates a request and copies it onto schedule, computing the next
+// tick from now
+func (h *ScheduleHandler) apply(c *gin.Context, v *models.Validation, schedule *models.Schedule, req *ScheduleRequest) bool {
+	schedule.Cron = req.Cron
+	schedule.Timezone = req.Timezone
+	if schedule.Timezone == "" {
+		schedule.Timezone = "UTC" // Set default
+	}
+	schedule.MissedPolicy = req.MissedPolicy
+	if schedule.MissedPolicy == "" {
+		schedule.MissedPolicy = models.MissedSkip // Set default
+	}
+	if schedule.MissedPolicy != models.MissedSkip && schedule.MissedPolicy != models.MissedCatchUp {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missed_policy must be skip or catch_up"})
+		return false
+	}
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	schedule.Parameters = req.Parameters
+
+	// Check the parameters against the validation's variables now rather
+	// than on the first tick
+	config, err := validation.ParseConfig(v.Config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid validation config: " + err.Error()})
+		return false
+	}
+	if err := config.ResolveVariables(time.Now().UTC(), schedule.Parameters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parameters: " + err.Error()})
+		return false
+	}
+
+	next, err := services.NextRun(schedule, time.Now().UTC())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule: " + err.Error()})
+		return false
+	}
+	schedule.NextRunAt = next
+	return true
+}
+
+// validation returns the user's validation named in the path
+func (h *ScheduleHandler) validation(c *gin.Context) (*models.Validation, bool) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid validation ID"})
+		return nil, false
+	}
+
+	var v models.Validation
+	if err := h.db.Select("id", "config").Where("id = ? AND user_id = ?", id, userID).First(&v).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Validation not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch validation"})
+		}
+		return nil, false
+	}
+	return &v, true
+}
+
+// schedule returns the schedule named in the path if its validation belongs to the user
+func (h *ScheduleHandler) schedule(c *gin.Context) (*models.Validation, *models.Schedule, bool) {
+	v, ok := h.validation(c)
+	if !ok {
+		return nil, nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("schedule_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule ID"})
+		return nil, nil, false
+	}
+
+	var schedule models.Schedule
+	if err := h.db.Where("id = ? AND validation_id = ?", id, v.ID).First(&schedule).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Schedule not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schedule"})
+		}
+		return nil, nil, false
+	}
+	return v, &schedule, true
+}
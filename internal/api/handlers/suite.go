@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/services"
+)
+
+type SuiteHandler struct {
+	db       *gorm.DB
+	suites   *services.SuiteService
+	executor *services.Executor
+}
+
+func NewSuiteHandler(db *gorm.DB, suites *services.SuiteService, executor *services.Executor) *SuiteHandler {
+	return &SuiteHandler{db: db, suites: suites, executor: executor}
+}
+
+type SuiteRequest struct {
+	Name          string                      `json:"name" binding:"required"`
+	Description   string                      `json:"description"`
+	ValidationIDs models.SuiteMembers         `json:"validation_ids" binding:"required"`
+	Notifications models.NotificationSettings `json:"notifications"`
+}
+
+func (h *SuiteHandler) List(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var suites []models.Suite
+	if err := h.db.Where("user_id = ?", userID).Find(&suites).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suites"})
+		return
+	}
+
+	c.JSON(http.StatusOK, suites)
+}
+
+func (h *SuiteHandler) Get(c *gin.Context) {
+	suite, ok := h.suite(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, suite)
+}
+
+func (h *SuiteHandler) Create(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var req SuiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkMembers(c, req.ValidationIDs) {
+		return
+	}
+	if err := req.Notifications.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	suite := &models.Suite{
+		Name:          req.Name,
+		Description:   req.Description,
+		ValidationIDs: req.ValidationIDs,
+		Notifications: req.Notifications,
+		UserID:        userID,
+		Status:        models.ValidationStatusPending,
+	}
+
+	if err := h.db.Create(suite).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create suite"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, suite)
+}
+
+func (h *SuiteHandler) Update(c *gin.Context) {
+	suite, ok := h.suite(c)
+	if !ok {
+		return
+	}
+
+	var req SuiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.checkMembers(c, req.ValidationIDs) {
+		return
+	}
+	if err := req.Notifications.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	suite.Name = req.Name
+	suite.Description = req.Description
+	suite.ValidationIDs = req.ValidationIDs
+	suite.Notifications = req.Notifications
+
+	if err := h.db.Save(suite).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update suite"})
+		return
+	}
+
+	c.JSON(http.StatusOK, suite)
+}
+
+func (h *SuiteHandler) Delete(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid suite ID"})
+		return
+	}
+
+	result := h.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Suite{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete suite"})
+		return
+	}
+
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Suite not found"})
+		return
+	}
+	h.db.Where("suite_id = ?", id).Delete(&models.SuiteRun{})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Suite deleted successfully"})
+}
+
+// Run starts every validation of the suite in the background and returns
+// the new suite run
+func (h *SuiteHandler) Run(c *gin.Context) {
+	suite, ok := h.suite(c)
+	if !ok {
+		return
+	}
+
+	run, err := h.suites.Start(suite)
+	if errors.Is(err, services.ErrEmptySuite) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record suite run"})
+		return
+	}
+	started := *run
+	started.Members = append(models.SuiteMemberRuns(nil), run.Members...)
+	h.executor.Go(func(ctx context.Context) {
+		h.suites.Execute(ctx, suite, run)
+	})
+
+	c.JSON(http.StatusAccepted, started)
+}
+
+// Runs lists the runs of a suite from the last days (default 30), most
+// recent first
+func (h *SuiteHandler) Runs(c *gin.Context) {
+	suite, ok := h.suite(c)
+	if !ok {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+		return
+	}
+	since := time.Now().UTC().AddDate(0, 0, -days)
+
+	var runs []models.SuiteRun
+	if err := h.db.Where("suite_id = ? AND started_at >= ?", suite.ID, since).Order("id DESC").Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suite runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// GetRun returns one suite run, or the latest for run ID "latest". The
+// optional status query parameter keeps only members with that status, e.g.
+// failed.
+func (h *SuiteHandler) GetRun(c *gin.Context) {
+	suite, ok := h.suite(c)
+	if !ok {
+		return
+	}
+
+	query := h.db.Where("suite_id = ?", suite.ID)
+	if c.Param("run_id") == "latest" {
+		query = query.Order("id DESC")
+	} else {
+		id, err := strconv.ParseUint(c.Param("run_id"), 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid suite run ID"})
+			return
+		}
+		query = query.Where("id = ?", id)
+	}
+
+	var run models.SuiteRun
+	if err := query.First(&run).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Suite run not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suite run"})
+		}
+		return
+	}
+
+	if status := c.Query("status"); status != "" {
+		var members models.SuiteMemberRuns
+		for _, m := range run.Members {
+			if string(m.Status) == status {
+				members = append(members, m)
+			}
+		}
+		run.Members = members
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// checkMembers checks that the suite names validations of the user, each once
+func (h *SuiteHandler) checkMembers(c *gin.Context, ids models.SuiteMembers) bool {
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Suite must have at least one validation"})
+		return false
+	}
+
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Duplicate validation " + strconv.FormatUint(uint64(id), 10)})
+			return false
+		}
+		seen[id] = true
+	}
+
+	var count int64
+	if err := h.db.Model(&models.Validation{}).Where("id IN ? AND user_id = ?", []uint(ids), c.GetUint("user_id")).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch validations"})
+		return false
+	}
+	if int(count) != len(ids) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid validation in suite"})
+		return false
+	}
+	return true
+}
+
+// suite returns the user's suite named in the path
+func (h *SuiteHandler) suite(c *gin.Context) (*models.Suite, bool) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid suite ID"})
+		return nil, false
+	}
+
+	var suite models.Suite
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&suite).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Suite not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch suite"})
+		}
+		return nil, false
+	}
+	return &suite, true
+}
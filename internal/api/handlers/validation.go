@@ -1,28 +1,49 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/services"
 )
 
 type ValidationHandler struct {
-	db *gorm.DB
+	db       *gorm.DB
+	service  *services.ValidationService
+	runs     *services.RunService
+	executor *services.Executor
 }
 
-func NewValidationHandler(db *gorm.DB) *ValidationHandler {
-	return &ValidationHandler{db: db}
+func NewValidationHandler(db *gorm.DB, runs *services.RunService, executor *services.Executor) *ValidationHandler {
+	return &ValidationHandler{
+		db:       db,
+		service:  runs.Validations(),
+		runs:     runs,
+		executor: executor,
+	}
 }
 
 type CreateValidationRequest struct {
-	Name               string                   `json:"name" binding:"required"`
-	SourceConnectionID uint                     `json:"source_connection_id" binding:"required"`
-	TargetConnectionID uint                     `json:"target_connection_id" binding:"required"`
-	Config             models.ValidationConfig  `json:"config"`
+	Name               string                      `json:"name" binding:"required"`
+	SourceConnectionID uint                        `json:"source_connection_id" binding:"required"`
+	TargetConnectionID uint                        `json:"target_connection_id" binding:"required"`
+	Config             models.ValidationConfig     `json:"config"`
+	Notifications      models.NotificationSettings `json:"notifications"`
+}
+
+// RunValidationRequest is the optional body of a run
+type RunValidationRequest struct {
+	// Parameters override the values of the config's variables
+	Parameters map[string]string `json:"parameters"`
 }
 
 func (h *ValidationHandler) List(c *gin.Context) {
@@ -69,6 +90,15 @@ func (h *ValidationHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if err := req.Notifications.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := h.service.ParseConfig(req.Config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid config: " + err.Error()})
+		return
+	}
+
 	// Verify that both connections belong to the user
 	var sourceConn, targetConn models.Connection
 	if err := h.db.Where("id = ? AND user_id = ?", req.SourceConnectionID, userID).First(&sourceConn).Error; err != nil {
@@ -85,6 +115,7 @@ func (h *ValidationHandler) Create(c *gin.Context) {
 		SourceConnectionID: req.SourceConnectionID,
 		TargetConnectionID: req.TargetConnectionID,
 		Config:             req.Config,
+		Notifications:      req.Notifications,
 		UserID:             userID,
 		Status:             models.ValidationStatusPending,
 	}
@@ -124,6 +155,15 @@ func (h *ValidationHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if err := req.Notifications.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := h.service.ParseConfig(req.Config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid config: " + err.Error()})
+		return
+	}
+
 	// Verify that both connections belong to the user
 	var sourceConn, targetConn models.Connection
 	if err := h.db.Where("id = ? AND user_id = ?", req.SourceConnectionID, userID).First(&sourceConn).Error; err != nil {
@@ -139,6 +179,7 @@ func (h *ValidationHandler) Update(c *gin.Context) {
 	validation.SourceConnectionID = req.SourceConnectionID
 	validation.TargetConnectionID = req.TargetConnectionID
 	validation.Config = req.Config
+	validation.Notifications = req.Notifications
 
 	if err := h.db.Save(&validation).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update validation"})
@@ -159,20 +200,75 @@ func (h *ValidationHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	result := h.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Validation{})
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete validation"})
+	// The checks and deletes share a transaction, so that a pipeline or
+	// suite cannot take up the validation meanwhile and no runs, schedules
+	// or incidents are left behind
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		// Removing a pipeline's node would break the edges of the nodes after it
+		var pipelines []models.Pipeline
+		if err := tx.Select("id", "name", "nodes").Where("user_id = ?", userID).Find(&pipelines).Error; err != nil {
+			return &statusError{http.StatusInternalServerError, "Failed to fetch pipelines"}
+		}
+		for _, pipeline := range pipelines {
+			for _, node := range pipeline.Nodes {
+				if node.ValidationID == uint(id) {
+					return &statusError{http.StatusConflict, fmt.Sprintf("Validation is used by pipeline %q, remove it from the pipeline first", pipeline.Name)}
+				}
+			}
+		}
+
+		var suites []models.Suite
+		if err := tx.Select("id", "name", "validation_ids").Where("user_id = ?", userID).Find(&suites).Error; err != nil {
+			return &statusError{http.StatusInternalServerError, "Failed to fetch suites"}
+		}
+		for _, suite := range suites {
+			for _, member := range suite.ValidationIDs {
+				if member == uint(id) {
+					return &statusError{http.StatusConflict, fmt.Sprintf("Validation is a member of suite %q, remove it from the suite first", suite.Name)}
+				}
+			}
+		}
+
+		result := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.Validation{})
+		if result.Error != nil {
+			return &statusError{http.StatusInternalServerError, "Failed to delete validation"}
+		}
+		if result.RowsAffected == 0 {
+			return &statusError{http.StatusNotFound, "Validation not found"}
+		}
+		for _, dependent := range []interface{}{&models.ValidationRun{}, &models.Schedule{}, &models.Incident{}} {
+			if err := tx.Where("validation_id = ?", id).Delete(dependent).Error; err != nil {
+				return &statusError{http.StatusInternalServerError, "Failed to delete validation"}
+			}
+		}
+		return nil
+	})
+	var failed *statusError
+	if errors.As(err, &failed) {
+		c.JSON(failed.status, gin.H{"error": failed.message})
 		return
 	}
-
-	if result.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Validation not found"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete validation"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Validation deleted successfully"})
 }
 
+// statusError ends a request with an HTTP status and message from inside a
+// transaction, rolling it back
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+// Run records a run of the validation and returns it while the validation
+// runs in the background
 func (h *ValidationHandler) Run(c *gin.Context) {
 	userID := c.GetUint("user_id")
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -192,24 +288,70 @@ func (h *ValidationHandler) Run(c *gin.Context) {
 		return
 	}
 
-	// Update status to running
-	validation.Status = models.ValidationStatusRunning
-	h.db.Save(&validation)
+	var req RunValidationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	// TODO: Implement actual validation logic
-	// For now, we'll just simulate a successful validation
-	validation.Status = models.ValidationStatusCompleted
-	validation.Results = models.ValidationResults{
-		"summary": map[string]interface{}{
-			"total_records": 1000,
-			"matched":       950,
-			"mismatched":    50,
-			"success_rate":  95.0,
-		},
+	runReq := services.RunRequest{
+		Trigger:     models.RunTriggerManual,
+		LogicalDate: time.Now().UTC(),
+		Parameters:  req.Parameters,
+	}
+	config, err := h.runs.Prepare(&validation, runReq)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	h.db.Save(&validation)
 
-	c.JSON(http.StatusOK, validation)
+	run, err := h.runs.Start(&validation, config, runReq)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record run"})
+		return
+	}
+	started := *run
+	h.executor.Go(func(ctx context.Context) {
+		h.runs.Execute(ctx, &validation, config, run)
+	})
+
+	c.JSON(http.StatusAccepted, started)
+}
+
+// History lists the runs of a validation from the last days (default 30),
+// most recent first
+func (h *ValidationHandler) History(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid validation ID"})
+		return
+	}
+
+	var count int64
+	if err := h.db.Model(&models.Validation{}).Where("id = ? AND user_id = ?", id, userID).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch validation"})
+		return
+	}
+	if count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Validation not found"})
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days"})
+		return
+	}
+	since := time.Now().UTC().AddDate(0, 0, -days)
+
+	var runs []models.ValidationRun
+	if err := h.db.Where("validation_id = ? AND started_at >= ?", id, since).Order("id DESC").Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
 }
 
 func (h *ValidationHandler) Status(c *gin.Context) {
@@ -231,10 +373,29 @@ func (h *ValidationHandler) Status(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"id":         validation.ID,
 		"name":       validation.Name,
 		"status":     validation.Status,
 		"updated_at": validation.UpdatedAt,
-	})
+	}
+	if validation.Status == models.ValidationStatusRunning {
+		var run models.ValidationRun
+		if err := h.db.Select("id").Where("validation_id = ? AND status = ?", validation.ID, validation.Status).
+			Order("id DESC").First(&run).Error; err == nil {
+			response["run_id"] = run.ID
+			if progress, ok := h.service.Progress(run.ID); ok {
+				response["progress"] = progress
+			}
+		}
+	}
+	if validation.Status == models.ValidationStatusWaiting {
+		var run models.ValidationRun
+		if err := h.db.Select("status_reason").Where("validation_id = ? AND status = ?", validation.ID, validation.Status).
+			Order("id DESC").First(&run).Error; err == nil {
+			response["status_reason"] = run.StatusReason
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
\ No newline at end of file
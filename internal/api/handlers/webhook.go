@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/services"
+)
+
+type WebhookHandler struct {
+	db       *gorm.DB
+	webhooks *services.WebhookService
+}
+
+func NewWebhookHandler(db *gorm.DB, webhooks *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{db: db, webhooks: webhooks}
+}
+
+type WebhookRequest struct {
+	Name    string             `json:"name" binding:"required"`
+	URL     string             `json:"url" binding:"required"`
+	Type    models.WebhookType `json:"type"` // json when empty
+	Secret  string             `json:"secret"`
+	Events  models.EventTypes  `json:"events" binding:"required"`
+	Enabled *bool              `json:"enabled"`
+}
+
+// webhookWithSecret returns the signing secret, which is only shown when
+// it is set
+type webhookWithSecret struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var webhooks []models.Webhook
+	if err := h.db.Where("user_id = ?", userID).Find(&webhooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandler) Get(c *gin.Context) {
+	webhook, ok := h.webhook(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook := &models.Webhook{UserID: c.GetUint("user_id"), Enabled: true}
+	if !h.apply(c, webhook, &req) {
+		return
+	}
+	if webhook.Secret == "" {
+		secret, err := services.NewWebhookSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		webhook.Secret = secret
+	}
+
+	if err := h.db.Create(webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhookWithSecret{Webhook: webhook, Secret: webhook.Secret})
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	webhook, ok := h.webhook(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.apply(c, webhook, &req) {
+		return
+	}
+
+	if err := h.db.Save(webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+
+	if req.Secret != "" {
+		c.JSON(http.StatusOK, webhookWithSecret{Webhook: webhook, Secret: webhook.Secret})
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	webhook, ok := h.webhook(c)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(webhook).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+	h.db.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookDelivery{})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// Test sends a ping event to the webhook and returns the delivery
+func (h *WebhookHandler) Test(c *gin.Context) {
+	webhook, ok := h.webhook(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhooks.Test(c.Request.Context(), webhook)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+// Deliveries lists the webhook's most recent deliveries, newest first
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	webhook, ok := h.webhook(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	query := h.db.Where("webhook_id = ?", webhook.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// apply validates a request and copies it onto webhook
+func (h *WebhookHandler) apply(c *gin.Context, webhook *models.Webhook, req *WebhookRequest) bool {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url must be an absolute http or https URL"})
+		return false
+	}
+	switch req.Type {
+	case "":
+		req.Type = models.WebhookJSON
+	case models.WebhookJSON, models.WebhookSlack, models.WebhookTeams:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type must be json, slack or teams"})
+		return false
+	}
+	if len(req.Events) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "events must not be empty"})
+		return false
+	}
+	for _, event := range req.Events {
+		if !models.EventTypes(models.WebhookEvents).Has(event) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown event " + strconv.Quote(event)})
+			return false
+		}
+	}
+
+	webhook.Name = req.Name
+	webhook.URL = req.URL
+	webhook.Type = req.Type
+	webhook.Events = req.Events
+	if req.Secret != "" {
+		webhook.Secret = req.Secret
+	}
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	return true
+}
+
+// webhook returns the user's webhook named in the path
+func (h *WebhookHandler) webhook(c *gin.Context) (*models.Webhook, bool) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return nil, false
+	}
+
+	var webhook models.Webhook
+	if err := h.db.Where("id = ? AND user_id = ?", id, userID).First(&webhook).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhook"})
+		}
+		return nil, false
+	}
+	return &webhook, true
+}
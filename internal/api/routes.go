@@ -1,15 +1,65 @@
 package api
 
 import (
+	"log"
+
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"github.com/compareflow/compareflow/internal/api/handlers"
 	"github.com/compareflow/compareflow/internal/api/middleware"
 	"github.com/compareflow/compareflow/internal/config"
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/secrets"
+	"github.com/compareflow/compareflow/internal/services"
 )
 
-func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
+// Workers are the background services behind the routes: the executor of
+// the runs started through the API, the scheduler and the senders of webhook
+// deliveries and emails. SetupRoutes only creates them; the caller starts
+// them once the server is up and stops them on shutdown.
+type Workers struct {
+	executor  *services.Executor
+	scheduler *services.Scheduler // nil when cfg.DisableScheduler is set
+	webhooks  *services.WebhookService
+	email     *services.EmailService // nil when SMTP is not configured
+}
+
+// Start starts the workers
+func (w *Workers) Start() {
+	w.webhooks.Start()
+	if w.email != nil {
+		w.email.Start()
+	}
+	w.executor.Start()
+	if w.scheduler != nil {
+		w.scheduler.Start()
+	}
+}
+
+// Stop stops the scheduler and the executor, cancelling their runs in
+// progress, then the senders, and waits for them
+func (w *Workers) Stop() {
+	if w.scheduler != nil {
+		w.scheduler.Stop()
+	}
+	w.executor.Stop()
+	w.webhooks.Stop()
+	if w.email != nil {
+		w.email.Stop()
+	}
+}
+
+// SetupRoutes registers the API on router and returns its background
+// workers, which are not started yet
+func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) *Workers {
+	// Encrypt connection secrets at rest
+	if cfg.EncryptionKey != "" {
+		models.SetSecretCipher(secrets.NewCipher(cfg.EncryptionKey))
+	} else {
+		log.Printf("warning: ENCRYPTION_KEY is not set; connection secrets are stored in plaintext")
+	}
+
 	// CORS middleware
 	router.Use(middleware.CORS(cfg.AllowedOrigins))
 
@@ -36,11 +86,16 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	// User routes
 	protected.GET("/auth/me", authHandler.Me)
 
+	// Connector routes
+	connectorHandler := handlers.NewConnectorHandler()
+	protected.GET("/connectors", connectorHandler.List)
+
 	// Connection routes
 	connectionHandler := handlers.NewConnectionHandler(db)
 	protected.GET("/connections", connectionHandler.List)
 	protected.GET("/connections/:id", connectionHandler.Get)
 	protected.POST("/connections", connectionHandler.Create)
+	protected.POST("/connections/parse-dsn", connectionHandler.ParseDSN)
 	protected.PUT("/connections/:id", connectionHandler.Update)
 	protected.DELETE("/connections/:id", connectionHandler.Delete)
 	protected.POST("/connections/:id/test", connectionHandler.Test)
@@ -48,7 +103,16 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	protected.GET("/connections/:id/tables/:table/columns", connectionHandler.GetColumns)
 
 	// Validation routes
-	validationHandler := handlers.NewValidationHandler(db)
+	runService := services.NewRunService(db)
+	webhookService := services.NewWebhookService(db, cfg.PublicURL)
+	runService.Subscribe(webhookService.Notify)
+	var emailService *services.EmailService
+	if cfg.SMTP.Host != "" {
+		emailService = services.NewEmailService(db, cfg.SMTP, cfg.PublicURL)
+		runService.Subscribe(emailService.Notify)
+	}
+	executor := services.NewExecutor(db, runService)
+	validationHandler := handlers.NewValidationHandler(db, runService, executor)
 	protected.GET("/validations", validationHandler.List)
 	protected.GET("/validations/:id", validationHandler.Get)
 	protected.POST("/validations", validationHandler.Create)
@@ -56,4 +120,56 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	protected.DELETE("/validations/:id", validationHandler.Delete)
 	protected.POST("/validations/:id/run", validationHandler.Run)
 	protected.GET("/validations/:id/status", validationHandler.Status)
+	protected.GET("/validations/:id/history", validationHandler.History)
+
+	// Incident routes
+	incidentHandler := handlers.NewIncidentHandler(db)
+	protected.GET("/incidents", incidentHandler.List)
+	protected.POST("/incidents/:id/acknowledge", incidentHandler.Acknowledge)
+	protected.GET("/validations/:id/incidents", incidentHandler.ValidationIncidents)
+
+	// Schedule routes
+	scheduleHandler := handlers.NewScheduleHandler(db)
+	protected.GET("/validations/:id/schedules", scheduleHandler.List)
+	protected.POST("/validations/:id/schedules", scheduleHandler.Create)
+	protected.PUT("/validations/:id/schedules/:schedule_id", scheduleHandler.Update)
+	protected.DELETE("/validations/:id/schedules/:schedule_id", scheduleHandler.Delete)
+
+	// Pipeline routes
+	pipelineHandler := handlers.NewPipelineHandler(db, services.NewPipelineService(db, runService), executor)
+	protected.GET("/pipelines", pipelineHandler.List)
+	protected.GET("/pipelines/:id", pipelineHandler.Get)
+	protected.POST("/pipelines", pipelineHandler.Create)
+	protected.PUT("/pipelines/:id", pipelineHandler.Update)
+	protected.DELETE("/pipelines/:id", pipelineHandler.Delete)
+	protected.POST("/pipelines/:id/run", pipelineHandler.Run)
+	protected.GET("/pipelines/:id/runs", pipelineHandler.Runs)
+
+	// Suite routes
+	suiteHandler := handlers.NewSuiteHandler(db, services.NewSuiteService(db, runService), executor)
+	protected.GET("/suites", suiteHandler.List)
+	protected.GET("/suites/:id", suiteHandler.Get)
+	protected.POST("/suites", suiteHandler.Create)
+	protected.PUT("/suites/:id", suiteHandler.Update)
+	protected.DELETE("/suites/:id", suiteHandler.Delete)
+	protected.POST("/suites/:id/run", suiteHandler.Run)
+	protected.GET("/suites/:id/runs", suiteHandler.Runs)
+	protected.GET("/suites/:id/runs/:run_id", suiteHandler.GetRun)
+
+	// Webhook routes
+	webhookHandler := handlers.NewWebhookHandler(db, webhookService)
+	protected.GET("/webhooks", webhookHandler.List)
+	protected.GET("/webhooks/:id", webhookHandler.Get)
+	protected.POST("/webhooks", webhookHandler.Create)
+	protected.PUT("/webhooks/:id", webhookHandler.Update)
+	protected.DELETE("/webhooks/:id", webhookHandler.Delete)
+	protected.POST("/webhooks/:id/test", webhookHandler.Test)
+	protected.GET("/webhooks/:id/deliveries", webhookHandler.Deliveries)
+
+	workers := &Workers{executor: executor, webhooks: webhookService, email: emailService}
+	// Start scheduled runs unless this instance only serves the API
+	if !cfg.DisableScheduler {
+		workers.scheduler = services.NewScheduler(db, runService)
+	}
+	return workers
 }
\ No newline at end of file
@@ -1,8 +1,119 @@
 package config
 
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
 type Config struct {
 	Port           string
 	DatabaseURL    string
 	JWTSecret      string
 	AllowedOrigins []string
-}
\ No newline at end of file
+	EncryptionKey  string // Encrypts connection secrets at rest; plaintext when empty
+	// DisableScheduler stops this instance from starting scheduled runs, e.g.
+	// on API-only replicas. Such replicas still run validations started
+	// through their API, and connection query limits are enforced per
+	// instance only, so replicas add to a connection's concurrent queries.
+	DisableScheduler bool
+	// PublicURL is where users reach the web UI, used for links in
+	// notifications, e.g. https://compareflow.example.com
+	PublicURL string
+	SMTP      SMTPConfig
+}
+
+// SMTPConfig configures email notifications. Email is off when Host is empty.
+type SMTPConfig struct {
+	Host     string
+	Port     int    // 587 when zero
+	Username string // no authentication when empty
+	Password string
+	From     string
+	// TLS connects with implicit TLS, usually on port 465. Otherwise STARTTLS
+	// is used when the server offers it.
+	TLS bool
+	// DigestHour is the UTC hour the daily digest is sent at
+	DigestHour int
+}
+
+// Load reads the configuration from the environment variables documented in
+// the README. DATABASE_URL and JWT_SECRET are required.
+func Load() (*Config, error) {
+	cfg := &Config{
+		Port:          getenv("PORT", "8080"),
+		DatabaseURL:   os.Getenv("DATABASE_URL"),
+		JWTSecret:     os.Getenv("JWT_SECRET"),
+		EncryptionKey: os.Getenv("ENCRYPTION_KEY"),
+		PublicURL:     strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/"),
+		SMTP: SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		},
+	}
+	if cfg.DatabaseURL == "" {
+		return nil, fmt.Errorf("DATABASE_URL is required")
+	}
+	if cfg.JWTSecret == "" {
+		return nil, fmt.Errorf("JWT_SECRET is required")
+	}
+	for _, origin := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			cfg.AllowedOrigins = append(cfg.AllowedOrigins, origin)
+		}
+	}
+
+	var err error
+	if cfg.DisableScheduler, err = getbool("DISABLE_SCHEDULER"); err != nil {
+		return nil, err
+	}
+	if cfg.SMTP.TLS, err = getbool("SMTP_TLS"); err != nil {
+		return nil, err
+	}
+	if cfg.SMTP.Port, err = getint("SMTP_PORT"); err != nil {
+		return nil, err
+	}
+	if cfg.SMTP.DigestHour, err = getint("SMTP_DIGEST_HOUR"); err != nil {
+		return nil, err
+	}
+	if cfg.SMTP.DigestHour < 0 || cfg.SMTP.DigestHour > 23 {
+		return nil, fmt.Errorf("SMTP_DIGEST_HOUR must be between 0 and 23")
+	}
+	return cfg, nil
+}
+
+func getenv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getbool parses a boolean variable, which is false when unset
+func getbool(name string) (bool, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", name, value)
+	}
+	return b, nil
+}
+
+// getint parses an integer variable, which is zero when unset
+func getint(name string) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number, got %q", name, value)
+	}
+	return n, nil
+}
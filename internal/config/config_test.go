@@ -0,0 +1,89 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	required := map[string]string{
+		"DATABASE_URL": "sqlite://compareflow.db",
+		"JWT_SECRET":   "secret",
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(*testing.T, *Config)
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Port != "8080" || cfg.EncryptionKey != "" || cfg.DisableScheduler || cfg.SMTP.Port != 0 {
+					t.Errorf("unexpected defaults: %+v", cfg)
+				}
+			},
+		},
+		{
+			name: "all variables",
+			env: map[string]string{
+				"PORT":              "9090",
+				"ENCRYPTION_KEY":    "key",
+				"ALLOWED_ORIGINS":   "https://a.example.com, https://b.example.com,",
+				"DISABLE_SCHEDULER": "true",
+				"PUBLIC_URL":        "https://compareflow.example.com/",
+				"SMTP_HOST":         "smtp.example.com",
+				"SMTP_PORT":         "465",
+				"SMTP_TLS":          "1",
+				"SMTP_DIGEST_HOUR":  "7",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Port != "9090" || cfg.EncryptionKey != "key" || !cfg.DisableScheduler {
+					t.Errorf("unexpected config: %+v", cfg)
+				}
+				if want := []string{"https://a.example.com", "https://b.example.com"}; !reflect.DeepEqual(cfg.AllowedOrigins, want) {
+					t.Errorf("AllowedOrigins = %q, want %q", cfg.AllowedOrigins, want)
+				}
+				if cfg.PublicURL != "https://compareflow.example.com" {
+					t.Errorf("PublicURL = %q", cfg.PublicURL)
+				}
+				if cfg.SMTP.Host != "smtp.example.com" || cfg.SMTP.Port != 465 || !cfg.SMTP.TLS || cfg.SMTP.DigestHour != 7 {
+					t.Errorf("unexpected SMTP config: %+v", cfg.SMTP)
+				}
+			},
+		},
+		{name: "missing database URL", env: map[string]string{"DATABASE_URL": ""}, wantErr: "DATABASE_URL is required"},
+		{name: "missing JWT secret", env: map[string]string{"JWT_SECRET": ""}, wantErr: "JWT_SECRET is required"},
+		{name: "invalid bool", env: map[string]string{"SMTP_TLS": "yes"}, wantErr: `SMTP_TLS must be true or false, got "yes"`},
+		{name: "invalid number", env: map[string]string{"SMTP_PORT": "smtp"}, wantErr: `SMTP_PORT must be a number, got "smtp"`},
+		{name: "digest hour out of range", env: map[string]string{"SMTP_DIGEST_HOUR": "24"}, wantErr: "SMTP_DIGEST_HOUR must be between 0 and 23"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"PORT", "ENCRYPTION_KEY", "ALLOWED_ORIGINS", "DISABLE_SCHEDULER", "PUBLIC_URL",
+				"SMTP_HOST", "SMTP_PORT", "SMTP_USERNAME", "SMTP_PASSWORD", "SMTP_FROM", "SMTP_TLS", "SMTP_DIGEST_HOUR"} {
+				t.Setenv(name, "")
+			}
+			for name, value := range required {
+				t.Setenv(name, value)
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
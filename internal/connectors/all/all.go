@@ -0,0 +1,9 @@
+// Package all registers every built-in connector.
+// Import it for side effects wherever connectors are looked up by type.
+package all
+
+import (
+	_ "github.com/compareflow/compareflow/internal/connectors/databricks"
+	_ "github.com/compareflow/compareflow/internal/connectors/postgresql"
+	_ "github.com/compareflow/compareflow/internal/connectors/sqlserver"
+)
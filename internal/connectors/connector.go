@@ -2,6 +2,8 @@ package connectors
 
 import (
 	"database/sql"
+
+	"github.com/compareflow/compareflow/internal/values"
 )
 
 // Connector defines the interface that all database connectors must implement
@@ -23,6 +25,12 @@ type Connector interface {
 	
 	// GetColumns returns column information for a specific table
 	GetColumns(db *sql.DB, tableName string) ([]ColumnInfo, error)
+	
+	// ConfigSchema describes the configuration fields accepted by ParseConfig
+	ConfigSchema() ConfigSchema
+	
+	// Capabilities reports the optional features this connector supports
+	Capabilities() Capabilities
 }
 
 // ColumnInfo represents information about a database column
@@ -30,4 +38,46 @@ type ColumnInfo struct {
 	Name     string `json:"name"`
 	DataType string `json:"data_type"`
 	Nullable bool   `json:"nullable"`
-}
\ No newline at end of file
+}
+
+// DSNParser is implemented by connectors that can import connection strings
+type DSNParser interface {
+	// ParseDSN converts a URL or key=value connection string into a raw config map
+	ParseDSN(dsn string) (map[string]interface{}, error)
+}
+
+// Dialect is implemented by connectors whose SQL the validation engine can generate.
+// Expressions from different connectors must agree: equal values normalize to the
+// same text and hash to the same number, so that aggregates compare across databases.
+type Dialect interface {
+	// QuoteIdentifier quotes a table or column name
+	QuoteIdentifier(name string) string
+	
+	// Placeholder returns the bind parameter marker for the n-th argument, starting at 1
+	Placeholder(n int) string
+	
+	// NormalizeExpression renders expr as canonical text based on its database type
+	// name as reported by the driver. NULL input may yield NULL.
+	NormalizeExpression(expr, databaseType string) string
+	
+	// HashExpression returns a BIGINT in [0, 2^32) read from bytes 4*part to
+	// 4*part+3 of the MD5 digest of the texts joined by "|"
+	HashExpression(texts []string, part int) string
+}
+
+// ValueMapper is implemented by connectors whose driver returns values that
+// need more than the type-name based mapping of values.FromDriver
+type ValueMapper interface {
+	// MapValue converts a scanned value into its canonical form based on its
+	// database type name as reported by the driver
+	MapValue(v interface{}, databaseType string) (values.Value, error)
+}
+
+// MapValue converts a scanned value using the connector's ValueMapper if it
+// implements one, falling back to values.FromDriver
+func MapValue(connector Connector, v interface{}, databaseType string) (values.Value, error) {
+	if mapper, ok := connector.(ValueMapper); ok {
+		return mapper.MapValue(v, databaseType)
+	}
+	return values.FromDriver(v, databaseType)
+}
@@ -4,10 +4,14 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
+	"time"
 	
-	_ "github.com/databricks/databricks-sql-go"
+	dbsql "github.com/databricks/databricks-sql-go"
 	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/connectors/sshtunnel"
 )
 
 func init() {
@@ -19,13 +23,29 @@ func init() {
 
 // Config represents Databricks connection configuration
 type Config struct {
+	// DSN is an optional JDBC URL or driver DSN; its settings fill in any
+	// discrete fields that are left empty
+	DSN         string `json:"dsn,omitempty"`
 	Workspace   string `json:"workspace" binding:"required"`
 	HTTPPath    string `json:"http_path" binding:"required"`
 	AccessToken string `json:"access_token" binding:"required"`
+	
+	// SSHTunnel optionally routes the HTTPS connection through a bastion host
+	SSHTunnel *sshtunnel.Config `json:"ssh_tunnel,omitempty"`
 }
 
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
+	if c.DSN != "" {
+		err := connectors.ApplyDSN(New(), c.DSN, map[string]interface{}{
+			"workspace":    &c.Workspace,
+			"http_path":    &c.HTTPPath,
+			"access_token": &c.AccessToken,
+		})
+		if err != nil {
+			return err
+		}
+	}
 	if c.Workspace == "" {
 		return fmt.Errorf("workspace URL is required")
 	}
@@ -35,6 +55,11 @@ func (c *Config) Validate() error {
 	if c.AccessToken == "" {
 		return fmt.Errorf("access token is required")
 	}
+	if c.SSHTunnel != nil {
+		if err := c.SSHTunnel.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -51,6 +76,36 @@ func (c *Connector) Type() string {
 	return "databricks"
 }
 
+// ConfigSchema describes the Databricks configuration fields
+func (c *Connector) ConfigSchema() connectors.ConfigSchema {
+	return connectors.ConfigSchema{
+		Title: "Databricks",
+		Fields: []connectors.Field{
+			{Name: "dsn", Label: "Connection String", Type: connectors.FieldTypeString, Secret: true,
+				Placeholder: "jdbc:databricks://your-workspace.databricks.com:443/default;httpPath=/sql/1.0/warehouses/abc;PWD=dapi...",
+				Help:        "Optional JDBC URL or driver DSN; fields left empty are taken from it"},
+			{Name: "workspace", Label: "Workspace URL", Type: connectors.FieldTypeString, RequiredUnless: "dsn",
+				Placeholder: "https://your-workspace.databricks.com"},
+			{Name: "http_path", Label: "HTTP Path", Type: connectors.FieldTypeString, RequiredUnless: "dsn",
+				Placeholder: "/sql/1.0/endpoints/your-endpoint",
+				Help: "HTTP path of the SQL warehouse, found under its connection details"},
+			{Name: "access_token", Label: "Access Token", Type: connectors.FieldTypeString, RequiredUnless: "dsn", Secret: true},
+			sshtunnel.SchemaField(),
+		},
+	}
+}
+
+// Capabilities reports the optional features supported by Databricks
+func (c *Connector) Capabilities() connectors.Capabilities {
+	return connectors.Capabilities{
+		SupportsCatalogs:     true,
+		SupportsHashPushdown: true,
+		SupportsSampling:     true,
+		SupportsDSNImport:    true,
+		SupportsSSHTunnel:    true,
+	}
+}
+
 // ParseConfig parses raw config into typed config
 func (c *Connector) ParseConfig(configMap map[string]interface{}) (interface{}, error) {
 	var config Config
@@ -79,8 +134,40 @@ func (c *Connector) Connect(config interface{}) (*sql.DB, error) {
 		return nil, fmt.Errorf("invalid config type: expected *Config, got %T", config)
 	}
 	
-	connString := c.buildConnectionString(dbConfig)
-	return sql.Open("databricks", connString)
+	if dbConfig.SSHTunnel == nil {
+		return sql.Open("databricks", c.buildConnectionString(dbConfig))
+	}
+	
+	tunnel, err := sshtunnel.Open(dbConfig.SSHTunnel)
+	if err != nil {
+		return nil, err
+	}
+	connector, err := dbsql.NewConnector(
+		dbsql.WithServerHostname(workspaceHost(dbConfig.Workspace)),
+		dbsql.WithPort(443),
+		dbsql.WithHTTPPath(dbConfig.HTTPPath),
+		dbsql.WithAccessToken(dbConfig.AccessToken),
+		dbsql.WithTransport(tunnelTransport(tunnel)),
+	)
+	if err != nil {
+		tunnel.Close()
+		return nil, fmt.Errorf("invalid connection settings: %w", err)
+	}
+	return connectors.OpenDB(connector, tunnel), nil
+}
+
+// tunnelTransport returns an HTTP transport that dials the workspace through
+// the tunnel. TLS is still negotiated end to end with the workspace.
+func tunnelTransport(tunnel *sshtunnel.Tunnel) *http.Transport {
+	return &http.Transport{
+		DialContext:           tunnel.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       180 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
 }
 
 // TestConnection verifies the Databricks connection works
@@ -184,6 +271,6 @@ func (c *Connector) buildConnectionString(config *Config) string {
 	host = strings.TrimPrefix(host, "http://")
 	
 	// Format: databricks://token:<access_token>@<workspace>:<port><http_path>
-	return fmt.Sprintf("databricks://token:%s@%s:443%s", 
-		config.AccessToken, host, config.HTTPPath)
+	return fmt.Sprintf("databricks://%s@%s:443%s", 
+		url.UserPassword("token", config.AccessToken), host, config.HTTPPath)
 }
\ No newline at end of file
@@ -0,0 +1,98 @@
+package databricks
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/connectors/diagnostics"
+)
+
+// Target returns the workspace endpoint for diagnostics
+func (c *Connector) Target(config interface{}) (diagnostics.Target, error) {
+	dbConfig, ok := config.(*Config)
+	if !ok {
+		return diagnostics.Target{}, fmt.Errorf("invalid config type: expected *Config, got %T", config)
+	}
+	return diagnostics.Target{Host: workspaceHost(dbConfig.Workspace), Port: 443, SSHTunnel: dbConfig.SSHTunnel}, nil
+}
+
+// ProbeTLS performs the HTTPS handshake with the workspace
+func (c *Connector) ProbeTLS(ctx context.Context, config interface{}, conn net.Conn) (string, error) {
+	dbConfig, ok := config.(*Config)
+	if !ok {
+		return "", fmt.Errorf("invalid config type: expected *Config, got %T", config)
+	}
+
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: workspaceHost(dbConfig.Workspace)})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return "", connectors.ExplainTLSError(err)
+	}
+	return tls.VersionName(tlsConn.ConnectionState().Version) + ", certificate and host name verified", nil
+}
+
+// ServerInfo reports the runtime version and the catalog privileges of the current user
+func (c *Connector) ServerInfo(ctx context.Context, db *sql.DB) (*diagnostics.ServerInfo, error) {
+	info := &diagnostics.ServerInfo{}
+	err := db.QueryRowContext(ctx, "SELECT version(), current_user(), current_catalog()").
+		Scan(&info.Version, &info.CurrentUser, &info.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query server version: %w", err)
+	}
+
+	// Grants are only available with Unity Catalog, so a failure here is not an error
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SHOW GRANTS `%s` ON CATALOG `%s`",
+		strings.ReplaceAll(info.CurrentUser, "`", "``"), strings.ReplaceAll(info.Database, "`", "``")))
+	if err != nil {
+		return info, nil
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return info, nil
+	}
+	values := make([]sql.NullString, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, column := range columns {
+			if strings.EqualFold(column, "ActionType") && values[i].Valid {
+				info.Privileges = append(info.Privileges, values[i].String)
+			}
+		}
+	}
+	return info, rows.Err()
+}
+
+// ExplainError suggests a fix for common Databricks errors
+func (c *Connector) ExplainError(err error) string {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "401") || strings.Contains(strings.ToLower(msg), "invalid access token"):
+		return "the access token was rejected; generate a new personal access token"
+	case strings.Contains(msg, "403"):
+		return "the token's user lacks CAN USE permission on the SQL warehouse"
+	case strings.Contains(msg, "404"):
+		return "the HTTP path was not found; copy it from the warehouse connection details"
+	}
+	return ""
+}
+
+// workspaceHost strips the scheme and path from a workspace URL
+func workspaceHost(workspace string) string {
+	if u, err := url.Parse(workspace); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	return strings.Split(workspace, "/")[0]
+}
@@ -0,0 +1,45 @@
+package databricks
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuoteIdentifier quotes a Databricks identifier, preserving dots between catalog, schema and name
+func (c *Connector) QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+	}
+	return strings.Join(parts, ".")
+}
+
+// Placeholder returns the n-th bind parameter marker; Databricks binds positional ? parameters
+func (c *Connector) Placeholder(n int) string {
+	return "?"
+}
+
+// NormalizeExpression renders expr as canonical text for hashing
+func (c *Connector) NormalizeExpression(expr, databaseType string) string {
+	switch strings.ToUpper(databaseType) {
+	case "TINYINT", "SMALLINT", "INT", "BIGINT":
+		return "CAST(" + expr + " AS STRING)"
+	case "DECIMAL", "FLOAT", "DOUBLE":
+		return "CAST(CAST(" + expr + " AS DECIMAL(38, 6)) AS STRING)"
+	case "BOOLEAN":
+		return "CASE WHEN " + expr + " THEN '1' ELSE '0' END"
+	case "DATE":
+		return "date_format(" + expr + ", 'yyyy-MM-dd')"
+	case "TIMESTAMP":
+		return "date_format(" + expr + ", 'yyyy-MM-dd HH:mm:ss.SSSSSS')"
+	case "BINARY":
+		return "lower(hex(" + expr + "))"
+	}
+	return "CAST(" + expr + " AS STRING)"
+}
+
+// HashExpression reads four bytes of the MD5 digest as a BIGINT
+func (c *Connector) HashExpression(texts []string, part int) string {
+	return fmt.Sprintf("CAST(conv(substr(md5(concat_ws('|', %s)), %d, 8), 16, 10) AS BIGINT)",
+		strings.Join(texts, ", "), part*8+1)
+}
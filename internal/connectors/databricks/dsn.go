@@ -0,0 +1,80 @@
+package databricks
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// ParseDSN converts a Databricks connection string into a raw config map.
+// Accepted forms are JDBC URLs (jdbc:databricks:// or jdbc:spark://) and
+// Go driver DSNs such as token:<access_token>@<host>:443/<http_path>.
+func (c *Connector) ParseDSN(dsn string) (map[string]interface{}, error) {
+	dsn = strings.TrimSpace(dsn)
+	lower := strings.ToLower(dsn)
+
+	if strings.HasPrefix(lower, "jdbc:databricks://") || strings.HasPrefix(lower, "jdbc:spark://") {
+		return parseJDBC(dsn[strings.Index(dsn, "://")+3:])
+	}
+
+	if !strings.Contains(dsn, "://") {
+		dsn = "databricks://" + dsn
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Databricks DSN: %w", err)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid Databricks DSN: missing host")
+	}
+
+	config := map[string]interface{}{
+		"workspace": "https://" + u.Hostname(),
+	}
+	if u.Path != "" && u.Path != "/" {
+		config["http_path"] = u.Path
+	}
+	if token, ok := u.User.Password(); ok {
+		config["access_token"] = token
+	}
+	return config, nil
+}
+
+// parseJDBC parses "host:port/schema;key=value;..." from a JDBC URL
+func parseJDBC(jdbc string) (map[string]interface{}, error) {
+	parts := strings.Split(jdbc, ";")
+
+	address := parts[0]
+	if i := strings.Index(address, "/"); i >= 0 {
+		address = address[:i]
+	}
+	host := address
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		host = h
+	}
+	if host == "" {
+		return nil, fmt.Errorf("invalid Databricks JDBC URL: missing host")
+	}
+
+	config := map[string]interface{}{
+		"workspace": "https://" + host,
+	}
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "httppath":
+			path := strings.TrimSpace(kv[1])
+			if !strings.HasPrefix(path, "/") {
+				path = "/" + path
+			}
+			config["http_path"] = path
+		case "pwd", "password":
+			config["access_token"] = strings.TrimSpace(kv[1])
+		}
+	}
+	return config, nil
+}
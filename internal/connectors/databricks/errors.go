@@ -0,0 +1,24 @@
+package databricks
+
+import (
+	"errors"
+	"strings"
+
+	dbsqlerr "github.com/databricks/databricks-sql-go/errors"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+)
+
+// ClassifyError recognizes a warehouse that is starting or temporarily
+// unavailable, which the driver reports as retryable
+func (c *Connector) ClassifyError(err error) string {
+	var dbErr dbsqlerr.DBError
+	if errors.As(err, &dbErr) && dbErr.IsRetryable() {
+		return connectors.ErrorClassUnavailable
+	}
+	message := err.Error()
+	if strings.Contains(message, "TEMPORARILY_UNAVAILABLE") || strings.Contains(message, "503 Service Unavailable") {
+		return connectors.ErrorClassUnavailable
+	}
+	return ""
+}
@@ -0,0 +1,18 @@
+package databricks
+
+import (
+	"strings"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/values"
+)
+
+// MapValue converts a scanned value into its canonical form. The driver
+// returns DATE as UTC midnight converted to the session location, so the
+// calendar date is read in UTC.
+func (c *Connector) MapValue(v interface{}, databaseType string) (values.Value, error) {
+	if t, ok := v.(time.Time); ok && strings.EqualFold(databaseType, "DATE") {
+		return values.Date(t.UTC()), nil
+	}
+	return values.FromDriver(v, databaseType)
+}
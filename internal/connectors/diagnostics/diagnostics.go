@@ -0,0 +1,337 @@
+// Package diagnostics runs a staged connection test that reports where
+// connecting to a database fails: name resolution, TCP, the SSH tunnel,
+// TLS, authentication, queries or metadata access.
+package diagnostics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/connectors/sshtunnel"
+)
+
+// stepTimeout bounds each network step
+const stepTimeout = 10 * time.Second
+
+// Step names in the order they run
+const (
+	StepResolve   = "resolve"
+	StepTCP       = "tcp"
+	StepSSHTunnel = "ssh_tunnel"
+	StepTLS       = "tls"
+	StepAuth      = "auth"
+	StepQuery     = "query"
+	StepMetadata  = "metadata"
+)
+
+// Status is the outcome of a step
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// Step reports the outcome of a single check
+type Step struct {
+	Name      string `json:"name"`
+	Status    Status `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Message   string `json:"message,omitempty"`
+	Hint      string `json:"hint,omitempty"`
+}
+
+// ServerInfo describes the server and what the connected user may do on the configured database
+type ServerInfo struct {
+	Version     string   `json:"version,omitempty"`
+	CurrentUser string   `json:"current_user,omitempty"`
+	Database    string   `json:"database,omitempty"`
+	Privileges  []string `json:"privileges"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// Report is the result of a diagnostic run
+type Report struct {
+	Success bool        `json:"success"`
+	Message string      `json:"message"`
+	Steps   []Step      `json:"steps"`
+	Server  *ServerInfo `json:"server,omitempty"`
+}
+
+// Target is the network endpoint a connector dials
+type Target struct {
+	Host      string
+	Port      int
+	SSHTunnel *sshtunnel.Config
+}
+
+// Address returns the host:port of the database server
+func (t Target) Address() string {
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+// Prober is implemented by connectors that expose their network path,
+// enabling the resolve, TCP, SSH tunnel and TLS steps
+type Prober interface {
+	// Target returns the endpoint described by config
+	Target(config interface{}) (Target, error)
+	// ProbeTLS performs the TLS handshake the driver would perform over conn
+	// and describes the negotiated session. Return Skip when TLS is not used.
+	ProbeTLS(ctx context.Context, config interface{}, conn net.Conn) (string, error)
+}
+
+// Inspector is implemented by connectors that can report server details
+type Inspector interface {
+	ServerInfo(ctx context.Context, db *sql.DB) (*ServerInfo, error)
+}
+
+// Explainer is implemented by connectors that can suggest a fix for driver errors
+type Explainer interface {
+	// ExplainError returns a hint for err, or an empty string if it is not recognized
+	ExplainError(err error) string
+}
+
+// SkipError marks a step that does not apply to the configuration
+type SkipError struct {
+	Reason string
+}
+
+func (e *SkipError) Error() string {
+	return e.Reason
+}
+
+// Skip returns an error that marks the current step as skipped
+func Skip(reason string) error {
+	return &SkipError{Reason: reason}
+}
+
+// runner tracks the report while steps execute
+type runner struct {
+	ctx       context.Context
+	connector connectors.Connector
+	report    *Report
+	failed    bool
+}
+
+// Run executes every step against config. Once a step fails the remaining
+// steps are reported as skipped.
+func Run(ctx context.Context, connector connectors.Connector, config interface{}) *Report {
+	r := &runner{
+		ctx:       ctx,
+		connector: connector,
+		report:    &Report{Steps: []Step{}},
+	}
+
+	if prober, ok := connector.(Prober); ok {
+		r.runNetwork(prober, config)
+	} else {
+		for _, name := range []string{StepResolve, StepTCP, StepTLS} {
+			r.skip(name, "not supported by the "+connector.Type()+" connector")
+		}
+	}
+
+	var db *sql.DB
+	r.step(StepAuth, func(ctx context.Context) (string, error) {
+		var err error
+		db, err = connector.Connect(config)
+		if err != nil {
+			return "", err
+		}
+		if err := db.PingContext(ctx); err != nil {
+			return "", connectors.ExplainTLSError(err)
+		}
+		return "logged in", nil
+	})
+	if db != nil {
+		defer db.Close()
+	}
+
+	r.step(StepQuery, func(ctx context.Context) (string, error) {
+		var result int
+		if err := db.QueryRowContext(ctx, "SELECT 1").Scan(&result); err != nil {
+			return "", err
+		}
+		return "SELECT 1 succeeded", nil
+	})
+
+	r.step(StepMetadata, func(ctx context.Context) (string, error) {
+		tables, err := connector.GetTables(db)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d tables visible", len(tables)), nil
+	})
+
+	if inspector, ok := connector.(Inspector); ok && !r.failed {
+		info, err := inspector.ServerInfo(ctx, db)
+		if err != nil {
+			info = &ServerInfo{Error: err.Error()}
+		}
+		r.report.Server = info
+	}
+
+	return r.finish()
+}
+
+// runNetwork checks the path to the server ahead of the driver
+func (r *runner) runNetwork(prober Prober, config interface{}) {
+	var target Target
+	r.step(StepResolve, func(ctx context.Context) (string, error) {
+		var err error
+		if target, err = prober.Target(config); err != nil {
+			return "", err
+		}
+		host := target.Host
+		if target.SSHTunnel != nil {
+			// The database host is resolved by the bastion
+			host = target.SSHTunnel.Host
+		}
+		addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s resolved to %s", host, strings.Join(addrs, ", ")), nil
+	})
+
+	r.step(StepTCP, func(ctx context.Context) (string, error) {
+		address := target.Address()
+		if target.SSHTunnel != nil {
+			address = target.SSHTunnel.Address()
+		}
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return "", err
+		}
+		conn.Close()
+		return "connected to " + address, nil
+	})
+
+	var tunnel *sshtunnel.Tunnel
+	if target.SSHTunnel != nil {
+		r.step(StepSSHTunnel, func(ctx context.Context) (string, error) {
+			var err error
+			if tunnel, err = sshtunnel.Open(target.SSHTunnel); err != nil {
+				return "", err
+			}
+			conn, err := tunnel.DialContext(ctx, "tcp", target.Address())
+			if err != nil {
+				return "", err
+			}
+			conn.Close()
+			return fmt.Sprintf("forwarded to %s through %s", target.Address(), target.SSHTunnel.Address()), nil
+		})
+		if tunnel != nil {
+			defer tunnel.Close()
+		}
+	}
+
+	r.step(StepTLS, func(ctx context.Context) (string, error) {
+		var conn net.Conn
+		var err error
+		if tunnel != nil {
+			conn, err = tunnel.DialContext(ctx, "tcp", target.Address())
+		} else {
+			var d net.Dialer
+			conn, err = d.DialContext(ctx, "tcp", target.Address())
+		}
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		return prober.ProbeTLS(ctx, config, conn)
+	})
+}
+
+// step runs fn and records its outcome
+func (r *runner) step(name string, fn func(ctx context.Context) (string, error)) {
+	if r.failed {
+		r.skip(name, "skipped because an earlier step failed")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, stepTimeout)
+	defer cancel()
+
+	start := time.Now()
+	message, err := fn(ctx)
+	step := Step{Name: name, Status: StatusPassed, LatencyMS: time.Since(start).Milliseconds(), Message: message}
+
+	var skip *SkipError
+	switch {
+	case errors.As(err, &skip):
+		step.Status = StatusSkipped
+		step.Message = skip.Reason
+	case err != nil:
+		step.Status = StatusFailed
+		step.Message = err.Error()
+		step.Hint = r.hint(name, err)
+		r.failed = true
+	}
+	r.report.Steps = append(r.report.Steps, step)
+}
+
+func (r *runner) skip(name, reason string) {
+	r.report.Steps = append(r.report.Steps, Step{Name: name, Status: StatusSkipped, Message: reason})
+}
+
+// hint suggests what to check after err failed the named step
+func (r *runner) hint(name string, err error) string {
+	var tlsErr *connectors.TLSError
+	if errors.As(err, &tlsErr) && tlsErr.Hint != "" {
+		return tlsErr.Hint
+	}
+	if explainer, ok := r.connector.(Explainer); ok {
+		if hint := explainer.ExplainError(err); hint != "" {
+			return hint
+		}
+	}
+
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+		return "the host name does not resolve from the CompareFlow server; check for typos or private DNS zones"
+	case errors.As(err, &dnsErr):
+		return "DNS lookup failed; check the resolver configuration of the CompareFlow server"
+	case strings.Contains(err.Error(), "connection refused"):
+		return "nothing is listening on that port; check the port and that the server is running"
+	case errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "i/o timeout"):
+		return "the connection timed out; check firewalls, security groups and network routes"
+	case strings.Contains(err.Error(), "ssh: handshake failed"):
+		return "check the SSH user, credentials and known_hosts entry"
+	case strings.Contains(err.Error(), "ssh_tunnel: failed to reach"):
+		return "the bastion could not reach the database; check that it allows TCP forwarding"
+	}
+
+	switch name {
+	case StepAuth:
+		return "check the username, password and database name"
+	case StepMetadata:
+		return "the user cannot read the catalog; grant access to information_schema or the system views"
+	}
+	return ""
+}
+
+func (r *runner) finish() *Report {
+	r.report.Success = !r.failed
+	r.report.Message = "Connection test successful"
+	for _, step := range r.report.Steps {
+		if step.Status == StatusFailed {
+			r.report.Message = fmt.Sprintf("%s step failed: %s", step.Name, step.Message)
+			break
+		}
+	}
+	return r.report
+}
@@ -0,0 +1,94 @@
+package diagnostics
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"testing"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+)
+
+// fakeConnector probes a fixed address and never reaches the driver steps
+type fakeConnector struct {
+	connectors.Connector
+	target Target
+}
+
+func (f *fakeConnector) Type() string { return "fake" }
+
+func (f *fakeConnector) Connect(config interface{}) (*sql.DB, error) {
+	panic("Connect should not run after a failed network step")
+}
+
+func (f *fakeConnector) Target(config interface{}) (Target, error) { return f.target, nil }
+
+func (f *fakeConnector) ProbeTLS(ctx context.Context, config interface{}, conn net.Conn) (string, error) {
+	return "", Skip("TLS disabled")
+}
+
+func TestRun_ConnectionRefused(t *testing.T) {
+	// Grab a free port and release it so nothing is listening
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	report := Run(context.Background(), &fakeConnector{target: Target{Host: "127.0.0.1", Port: port}}, nil)
+
+	if report.Success {
+		t.Fatal("Run() should fail when the port is closed")
+	}
+	want := []struct {
+		name   string
+		status Status
+	}{
+		{StepResolve, StatusPassed},
+		{StepTCP, StatusFailed},
+		{StepTLS, StatusSkipped},
+		{StepAuth, StatusSkipped},
+		{StepQuery, StatusSkipped},
+		{StepMetadata, StatusSkipped},
+	}
+	if len(report.Steps) != len(want) {
+		t.Fatalf("Run() returned %d steps, want %d: %+v", len(report.Steps), len(want), report.Steps)
+	}
+	for i, w := range want {
+		if report.Steps[i].Name != w.name || report.Steps[i].Status != w.status {
+			t.Errorf("step %d = %s/%s, want %s/%s", i, report.Steps[i].Name, report.Steps[i].Status, w.name, w.status)
+		}
+	}
+	if report.Steps[1].Hint == "" {
+		t.Error("failed tcp step should include a hint")
+	}
+}
+
+func TestRun_TLSSkipped(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	r := &runner{ctx: context.Background(), report: &Report{}}
+	addr := listener.Addr().(*net.TCPAddr)
+	r.runNetwork(&fakeConnector{target: Target{Host: "127.0.0.1", Port: addr.Port}}, nil)
+
+	if r.failed {
+		t.Fatalf("runNetwork() failed: %+v", r.report.Steps)
+	}
+	if last := r.report.Steps[len(r.report.Steps)-1]; last.Name != StepTLS || last.Status != StatusSkipped {
+		t.Errorf("tls step = %s/%s, want skipped", last.Name, last.Status)
+	}
+}
@@ -0,0 +1,37 @@
+package connectors
+
+import "fmt"
+
+// ApplyDSN parses dsn and fills the empty config fields from it. fields maps
+// the keys of the parsed config to pointers to the fields they fill: *string,
+// *int and *bool are set when zero, **bool when nil.
+func ApplyDSN(parser DSNParser, dsn string, fields map[string]interface{}) error {
+	parsed, err := parser.ParseDSN(dsn)
+	if err != nil {
+		return fmt.Errorf("dsn: %w", err)
+	}
+
+	for key, field := range fields {
+		switch field := field.(type) {
+		case *string:
+			if value, ok := parsed[key].(string); ok && *field == "" {
+				*field = value
+			}
+		case *int:
+			if value, ok := parsed[key].(int); ok && *field == 0 {
+				*field = value
+			}
+		case *bool:
+			if value, ok := parsed[key].(bool); ok && !*field {
+				*field = value
+			}
+		case **bool:
+			if value, ok := parsed[key].(bool); ok && *field == nil {
+				*field = &value
+			}
+		default:
+			panic(fmt.Sprintf("connectors: unsupported DSN field type %T for %s", field, key))
+		}
+	}
+	return nil
+}
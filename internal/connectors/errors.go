@@ -0,0 +1,62 @@
+package connectors
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// Error classes tell transient infrastructure failures apart from errors
+// that will recur on every attempt
+const (
+	// ErrorClassConnection is a refused, dropped or reset connection
+	ErrorClassConnection = "connection"
+	// ErrorClassTimeout is a query or network timeout
+	ErrorClassTimeout = "timeout"
+	// ErrorClassDeadlock is a deadlock victim or serialization failure
+	ErrorClassDeadlock = "deadlock"
+	// ErrorClassUnavailable is a server that is starting, throttling or
+	// temporarily unable to serve, e.g. a warehouse cold start
+	ErrorClassUnavailable = "unavailable"
+	// ErrorClassOther is everything else, e.g. invalid SQL or permissions
+	ErrorClassOther = "other"
+)
+
+// ErrorClassifier is implemented by connectors that recognize their
+// driver's transient errors
+type ErrorClassifier interface {
+	// ClassifyError returns the class of err, or "" if the connector does
+	// not recognize it
+	ClassifyError(err error) string
+}
+
+// ClassifyError returns the class of an error from a connector's database,
+// asking the connector first and falling back to network and context errors.
+// connector may be nil.
+func ClassifyError(connector Connector, err error) string {
+	if err == nil {
+		return ""
+	}
+	if classifier, ok := connector.(ErrorClassifier); ok {
+		if class := classifier.ClassifyError(err); class != "" {
+			return class
+		}
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return ErrorClassConnection
+	case errors.As(err, &netErr):
+		return ErrorClassConnection
+	}
+	return ErrorClassOther
+}
@@ -0,0 +1,194 @@
+package postgresql
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/lib/pq"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/connectors/diagnostics"
+)
+
+// sslRequest is the startup packet asking the server to switch to TLS
+var sslRequest = []byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f}
+
+// Target returns the server endpoint for diagnostics
+func (c *Connector) Target(config interface{}) (diagnostics.Target, error) {
+	pgConfig, ok := config.(*Config)
+	if !ok {
+		return diagnostics.Target{}, fmt.Errorf("invalid config type: expected *Config, got %T", config)
+	}
+	return diagnostics.Target{Host: pgConfig.Host, Port: pgConfig.Port, SSHTunnel: pgConfig.SSHTunnel}, nil
+}
+
+// ProbeTLS negotiates TLS the way libpq does for the configured ssl_mode
+func (c *Connector) ProbeTLS(ctx context.Context, config interface{}, conn net.Conn) (string, error) {
+	pgConfig, ok := config.(*Config)
+	if !ok {
+		return "", fmt.Errorf("invalid config type: expected *Config, got %T", config)
+	}
+	if pgConfig.SSLMode == "disable" {
+		return "", diagnostics.Skip("TLS is disabled by ssl_mode")
+	}
+
+	if _, err := conn.Write(sslRequest); err != nil {
+		return "", fmt.Errorf("failed to send SSL request: %w", err)
+	}
+	reply := make([]byte, 1)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return "", fmt.Errorf("failed to read SSL response: %w", err)
+	}
+	if reply[0] != 'S' {
+		if pgConfig.SSLMode == "allow" || pgConfig.SSLMode == "prefer" {
+			return "", diagnostics.Skip("server does not offer TLS; the connection will not be encrypted")
+		}
+		return "", fmt.Errorf("server does not support TLS but ssl_mode is %s", pgConfig.SSLMode)
+	}
+
+	tlsConfig, verification, err := probeTLSConfig(pgConfig)
+	if err != nil {
+		return "", err
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return "", connectors.ExplainTLSError(err)
+	}
+	return fmt.Sprintf("%s, %s", tls.VersionName(tlsConn.ConnectionState().Version), verification), nil
+}
+
+// probeTLSConfig mirrors the certificate checks lib/pq applies for each ssl_mode
+func probeTLSConfig(config *Config) (*tls.Config, string, error) {
+	serverName := config.Host
+	if config.SSLServerName != "" {
+		serverName = config.SSLServerName
+	}
+	tlsConfig := &tls.Config{ServerName: serverName}
+
+	if config.SSLRootCert != "" {
+		pool, err := connectors.ParseCertPool(config.SSLRootCert)
+		if err != nil {
+			return nil, "", fmt.Errorf("ssl_root_cert: %w", err)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.SSLCert != "" {
+		cert, err := connectors.ParseClientCertificate(config.SSLCert, config.SSLKey)
+		if err != nil {
+			return nil, "", fmt.Errorf("ssl_cert: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	mode := config.SSLMode
+	if mode == "require" && config.SSLRootCert != "" {
+		// libpq treats require with a root certificate as verify-ca
+		mode = "verify-ca"
+	}
+
+	switch mode {
+	case "verify-full":
+		return tlsConfig, "certificate and host name verified", nil
+	case "verify-ca":
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = verifyChain(tlsConfig.RootCAs)
+		return tlsConfig, "certificate chain verified", nil
+	default:
+		tlsConfig.InsecureSkipVerify = true
+		return tlsConfig, "certificate not verified (ssl_mode " + config.SSLMode + ")", nil
+	}
+}
+
+// verifyChain checks the server certificate against roots without checking the host name
+func verifyChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+		if len(certs) == 0 {
+			return fmt.Errorf("server sent no certificate")
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		return err
+	}
+}
+
+// ServerInfo reports the server version and the current user's database privileges
+func (c *Connector) ServerInfo(ctx context.Context, db *sql.DB) (*diagnostics.ServerInfo, error) {
+	info := &diagnostics.ServerInfo{}
+	err := db.QueryRowContext(ctx, "SELECT version(), current_user, current_database()").
+		Scan(&info.Version, &info.CurrentUser, &info.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query server version: %w", err)
+	}
+
+	var superuser bool
+	var connect, create, temporary bool
+	var readable, total int
+	err = db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT rolsuper FROM pg_roles WHERE rolname = current_user),
+			has_database_privilege(current_database(), 'CONNECT'),
+			has_database_privilege(current_database(), 'CREATE'),
+			has_database_privilege(current_database(), 'TEMPORARY'),
+			count(*) FILTER (WHERE has_table_privilege(quote_ident(schemaname) || '.' || quote_ident(tablename), 'SELECT')),
+			count(*)
+		FROM pg_tables
+		WHERE schemaname NOT IN ('pg_catalog', 'information_schema')
+	`).Scan(&superuser, &connect, &create, &temporary, &readable, &total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query privileges: %w", err)
+	}
+
+	if superuser {
+		info.Privileges = append(info.Privileges, "SUPERUSER")
+	}
+	if connect {
+		info.Privileges = append(info.Privileges, "CONNECT")
+	}
+	if create {
+		info.Privileges = append(info.Privileges, "CREATE")
+	}
+	if temporary {
+		info.Privileges = append(info.Privileges, "TEMPORARY")
+	}
+	info.Privileges = append(info.Privileges, fmt.Sprintf("SELECT on %d of %d tables", readable, total))
+	return info, nil
+}
+
+// ExplainError suggests a fix for common PostgreSQL errors
+func (c *Connector) ExplainError(err error) string {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return ""
+	}
+	switch pqErr.Code {
+	case "28P01":
+		return "password authentication failed; check the username and password"
+	case "28000":
+		return "the server rejected this user or client address; check pg_hba.conf and ssl_mode"
+	case "3D000":
+		return "the database does not exist; check the database name"
+	case "42501":
+		return "the user lacks privileges; grant CONNECT on the database and USAGE and SELECT on the schemas to validate"
+	case "53300":
+		return "the server has no free connection slots; retry later or raise max_connections"
+	}
+	return ""
+}
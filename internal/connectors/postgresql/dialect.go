@@ -0,0 +1,49 @@
+package postgresql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuoteIdentifier quotes a PostgreSQL identifier, preserving dots between schema and name
+func (c *Connector) QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+// Placeholder returns the n-th bind parameter marker; PostgreSQL numbers its bind parameters
+func (c *Connector) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// NormalizeExpression renders expr as canonical text for hashing
+func (c *Connector) NormalizeExpression(expr, databaseType string) string {
+	switch strings.ToUpper(databaseType) {
+	case "INT2", "INT4", "INT8", "OID":
+		return expr + "::text"
+	case "NUMERIC", "FLOAT4", "FLOAT8", "MONEY":
+		return "CAST(" + expr + " AS NUMERIC(38, 6))::text"
+	case "BOOL":
+		return "CASE WHEN " + expr + " THEN '1' ELSE '0' END"
+	case "DATE":
+		return "to_char(" + expr + ", 'YYYY-MM-DD')"
+	case "TIMESTAMP":
+		return "to_char(" + expr + ", 'YYYY-MM-DD HH24:MI:SS.US')"
+	case "TIMESTAMPTZ":
+		return "to_char(" + expr + " AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI:SS.US')"
+	case "UUID":
+		return "lower(" + expr + "::text)"
+	case "BYTEA":
+		return "encode(" + expr + ", 'hex')"
+	}
+	return expr + "::text"
+}
+
+// HashExpression reads four bytes of the MD5 digest as a BIGINT
+func (c *Connector) HashExpression(texts []string, part int) string {
+	return fmt.Sprintf("('x' || substr(md5(concat_ws('|', %s)), %d, 8))::bit(32)::bigint",
+		strings.Join(texts, ", "), part*8+1)
+}
@@ -0,0 +1,130 @@
+package postgresql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/lib/pq"
+)
+
+// ParseDSN converts a PostgreSQL connection string into a raw config map.
+// Accepted forms are postgres:// URLs, JDBC URLs and libpq key=value strings.
+func (c *Connector) ParseDSN(dsn string) (map[string]interface{}, error) {
+	params, err := parseDSNParams(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]interface{})
+	for key, value := range params {
+		switch key {
+		case "host":
+			config["host"] = value
+		case "port":
+			port, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid port %q", value)
+			}
+			config["port"] = port
+		case "dbname":
+			config["database"] = value
+		case "user":
+			config["username"] = value
+		case "password":
+			config["password"] = value
+		case "sslmode":
+			config["ssl_mode"] = value
+		}
+	}
+
+	// JDBC uses ssl=true instead of an sslmode
+	if _, ok := config["ssl_mode"]; !ok && params["ssl"] == "true" {
+		config["ssl_mode"] = "require"
+	}
+
+	return config, nil
+}
+
+// parseDSNParams normalizes any accepted DSN form into libpq parameters
+func parseDSNParams(dsn string) (map[string]string, error) {
+	dsn = strings.TrimSpace(dsn)
+	dsn = strings.TrimPrefix(dsn, "jdbc:")
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		converted, err := pq.ParseURL(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PostgreSQL URL: %w", err)
+		}
+		dsn = converted
+	}
+
+	return parseKeyValue(dsn)
+}
+
+// parseKeyValue parses a libpq key=value string; values may be single quoted
+// with backslash escapes
+func parseKeyValue(dsn string) (map[string]string, error) {
+	params := make(map[string]string)
+	runes := []rune(dsn)
+	i := 0
+
+	skipSpaces := func() {
+		for i < len(runes) && unicode.IsSpace(runes[i]) {
+			i++
+		}
+	}
+
+	for {
+		skipSpaces()
+		if i >= len(runes) {
+			return params, nil
+		}
+
+		start := i
+		for i < len(runes) && runes[i] != '=' && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+		key := string(runes[start:i])
+		skipSpaces()
+		if i >= len(runes) || runes[i] != '=' {
+			return nil, fmt.Errorf("missing \"=\" after %q in connection string", key)
+		}
+		i++
+		skipSpaces()
+
+		var value strings.Builder
+		if i < len(runes) && runes[i] == '\'' {
+			i++
+			closed := false
+			for i < len(runes) {
+				r := runes[i]
+				i++
+				if r == '\\' && i < len(runes) {
+					value.WriteRune(runes[i])
+					i++
+					continue
+				}
+				if r == '\'' {
+					closed = true
+					break
+				}
+				value.WriteRune(r)
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated quoted value for %q in connection string", key)
+			}
+		} else {
+			for i < len(runes) && !unicode.IsSpace(runes[i]) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+		}
+
+		params[key] = value.String()
+	}
+}
@@ -0,0 +1,29 @@
+package postgresql
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+)
+
+// ClassifyError recognizes transient PostgreSQL errors by SQLSTATE
+func (c *Connector) ClassifyError(err error) string {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return ""
+	}
+	switch code := string(pqErr.Code); {
+	case code == "40P01", code == "40001": // deadlock_detected, serialization_failure
+		return connectors.ErrorClassDeadlock
+	case code == "57014", code == "55P03": // query_canceled (statement_timeout), lock_not_available
+		return connectors.ErrorClassTimeout
+	case code == "57P01", code == "57P02", code == "57P03", code == "53300": // shutdown, starting up, too many connections
+		return connectors.ErrorClassUnavailable
+	case strings.HasPrefix(code, "08"): // connection exceptions
+		return connectors.ErrorClassConnection
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package postgresql
+
+import (
+	"strings"
+
+	"github.com/compareflow/compareflow/internal/values"
+)
+
+// MapValue converts a scanned value into its canonical form. The driver
+// returns MONEY in the server's lc_monetary format, e.g. "$1,234.50", so the
+// currency symbol and group separators are dropped before parsing.
+func (c *Connector) MapValue(v interface{}, databaseType string) (values.Value, error) {
+	if b, ok := v.([]byte); ok && strings.EqualFold(databaseType, "MONEY") {
+		text := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' || r == '.' || r == '-' {
+				return r
+			}
+			return -1
+		}, string(b))
+		return values.ParseDecimal(text)
+	}
+	return values.FromDriver(v, databaseType)
+}
@@ -2,6 +2,7 @@ package connectors
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -40,4 +41,19 @@ func List() []string {
 		types = append(types, t)
 	}
 	return types
+}
+
+// Descriptors returns the descriptors of all registered connectors, sorted by type
+func Descriptors() []Descriptor {
+	mu.RLock()
+	defer mu.RUnlock()
+	
+	descriptors := make([]Descriptor, 0, len(registry))
+	for _, factory := range registry {
+		descriptors = append(descriptors, Describe(factory()))
+	}
+	sort.Slice(descriptors, func(i, j int) bool {
+		return descriptors[i].Type < descriptors[j].Type
+	})
+	return descriptors
 }
\ No newline at end of file
@@ -0,0 +1,169 @@
+package connectors
+
+// FieldType identifies the kind of value a configuration field holds
+type FieldType string
+
+const (
+	FieldTypeString  FieldType = "string"
+	FieldTypeInteger FieldType = "integer"
+	FieldTypeBoolean FieldType = "boolean"
+	FieldTypeObject  FieldType = "object"
+)
+
+// Field describes a single connector configuration field
+type Field struct {
+	Name     string    `json:"name"`
+	Label    string    `json:"label"`
+	Type     FieldType `json:"type"`
+	Required bool      `json:"required,omitempty"`
+	// RequiredUnless names a sibling field that makes this one optional when
+	// set, as a connection string does for the fields it supplies
+	RequiredUnless string      `json:"required_unless,omitempty"`
+	Default        interface{} `json:"default,omitempty"`
+	Secret         bool        `json:"secret,omitempty"`
+	Multiline      bool        `json:"multiline,omitempty"`
+	Help           string      `json:"help,omitempty"`
+	Placeholder    string      `json:"placeholder,omitempty"`
+	Enum           []string    `json:"enum,omitempty"`
+	Fields         []Field     `json:"fields,omitempty"` // Nested fields for FieldTypeObject
+}
+
+// ConfigSchema describes the configuration accepted by a connector
+type ConfigSchema struct {
+	Title  string  `json:"title"`
+	Fields []Field `json:"fields"`
+}
+
+// JSONSchema renders the schema as a JSON Schema document.
+// Field order is preserved through the "propertyOrder" keyword so that
+// clients can render forms in the order the connector declared them.
+func (s ConfigSchema) JSONSchema() map[string]interface{} {
+	schema := objectSchema(s.Fields)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = s.Title
+	return schema
+}
+
+func objectSchema(fields []Field) map[string]interface{} {
+	properties := make(map[string]interface{}, len(fields))
+	required := []string{}
+	// conditional lists the fields required unless another field is set, by
+	// that field
+	conditional := make(map[string][]string)
+	var unless []string
+
+	for i, field := range fields {
+		properties[field.Name] = fieldSchema(field, i)
+		if field.Required {
+			required = append(required, field.Name)
+		}
+		if other := field.RequiredUnless; other != "" {
+			if _, ok := conditional[other]; !ok {
+				unless = append(unless, other)
+			}
+			conditional[other] = append(conditional[other], field.Name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	if len(unless) > 0 {
+		allOf := make([]interface{}, len(unless))
+		for i, other := range unless {
+			allOf[i] = map[string]interface{}{"anyOf": []interface{}{
+				map[string]interface{}{"required": []string{other}},
+				map[string]interface{}{"required": conditional[other]},
+			}}
+		}
+		schema["allOf"] = allOf
+	}
+	return schema
+}
+
+func fieldSchema(field Field, order int) map[string]interface{} {
+	var prop map[string]interface{}
+	if field.Type == FieldTypeObject {
+		prop = objectSchema(field.Fields)
+	} else {
+		prop = map[string]interface{}{"type": string(field.Type)}
+	}
+
+	prop["title"] = field.Label
+	prop["propertyOrder"] = order
+	if field.Help != "" {
+		prop["description"] = field.Help
+	}
+	if field.Default != nil {
+		prop["default"] = field.Default
+	}
+	if len(field.Enum) > 0 {
+		prop["enum"] = field.Enum
+	}
+	if field.Placeholder != "" {
+		prop["examples"] = []string{field.Placeholder}
+	}
+	if field.Secret {
+		prop["writeOnly"] = true
+		prop["format"] = "password"
+	}
+	if field.Multiline {
+		prop["x-multiline"] = true
+	}
+	if field.RequiredUnless != "" {
+		prop["x-required-unless"] = field.RequiredUnless
+	}
+	return prop
+}
+
+// Capabilities advertises optional features supported by a connector
+type Capabilities struct {
+	SupportsCatalogs      bool `json:"supports_catalogs"`
+	SupportsHashPushdown  bool `json:"supports_hash_pushdown"`
+	SupportsSampling      bool `json:"supports_sampling"`
+	SupportsSSHTunnel     bool `json:"supports_ssh_tunnel"`
+	SupportsClientCertTLS bool `json:"supports_client_cert_tls"`
+	SupportsDSNImport     bool `json:"supports_dsn_import"`
+}
+
+// Descriptor bundles the public description of a connector
+type Descriptor struct {
+	Type         string                 `json:"type"`
+	Title        string                 `json:"title"`
+	ConfigSchema map[string]interface{} `json:"config_schema"`
+	Capabilities Capabilities           `json:"capabilities"`
+}
+
+// Describe builds the descriptor for a connector
+func Describe(c Connector) Descriptor {
+	schema := c.ConfigSchema()
+	return Descriptor{
+		Type:         c.Type(),
+		Title:        schema.Title,
+		ConfigSchema: schema.JSONSchema(),
+		Capabilities: c.Capabilities(),
+	}
+}
+
+// SecretPaths returns the path of every secret field, descending into nested objects
+func (s ConfigSchema) SecretPaths() [][]string {
+	return secretPaths(s.Fields, nil)
+}
+
+func secretPaths(fields []Field, parent []string) [][]string {
+	var paths [][]string
+	for _, field := range fields {
+		path := append(append([]string{}, parent...), field.Name)
+		if field.Type == FieldTypeObject {
+			paths = append(paths, secretPaths(field.Fields, path)...)
+		} else if field.Secret {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
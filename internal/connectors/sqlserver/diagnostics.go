@@ -0,0 +1,81 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+
+	"github.com/compareflow/compareflow/internal/connectors/diagnostics"
+)
+
+// Target returns the server endpoint for diagnostics
+func (c *Connector) Target(config interface{}) (diagnostics.Target, error) {
+	sqlConfig, ok := config.(*Config)
+	if !ok {
+		return diagnostics.Target{}, fmt.Errorf("invalid config type: expected *Config, got %T", config)
+	}
+	// A named instance is addressed by host\instance; only the host is dialed
+	host := strings.SplitN(sqlConfig.Server, `\`, 2)[0]
+	return diagnostics.Target{Host: host, Port: sqlConfig.Port, SSHTunnel: sqlConfig.SSHTunnel}, nil
+}
+
+// ProbeTLS is skipped for SQL Server because TLS is negotiated inside TDS
+// pre-login packets; handshake failures surface in the auth step instead
+func (c *Connector) ProbeTLS(ctx context.Context, config interface{}, conn net.Conn) (string, error) {
+	return "", diagnostics.Skip("SQL Server negotiates TLS during login; handshake errors are reported by the auth step")
+}
+
+// ServerInfo reports the server version and the current user's database permissions
+func (c *Connector) ServerInfo(ctx context.Context, db *sql.DB) (*diagnostics.ServerInfo, error) {
+	info := &diagnostics.ServerInfo{}
+	err := db.QueryRowContext(ctx, "SELECT @@VERSION, SUSER_SNAME(), DB_NAME()").
+		Scan(&info.Version, &info.CurrentUser, &info.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query server version: %w", err)
+	}
+	// @@VERSION spans several lines; the first names the product and build
+	info.Version = strings.TrimSpace(strings.SplitN(info.Version, "\n", 2)[0])
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT permission_name
+		FROM fn_my_permissions(NULL, 'DATABASE')
+		ORDER BY permission_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query permissions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		info.Privileges = append(info.Privileges, permission)
+	}
+	return info, rows.Err()
+}
+
+// ExplainError suggests a fix for common SQL Server errors
+func (c *Connector) ExplainError(err error) string {
+	var mssqlErr mssql.Error
+	if !errors.As(err, &mssqlErr) {
+		return ""
+	}
+	switch mssqlErr.Number {
+	case 18456:
+		return "login failed; check the username and password and that SQL Server authentication is enabled"
+	case 4060:
+		return "the database cannot be opened; check the database name and that the login is mapped to a user in it"
+	case 18452:
+		return "the login is from an untrusted domain; use a SQL Server login"
+	case 229, 230:
+		return "the user lacks permissions; grant VIEW DEFINITION and SELECT on the schemas to validate"
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package sqlserver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuoteIdentifier quotes a SQL Server identifier, preserving dots between schema and name
+func (c *Connector) QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = "[" + strings.ReplaceAll(part, "]", "]]") + "]"
+	}
+	return strings.Join(parts, ".")
+}
+
+// Placeholder returns the n-th bind parameter marker; SQL Server binds ordinal parameters as @p1, @p2, ...
+func (c *Connector) Placeholder(n int) string {
+	return fmt.Sprintf("@p%d", n)
+}
+
+// NormalizeExpression renders expr as canonical text for hashing.
+// Text is hashed as VARCHAR, so only characters in the database code page
+// (or a UTF-8 collation) hash the same as on other connectors.
+func (c *Connector) NormalizeExpression(expr, databaseType string) string {
+	switch strings.ToUpper(databaseType) {
+	case "TINYINT", "SMALLINT", "INT", "BIGINT", "BIT":
+		return "CAST(" + expr + " AS VARCHAR(20))"
+	case "DECIMAL", "NUMERIC", "MONEY", "SMALLMONEY", "FLOAT", "REAL":
+		return "CAST(CAST(" + expr + " AS DECIMAL(38, 6)) AS VARCHAR(48))"
+	case "DATE":
+		return "CONVERT(VARCHAR(10), " + expr + ", 23)"
+	case "DATETIME", "DATETIME2", "SMALLDATETIME":
+		return "CONVERT(VARCHAR(26), CAST(" + expr + " AS DATETIME2(6)), 121)"
+	case "DATETIMEOFFSET":
+		return "CONVERT(VARCHAR(26), CAST(SWITCHOFFSET(" + expr + ", 0) AS DATETIME2(6)), 121)"
+	case "UNIQUEIDENTIFIER":
+		return "LOWER(CAST(" + expr + " AS VARCHAR(36)))"
+	case "BINARY", "VARBINARY", "IMAGE":
+		return "LOWER(CONVERT(VARCHAR(MAX), " + expr + ", 2))"
+	case "CHAR", "NCHAR":
+		// PostgreSQL drops the padding of CHAR columns when casting to text
+		return "RTRIM(CAST(" + expr + " AS VARCHAR(MAX)))"
+	}
+	return "CAST(" + expr + " AS VARCHAR(MAX))"
+}
+
+// HashExpression reads four bytes of the MD5 digest as a BIGINT
+func (c *Connector) HashExpression(texts []string, part int) string {
+	input := texts[0]
+	if len(texts) > 1 {
+		joined := make([]string, 0, len(texts)*2-1)
+		for i, text := range texts {
+			if i > 0 {
+				joined = append(joined, "'|'")
+			}
+			joined = append(joined, text)
+		}
+		input = "CONCAT(" + strings.Join(joined, ", ") + ")"
+	}
+	return fmt.Sprintf("CAST(SUBSTRING(HASHBYTES('MD5', %s), %d, 4) AS BIGINT)", input, part*4+1)
+}
@@ -0,0 +1,114 @@
+package sqlserver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/denisenkom/go-mssqldb/msdsn"
+)
+
+// jdbcKeys maps JDBC property names to msdsn parameter names
+var jdbcKeys = map[string]string{
+	"databasename":           "database",
+	"database":               "database",
+	"user":                   "user id",
+	"username":               "user id",
+	"password":               "password",
+	"encrypt":                "encrypt",
+	"trustservercertificate": "trustservercertificate",
+	"hostnameincertificate":  "hostnameincertificate",
+	"portnumber":             "port",
+	"port":                   "port",
+	"servername":             "server",
+	"instancename":           "instance",
+}
+
+// ParseDSN converts a SQL Server connection string into a raw config map.
+// Accepted forms are ADO.NET key=value strings, JDBC URLs, sqlserver:// URLs
+// and odbc: prefixed ODBC strings.
+func (c *Connector) ParseDSN(dsn string) (map[string]interface{}, error) {
+	dsn = strings.TrimSpace(dsn)
+
+	var params map[string]string
+	if strings.HasPrefix(strings.ToLower(dsn), "jdbc:sqlserver://") {
+		params = parseJDBC(dsn[len("jdbc:sqlserver://"):])
+	} else {
+		var err error
+		if _, params, err = msdsn.Parse(dsn); err != nil {
+			return nil, fmt.Errorf("invalid SQL Server connection string: %w", err)
+		}
+	}
+
+	config := make(map[string]interface{})
+	if server := params["server"]; server != "" {
+		config["server"] = server
+	}
+	if port := params["port"]; port != "" {
+		value, err := strconv.Atoi(port)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q", port)
+		}
+		config["port"] = value
+	}
+	if database := params["database"]; database != "" {
+		config["database"] = database
+	}
+	if user := params["user id"]; user != "" {
+		config["username"] = user
+	}
+	if password := params["password"]; password != "" {
+		config["password"] = password
+	}
+	if encrypt, ok := params["encrypt"]; ok {
+		config["encrypt"] = parseBool(encrypt)
+	}
+	if trust, ok := params["trustservercertificate"]; ok {
+		config["trust_server_certificate"] = parseBool(trust)
+	}
+	if host := params["hostnameincertificate"]; host != "" {
+		config["host_name_in_certificate"] = host
+	}
+
+	return config, nil
+}
+
+// parseJDBC splits "host\instance:port;key=value;..." into msdsn parameter names
+func parseJDBC(jdbc string) map[string]string {
+	parts := strings.Split(jdbc, ";")
+	address := parts[0]
+
+	params := make(map[string]string)
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if key, ok := jdbcKeys[strings.ToLower(strings.TrimSpace(kv[0]))]; ok {
+			params[key] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	if address != "" {
+		if i := strings.LastIndex(address, ":"); i >= 0 {
+			params["port"] = address[i+1:]
+			address = address[:i]
+		}
+		params["server"] = address
+	}
+	if instance, ok := params["instance"]; ok {
+		params["server"] += `\` + instance
+		delete(params, "instance")
+	}
+
+	return params
+}
+
+// parseBool accepts the boolean spellings used by ADO.NET and JDBC
+func parseBool(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "1", "mandatory", "strict":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package sqlserver
+
+import (
+	"errors"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+)
+
+// ClassifyError recognizes transient SQL Server errors by number
+func (c *Connector) ClassifyError(err error) string {
+	var sqlErr mssql.Error
+	if !errors.As(err, &sqlErr) {
+		return ""
+	}
+	switch sqlErr.Number {
+	case 1205: // chosen as deadlock victim
+		return connectors.ErrorClassDeadlock
+	case 1222: // lock request time out
+		return connectors.ErrorClassTimeout
+	case 233, 10053, 10054, 10060: // transport-level errors
+		return connectors.ErrorClassConnection
+	case 40197, 40501, 40613, 49918, 49919, 49920: // Azure SQL busy, throttled or failing over
+		return connectors.ErrorClassUnavailable
+	}
+	return ""
+}
@@ -1,13 +1,19 @@
 package sqlserver
 
 import (
+	"crypto/tls"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
 	"strings"
 	
-	_ "github.com/denisenkom/go-mssqldb"
+	mssql "github.com/denisenkom/go-mssqldb"
+	"github.com/denisenkom/go-mssqldb/msdsn"
 	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/connectors/sshtunnel"
 )
 
 func init() {
@@ -19,6 +25,9 @@ func init() {
 
 // Config represents SQL Server connection configuration
 type Config struct {
+	// DSN is an optional connection string; its settings fill in any
+	// discrete fields that are left empty
+	DSN                    string `json:"dsn,omitempty"`
 	Server                 string `json:"server" binding:"required"`
 	Port                   int    `json:"port"`
 	Database               string `json:"database" binding:"required"`
@@ -26,10 +35,35 @@ type Config struct {
 	Password               string `json:"password" binding:"required"`
 	Encrypt                bool   `json:"encrypt"`
 	TrustServerCertificate bool   `json:"trust_server_certificate"`
+	
+	// PEM encoded TLS material; HostNameInCertificate overrides the name
+	// verified against the server certificate when it differs from Server
+	CACert                string `json:"ca_cert,omitempty"`
+	ClientCert            string `json:"client_cert,omitempty"`
+	ClientKey             string `json:"client_key,omitempty"`
+	HostNameInCertificate string `json:"host_name_in_certificate,omitempty"`
+	
+	// SSHTunnel optionally routes the connection through a bastion host
+	SSHTunnel *sshtunnel.Config `json:"ssh_tunnel,omitempty"`
 }
 
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
+	if c.DSN != "" {
+		err := connectors.ApplyDSN(New(), c.DSN, map[string]interface{}{
+			"server":                   &c.Server,
+			"port":                     &c.Port,
+			"database":                 &c.Database,
+			"username":                 &c.Username,
+			"password":                 &c.Password,
+			"encrypt":                  &c.Encrypt,
+			"trust_server_certificate": &c.TrustServerCertificate,
+			"host_name_in_certificate": &c.HostNameInCertificate,
+		})
+		if err != nil {
+			return err
+		}
+	}
 	if c.Server == "" {
 		return fmt.Errorf("server is required")
 	}
@@ -45,6 +79,24 @@ func (c *Config) Validate() error {
 	if c.Port == 0 {
 		c.Port = 1433 // Set default
 	}
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return fmt.Errorf("client_cert and client_key must be provided together")
+	}
+	if c.CACert != "" {
+		if _, err := connectors.ParseCertPool(c.CACert); err != nil {
+			return fmt.Errorf("ca_cert: %w", err)
+		}
+	}
+	if c.ClientCert != "" {
+		if _, err := connectors.ParseClientCertificate(c.ClientCert, c.ClientKey); err != nil {
+			return fmt.Errorf("client_cert: %w", err)
+		}
+	}
+	if c.SSHTunnel != nil {
+		if err := c.SSHTunnel.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -61,6 +113,47 @@ func (c *Connector) Type() string {
 	return "sqlserver"
 }
 
+// ConfigSchema describes the SQL Server configuration fields
+func (c *Connector) ConfigSchema() connectors.ConfigSchema {
+	return connectors.ConfigSchema{
+		Title: "SQL Server",
+		Fields: []connectors.Field{
+			{Name: "dsn", Label: "Connection String", Type: connectors.FieldTypeString, Secret: true,
+				Placeholder: "Server=sql.example.com,1433;Database=mydb;User Id=user;Password=secret",
+				Help:        "Optional ADO.NET, JDBC or sqlserver:// connection string; fields left empty are taken from it"},
+			{Name: "server", Label: "Server", Type: connectors.FieldTypeString, RequiredUnless: "dsn", Placeholder: "sql.example.com"},
+			{Name: "port", Label: "Port", Type: connectors.FieldTypeInteger, Default: 1433},
+			{Name: "database", Label: "Database", Type: connectors.FieldTypeString, RequiredUnless: "dsn"},
+			{Name: "username", Label: "Username", Type: connectors.FieldTypeString, RequiredUnless: "dsn"},
+			{Name: "password", Label: "Password", Type: connectors.FieldTypeString, RequiredUnless: "dsn", Secret: true},
+			{Name: "encrypt", Label: "Encrypt Connection", Type: connectors.FieldTypeBoolean, Default: false,
+				Help: "Encrypt all traffic between CompareFlow and the server with TLS"},
+			{Name: "trust_server_certificate", Label: "Trust Server Certificate", Type: connectors.FieldTypeBoolean, Default: false,
+				Help: "Skip validation of the server certificate chain"},
+			{Name: "ca_cert", Label: "CA Certificate", Type: connectors.FieldTypeString, Secret: true, Multiline: true,
+				Help: "PEM encoded certificate of the CA that signed the server certificate"},
+			{Name: "client_cert", Label: "Client Certificate", Type: connectors.FieldTypeString, Secret: true, Multiline: true,
+				Help: "PEM encoded client certificate presented during the TLS handshake"},
+			{Name: "client_key", Label: "Client Key", Type: connectors.FieldTypeString, Secret: true, Multiline: true,
+				Help: "PEM encoded private key of the client certificate"},
+			{Name: "host_name_in_certificate", Label: "Host Name in Certificate", Type: connectors.FieldTypeString,
+				Help: "Host name expected in the server certificate, if different from Server"},
+			sshtunnel.SchemaField(),
+		},
+	}
+}
+
+// Capabilities reports the optional features supported by SQL Server
+func (c *Connector) Capabilities() connectors.Capabilities {
+	return connectors.Capabilities{
+		SupportsHashPushdown:  true,
+		SupportsSampling:      true,
+		SupportsSSHTunnel:     true,
+		SupportsClientCertTLS: true,
+		SupportsDSNImport:     true,
+	}
+}
+
 // ParseConfig parses raw config into typed config
 func (c *Connector) ParseConfig(configMap map[string]interface{}) (interface{}, error) {
 	var config Config
@@ -89,8 +182,40 @@ func (c *Connector) Connect(config interface{}) (*sql.DB, error) {
 		return nil, fmt.Errorf("invalid config type: expected *Config, got %T", config)
 	}
 	
-	connString := c.buildConnectionString(sqlConfig)
-	return sql.Open("sqlserver", connString)
+	params, _, err := msdsn.Parse(c.buildConnectionString(sqlConfig))
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection settings: %w", err)
+	}
+	
+	// Certificates are passed to the driver in memory
+	if params.TLSConfig != nil {
+		if sqlConfig.CACert != "" {
+			pool, err := connectors.ParseCertPool(sqlConfig.CACert)
+			if err != nil {
+				return nil, fmt.Errorf("ca_cert: %w", err)
+			}
+			params.TLSConfig.RootCAs = pool
+		}
+		if sqlConfig.ClientCert != "" {
+			cert, err := connectors.ParseClientCertificate(sqlConfig.ClientCert, sqlConfig.ClientKey)
+			if err != nil {
+				return nil, fmt.Errorf("client_cert: %w", err)
+			}
+			params.TLSConfig.Certificates = []tls.Certificate{cert}
+		}
+	}
+	
+	connector := mssql.NewConnectorConfig(params)
+	if sqlConfig.SSHTunnel == nil {
+		return connectors.OpenDB(connector), nil
+	}
+	
+	tunnel, err := sshtunnel.Open(sqlConfig.SSHTunnel)
+	if err != nil {
+		return nil, err
+	}
+	connector.Dialer = tunnel
+	return connectors.OpenDB(connector, tunnel), nil
 }
 
 // TestConnection verifies the SQL Server connection works
@@ -103,7 +228,7 @@ func (c *Connector) TestConnection(config interface{}) error {
 	
 	// Ping the database
 	if err := db.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+		return fmt.Errorf("failed to ping database: %w", connectors.ExplainTLSError(err))
 	}
 	
 	// Run a simple query
@@ -185,19 +310,38 @@ func (c *Connector) GetColumns(db *sql.DB, tableName string) ([]connectors.Colum
 	return columns, rows.Err()
 }
 
-// buildConnectionString builds a SQL Server connection string
+// buildConnectionString builds a SQL Server connection string. The URL form
+// is used because the driver's ADO form cannot quote values, so a password
+// holding ";" would end the value.
 func (c *Connector) buildConnectionString(config *Config) string {
 	encrypt := "false"
 	if config.Encrypt {
 		encrypt = "true"
 	}
 	
-	trustServerCertificate := "true"
-	if !config.TrustServerCertificate {
-		trustServerCertificate = "false"
+	trustServerCertificate := "false"
+	if config.TrustServerCertificate {
+		trustServerCertificate = "true"
 	}
 	
-	return fmt.Sprintf("server=%s;port=%d;database=%s;user id=%s;password=%s;encrypt=%s;TrustServerCertificate=%s",
-		config.Server, config.Port, config.Database, config.Username, config.Password, 
-		encrypt, trustServerCertificate)
+	query := url.Values{}
+	query.Set("database", config.Database)
+	query.Set("encrypt", encrypt)
+	query.Set("TrustServerCertificate", trustServerCertificate)
+	if config.HostNameInCertificate != "" {
+		query.Set("hostNameInCertificate", config.HostNameInCertificate)
+	}
+	
+	// A named instance is given as server\instance and goes in the path
+	host, instance, _ := strings.Cut(config.Server, `\`)
+	u := &url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(config.Username, config.Password),
+		Host:     net.JoinHostPort(host, strconv.Itoa(config.Port)),
+		RawQuery: query.Encode(),
+	}
+	if instance != "" {
+		u.Path = "/" + instance
+	}
+	return u.String()
 }
\ No newline at end of file
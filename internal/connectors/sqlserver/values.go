@@ -0,0 +1,23 @@
+package sqlserver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/compareflow/compareflow/internal/values"
+	mssql "github.com/denisenkom/go-mssqldb"
+)
+
+// MapValue converts a scanned value into its canonical form. The driver
+// returns UNIQUEIDENTIFIER as 16 bytes in SQL Server byte order, which
+// differs from the text order of the UUID.
+func (c *Connector) MapValue(v interface{}, databaseType string) (values.Value, error) {
+	if b, ok := v.([]byte); ok && strings.EqualFold(databaseType, "UNIQUEIDENTIFIER") {
+		var id mssql.UniqueIdentifier
+		if err := id.Scan(b); err != nil {
+			return values.Value{}, fmt.Errorf("invalid uniqueidentifier: %w", err)
+		}
+		return values.UUID(id.String()), nil
+	}
+	return values.FromDriver(v, databaseType)
+}
@@ -0,0 +1,234 @@
+// Package sshtunnel lets SQL connectors reach database servers through an SSH bastion.
+package sshtunnel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+)
+
+const dialTimeout = 15 * time.Second
+
+// Config represents the SSH tunnel block of a connection configuration
+type Config struct {
+	Host                  string `json:"host"`
+	Port                  int    `json:"port"`
+	User                  string `json:"user"`
+	PrivateKey            string `json:"private_key"`
+	Passphrase            string `json:"passphrase"`
+	Password              string `json:"password"`
+	KnownHosts            string `json:"known_hosts"`
+	InsecureIgnoreHostKey bool   `json:"insecure_ignore_host_key"`
+}
+
+// Validate checks if the configuration is valid
+func (c *Config) Validate() error {
+	if c.Host == "" {
+		return fmt.Errorf("ssh_tunnel: host is required")
+	}
+	if c.User == "" {
+		return fmt.Errorf("ssh_tunnel: user is required")
+	}
+	if c.PrivateKey == "" && c.Password == "" {
+		return fmt.Errorf("ssh_tunnel: private_key or password is required")
+	}
+	if c.KnownHosts == "" && !c.InsecureIgnoreHostKey {
+		return fmt.Errorf("ssh_tunnel: known_hosts is required unless insecure_ignore_host_key is set")
+	}
+	if c.Port == 0 {
+		c.Port = 22 // Set default
+	}
+	return nil
+}
+
+// Address returns the host:port of the bastion
+func (c *Config) Address() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// SchemaField describes the ssh_tunnel block for connector config schemas
+func SchemaField() connectors.Field {
+	return connectors.Field{
+		Name:  "ssh_tunnel",
+		Label: "Connect through SSH tunnel",
+		Type:  connectors.FieldTypeObject,
+		Help:  "Reach the database through an SSH bastion host",
+		Fields: []connectors.Field{
+			{Name: "host", Label: "SSH Host", Type: connectors.FieldTypeString, Required: true, Placeholder: "bastion.example.com"},
+			{Name: "port", Label: "SSH Port", Type: connectors.FieldTypeInteger, Default: 22},
+			{Name: "user", Label: "SSH User", Type: connectors.FieldTypeString, Required: true},
+			{Name: "private_key", Label: "Private Key", Type: connectors.FieldTypeString, Secret: true, Multiline: true,
+				Help: "PEM encoded private key; leave empty to use password authentication"},
+			{Name: "passphrase", Label: "Key Passphrase", Type: connectors.FieldTypeString, Secret: true},
+			{Name: "password", Label: "SSH Password", Type: connectors.FieldTypeString, Secret: true},
+			{Name: "known_hosts", Label: "Known Hosts", Type: connectors.FieldTypeString, Multiline: true,
+				Help: "known_hosts entries used to verify the bastion host key, e.g. the output of ssh-keyscan"},
+			{Name: "insecure_ignore_host_key", Label: "Skip Host Key Verification", Type: connectors.FieldTypeBoolean, Default: false,
+				Help: "Accept any host key; only use this for local testing"},
+		},
+	}
+}
+
+// Tunnel forwards connections to the database server through an SSH client.
+// The SSH session is re-established on demand if the bastion drops it.
+// Pass the tunnel to connectors.OpenDB to close it together with the pool.
+type Tunnel struct {
+	config  *ssh.ClientConfig
+	address string
+	mu      sync.Mutex
+	client  *ssh.Client
+	closed  bool
+}
+
+// Open connects to the bastion described by config
+func Open(config *Config) (*Tunnel, error) {
+	clientConfig, err := clientConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tunnel{config: clientConfig, address: config.Address()}
+	if _, err := t.connect(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func clientConfig(config *Config) (*ssh.ClientConfig, error) {
+	var auth []ssh.AuthMethod
+	if config.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if config.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(config.PrivateKey), []byte(config.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(config.PrivateKey))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ssh_tunnel: failed to parse private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if config.Password != "" {
+		auth = append(auth, ssh.Password(config.Password))
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if !config.InsecureIgnoreHostKey {
+		var err error
+		hostKeyCallback, err = knownHostsCallback(config.KnownHosts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &ssh.ClientConfig{
+		User:            config.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         dialTimeout,
+	}, nil
+}
+
+// knownHostsCallback builds a host key callback from known_hosts file contents
+func knownHostsCallback(contents string) (ssh.HostKeyCallback, error) {
+	// knownhosts only reads from files; the entries are kept in memory once parsed
+	file, err := os.CreateTemp("", "compareflow-known-hosts-*")
+	if err != nil {
+		return nil, fmt.Errorf("ssh_tunnel: failed to stage known_hosts: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.WriteString(contents + "\n"); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("ssh_tunnel: failed to stage known_hosts: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("ssh_tunnel: failed to stage known_hosts: %w", err)
+	}
+
+	callback, err := knownhosts.New(file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("ssh_tunnel: invalid known_hosts: %w", err)
+	}
+	return callback, nil
+}
+
+// connect returns the live SSH client, dialing the bastion if necessary
+func (t *Tunnel) connect() (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, fmt.Errorf("ssh_tunnel: tunnel is closed")
+	}
+	if t.client != nil {
+		return t.client, nil
+	}
+
+	client, err := ssh.Dial("tcp", t.address, t.config)
+	if err != nil {
+		return nil, fmt.Errorf("ssh_tunnel: failed to connect to %s: %w", t.address, err)
+	}
+	t.client = client
+
+	// Forget the client once the bastion drops it so the next dial reconnects
+	go func() {
+		client.Wait()
+		t.mu.Lock()
+		if t.client == client {
+			t.client = nil
+		}
+		t.mu.Unlock()
+	}()
+
+	return client, nil
+}
+
+// DialContext opens a connection to addr on the far side of the tunnel
+func (t *Tunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := t.connect()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh_tunnel: failed to reach %s through %s: %w", addr, t.address, err)
+	}
+	return conn, nil
+}
+
+// Dial opens a connection to addr on the far side of the tunnel
+func (t *Tunnel) Dial(network, addr string) (net.Conn, error) {
+	return t.DialContext(context.Background(), network, addr)
+}
+
+// DialTimeout opens a connection to addr on the far side of the tunnel
+func (t *Tunnel) DialTimeout(network, addr string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return t.DialContext(ctx, network, addr)
+}
+
+// Close shuts down the SSH session
+func (t *Tunnel) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	if t.client == nil {
+		return nil
+	}
+	err := t.client.Close()
+	t.client = nil
+	return err
+}
@@ -0,0 +1,224 @@
+package sshtunnel
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// startEchoServer starts a TCP server standing in for the database
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// startSSHServer starts an in-process bastion that accepts password auth
+// and forwards direct-tcpip channels, like sshd with AllowTcpForwarding.
+func startSSHServer(t *testing.T, password string) (string, ssh.PublicKey) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, given []byte) (*ssh.Permissions, error) {
+			if string(given) == password {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(conn, config)
+		}
+	}()
+	return listener.Addr().String(), signer.PublicKey()
+}
+
+func serveSSH(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		// RFC 4254 7.2: host to connect, port to connect, originator address, originator port
+		extra := newChannel.ExtraData()
+		hostLen := binary.BigEndian.Uint32(extra[:4])
+		host := string(extra[4 : 4+hostLen])
+		port := binary.BigEndian.Uint32(extra[4+hostLen : 8+hostLen])
+
+		target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, reqs, err := newChannel.Accept()
+		if err != nil {
+			target.Close()
+			continue
+		}
+		go ssh.DiscardRequests(reqs)
+		go func() {
+			defer channel.Close()
+			defer target.Close()
+			go io.Copy(target, channel)
+			io.Copy(channel, target)
+		}()
+	}
+}
+
+func tunnelConfig(t *testing.T, addr string, password string, hostKey ssh.PublicKey) *Config {
+	t.Helper()
+	host, portStr, _ := net.SplitHostPort(addr)
+	port, _ := strconv.Atoi(portStr)
+	return &Config{
+		Host:       host,
+		Port:       port,
+		User:       "compareflow",
+		Password:   password,
+		KnownHosts: knownhosts.Line([]string{addr}, hostKey),
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{
+			name:    "valid with key",
+			config:  Config{Host: "bastion", User: "ops", PrivateKey: "key", KnownHosts: "bastion ssh-ed25519 AAAA"},
+			wantErr: false,
+		},
+		{
+			name:    "missing host",
+			config:  Config{User: "ops", Password: "secret", InsecureIgnoreHostKey: true},
+			wantErr: true,
+		},
+		{
+			name:    "missing credentials",
+			config:  Config{Host: "bastion", User: "ops", InsecureIgnoreHostKey: true},
+			wantErr: true,
+		},
+		{
+			name:    "missing host key verification",
+			config:  Config{Host: "bastion", User: "ops", Password: "secret"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Config.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tt.config.Port != 22 {
+				t.Errorf("Config.Validate() should set default port, got %d", tt.config.Port)
+			}
+		})
+	}
+}
+
+func TestTunnel_Dial(t *testing.T) {
+	echoAddr := startEchoServer(t)
+	sshAddr, hostKey := startSSHServer(t, "secret")
+
+	tunnel, err := Open(tunnelConfig(t, sshAddr, "secret", hostKey))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer tunnel.Close()
+
+	conn, err := tunnel.Dial("tcp", echoAddr)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "ping" {
+		t.Errorf("echo through tunnel = %q, want %q", buf, "ping")
+	}
+
+	tunnel.Close()
+	if _, err := tunnel.Dial("tcp", echoAddr); err == nil {
+		t.Error("Dial() after Close() should fail")
+	}
+}
+
+func TestTunnel_RejectsUnknownHostKey(t *testing.T) {
+	sshAddr, _ := startSSHServer(t, "secret")
+
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherSigner, _ := ssh.NewSignerFromKey(otherKey)
+
+	if _, err := Open(tunnelConfig(t, sshAddr, "secret", otherSigner.PublicKey())); err == nil {
+		t.Error("Open() should fail when the host key does not match known_hosts")
+	}
+}
+
+func TestTunnel_RejectsBadPassword(t *testing.T) {
+	sshAddr, hostKey := startSSHServer(t, "secret")
+
+	if _, err := Open(tunnelConfig(t, sshAddr, "wrong", hostKey)); err == nil {
+		t.Error("Open() should fail with the wrong password")
+	}
+}
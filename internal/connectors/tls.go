@@ -0,0 +1,152 @@
+package connectors
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParseCertPool builds a certificate pool from PEM encoded CA certificates
+func ParseCertPool(caPEM string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(caPEM)) {
+		return nil, fmt.Errorf("CA certificate is not valid PEM")
+	}
+	return pool, nil
+}
+
+// ParseClientCertificate loads a PEM encoded client certificate and private key
+func ParseClientCertificate(certPEM, keyPEM string) (tls.Certificate, error) {
+	if certPEM == "" || keyPEM == "" {
+		return tls.Certificate{}, fmt.Errorf("client certificate and key must be provided together")
+	}
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("invalid client certificate or key: %w", err)
+	}
+	return cert, nil
+}
+
+// SecureFiles stages PEM material in a private temporary directory for
+// drivers that only accept file paths. Files are readable by the owner only.
+type SecureFiles struct {
+	dir string
+}
+
+// NewSecureFiles creates an empty private directory
+func NewSecureFiles() (*SecureFiles, error) {
+	dir, err := os.MkdirTemp("", "compareflow-tls-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TLS directory: %w", err)
+	}
+	return &SecureFiles{dir: dir}, nil
+}
+
+// Write stores contents under name and returns the file path
+func (f *SecureFiles) Write(name, contents string) (string, error) {
+	path := filepath.Join(f.dir, name)
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return path, nil
+}
+
+// Close removes the directory and everything in it
+func (f *SecureFiles) Close() error {
+	return os.RemoveAll(f.dir)
+}
+
+// OpenDB returns a connection pool for connector. The closers are closed
+// together with the pool, which ties tunnels and staged files to its lifetime.
+func OpenDB(connector driver.Connector, closers ...io.Closer) *sql.DB {
+	if len(closers) == 0 {
+		return sql.OpenDB(connector)
+	}
+	return sql.OpenDB(&closingConnector{Connector: connector, closers: closers})
+}
+
+// closingConnector relies on database/sql calling Close on connectors implementing io.Closer
+type closingConnector struct {
+	driver.Connector
+	closers []io.Closer
+}
+
+func (c *closingConnector) Close() error {
+	var errs []error
+	for _, closer := range c.closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// TLSError reports a failed TLS handshake or certificate validation
+type TLSError struct {
+	Reason string
+	Hint   string
+	Err    error
+}
+
+func (e *TLSError) Error() string {
+	msg := "TLS certificate validation failed: " + e.Reason
+	if e.Hint != "" {
+		msg += " (" + e.Hint + ")"
+	}
+	return msg
+}
+
+func (e *TLSError) Unwrap() error {
+	return e.Err
+}
+
+// ExplainTLSError converts certificate validation failures into a TLSError with
+// a hint about which setting to check. Other errors are returned unchanged.
+// Some drivers flatten the x509 error into a string, so the message is matched as well.
+func ExplainTLSError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var verification *tls.CertificateVerificationError
+	msg := err.Error()
+
+	switch {
+	case errors.As(err, &unknownAuthority) || strings.Contains(msg, "certificate signed by unknown authority"):
+		return &TLSError{
+			Reason: "server certificate is signed by an unknown authority",
+			Hint:   "provide the issuing CA certificate",
+			Err:    err,
+		}
+	case errors.As(err, &hostname) || strings.Contains(msg, "certificate is valid for") || strings.Contains(msg, "doesn't contain any IP SANs"):
+		return &TLSError{
+			Reason: "server certificate does not match the host name",
+			Hint:   "set the server name override to a name listed in the certificate",
+			Err:    err,
+		}
+	case errors.As(err, &invalid) || strings.Contains(msg, "certificate has expired or is not yet valid"):
+		return &TLSError{
+			Reason: "server certificate is expired or not valid for this use",
+			Err:    err,
+		}
+	case errors.As(err, &verification):
+		return &TLSError{Reason: verification.Err.Error(), Err: err}
+	case strings.Contains(msg, "tls: bad certificate") || strings.Contains(msg, "tls: certificate required"):
+		return &TLSError{
+			Reason: "server rejected the client certificate",
+			Hint:   "check the client certificate and key",
+			Err:    err,
+		}
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	from := time.Date(2024, 1, 31, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"* * * * *", from, time.Date(2024, 1, 31, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", from, time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{"0 2 * * *", from, time.Date(2024, 2, 1, 2, 0, 0, 0, time.UTC)},
+		{"@hourly", from, time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"30 6 * * mon-fri", from, time.Date(2024, 2, 1, 6, 30, 0, 0, time.UTC)},
+		{"0 0 29 feb *", from, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * 0", from, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", from, time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", from, time.Time{}},
+		// 02:30 does not exist on the day clocks go forward, so that day is skipped
+		{"30 2 * * *", time.Date(2024, 3, 30, 12, 0, 0, 0, berlin), time.Date(2024, 4, 1, 2, 30, 0, 0, berlin)},
+		{"0 8 * * *", time.Date(2024, 3, 30, 12, 0, 0, 0, berlin), time.Date(2024, 3, 31, 8, 0, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "5-1 * * * *", "0 0 * * fun"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) error = nil", expr)
+		}
+	}
+}
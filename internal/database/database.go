@@ -35,7 +35,7 @@ func Initialize(databaseURL string, migrationsFS embed.FS) (*gorm.DB, error) {
 	}
 
 	// Run auto-migrations
-	if err := db.AutoMigrate(&models.User{}, &models.Connection{}, &models.Validation{}); err != nil {
+	if err := db.AutoMigrate(&models.User{}, &models.Connection{}, &models.Validation{}, &models.ValidationRun{}, &models.Schedule{}, &models.Pipeline{}, &models.PipelineRun{}, &models.Suite{}, &models.SuiteRun{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.EmailMessage{}, &models.Incident{}); err != nil {
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
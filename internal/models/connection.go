@@ -4,7 +4,15 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	_ "github.com/compareflow/compareflow/internal/connectors/all"
+	"github.com/compareflow/compareflow/internal/secrets"
 )
 
 type ConnectionType string
@@ -36,13 +44,72 @@ func (c *ConnectionConfig) Scan(value interface{}) error {
 }
 
 type Connection struct {
-	ID        uint             `json:"id"`
-	Name      string           `gorm:"not null" json:"name"`
-	Type      ConnectionType   `gorm:"not null" json:"type"`
-	Config    ConnectionConfig `gorm:"type:json" json:"config"`
-	UserID    uint             `json:"user_id"`
-	User      *User            `gorm:"foreignKey:UserID" json:"-"`
-	CreatedAt time.Time        `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+	ID     uint             `json:"id"`
+	Name   string           `gorm:"not null" json:"name"`
+	Type   ConnectionType   `gorm:"not null" json:"type"`
+	Config ConnectionConfig `gorm:"type:json" json:"config"`
+	// ExecutionWindows limit when runs may start on the connection
+	ExecutionWindows ExecutionWindows `gorm:"type:json" json:"execution_windows"`
+	// MaxConcurrentQueries caps the queries runs hold open at once; 0 is unlimited
+	MaxConcurrentQueries int       `json:"max_concurrent_queries"`
+	UserID               uint      `json:"user_id"`
+	User                 *User     `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt            time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// secretCipher encrypts the secret fields of connection configs at rest.
+// When nil, secrets are stored in plaintext.
+var secretCipher *secrets.Cipher
+
+// SetSecretCipher configures the cipher used for connection secrets
+func SetSecretCipher(c *secrets.Cipher) {
+	secretCipher = c
+}
+
+func (c *Connection) BeforeSave(tx *gorm.DB) error {
+	return c.transformSecrets(secretCipher.Encrypt)
+}
+
+func (c *Connection) AfterSave(tx *gorm.DB) error {
+	return c.transformSecrets(secretCipher.Decrypt)
+}
+
+func (c *Connection) AfterFind(tx *gorm.DB) error {
+	return c.transformSecrets(secretCipher.Decrypt)
 }
 
+// transformSecrets applies fn to every field the connector's schema marks as secret
+func (c *Connection) transformSecrets(fn func(string) (string, error)) error {
+	connector, err := connectors.Get(string(c.Type))
+	if err != nil {
+		return nil // Unknown types have no schema to consult
+	}
+
+	for _, path := range connector.ConfigSchema().SecretPaths() {
+		parent := map[string]interface{}(c.Config)
+		for _, key := range path[:len(path)-1] {
+			nested, ok := parent[key].(map[string]interface{})
+			if !ok {
+				parent = nil
+				break
+			}
+			parent = nested
+		}
+		if parent == nil {
+			continue
+		}
+
+		key := path[len(path)-1]
+		value, ok := parent[key].(string)
+		if !ok || value == "" {
+			continue
+		}
+		transformed, err := fn(value)
+		if err != nil {
+			return fmt.Errorf("connection %d: %s: %w", c.ID, strings.Join(path, "."), err)
+		}
+		parent[key] = transformed
+	}
+	return nil
+}
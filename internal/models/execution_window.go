@@ -0,0 +1,193 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ExecutionWindow is a daily period in which runs may start on a connection.
+// An end before the start wraps past midnight, e.g. 18:00-08:00.
+type ExecutionWindow struct {
+	Days     []string `json:"days,omitempty"` // days the window opens on, e.g. mon; default every day
+	Start    string   `json:"start"`          // HH:MM
+	End      string   `json:"end"`            // HH:MM
+	Timezone string   `json:"timezone,omitempty"`
+}
+
+// ExecutionWindows holds the windows of a connection; none means always open
+type ExecutionWindows []ExecutionWindow
+
+func (w ExecutionWindows) Value() (driver.Value, error) {
+	return json.Marshal(w)
+}
+
+func (w *ExecutionWindows) Scan(value interface{}) error {
+	if value == nil {
+		*w = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan non-byte value into ExecutionWindows")
+	}
+
+	return json.Unmarshal(bytes, w)
+}
+
+// Validate checks the windows' days, times and time zones
+func (w ExecutionWindows) Validate() error {
+	for i, window := range w {
+		if _, _, _, err := window.parse(); err != nil {
+			return fmt.Errorf("execution_windows[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Open reports whether t falls in any window. With no windows it is always true.
+func (w ExecutionWindows) Open(t time.Time) bool {
+	if len(w) == 0 {
+		return true
+	}
+	for _, window := range w {
+		if window.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns when the next window after t opens, or the zero time if
+// none parse
+func (w ExecutionWindows) NextOpen(t time.Time) time.Time {
+	var next time.Time
+	for _, window := range w {
+		days, start, _, err := window.parse()
+		if err != nil {
+			continue
+		}
+		loc, _ := time.LoadLocation(window.Timezone)
+		local := t.In(loc)
+		for d := 0; d <= 7; d++ {
+			opens := clockOn(local, d, start)
+			if days[opens.Weekday()] && opens.After(t) {
+				if next.IsZero() || opens.Before(next) {
+					next = opens
+				}
+				break
+			}
+		}
+	}
+	return next
+}
+
+// Closes returns when the windows open at t have all closed, or the zero
+// time if t falls outside them or they never close
+func (w ExecutionWindows) Closes(t time.Time) time.Time {
+	var closes time.Time
+	for at := t; ; at = closes {
+		// A window may open as another closes, so follow them to the last
+		next := at
+		for _, window := range w {
+			if ends, ok := window.occurrence(at); ok && ends.After(next) {
+				next = ends
+			}
+		}
+		if !next.After(at) {
+			return closes
+		}
+		closes = next
+		if closes.Sub(t) > 8*24*time.Hour {
+			return time.Time{}
+		}
+	}
+}
+
+// contains reports whether t falls in an occurrence of the window
+func (window ExecutionWindow) contains(t time.Time) bool {
+	_, ok := window.occurrence(t)
+	return ok
+}
+
+// occurrence returns when the occurrence of the window that t falls in
+// closes, and false if t falls in none
+func (window ExecutionWindow) occurrence(t time.Time) (time.Time, bool) {
+	days, start, length, err := window.parse()
+	if err != nil {
+		return time.Time{}, false
+	}
+	loc, _ := time.LoadLocation(window.Timezone)
+	local := t.In(loc)
+	// An occurrence that wraps past midnight may have opened yesterday
+	for d := 0; d >= -1; d-- {
+		opens := clockOn(local, d, start)
+		if days[opens.Weekday()] && !t.Before(opens) && t.Before(opens.Add(length)) {
+			return opens.Add(length), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parse returns the days the window opens on, its start as an offset from
+// midnight and its length
+func (window ExecutionWindow) parse() (map[time.Weekday]bool, time.Duration, time.Duration, error) {
+	days := make(map[time.Weekday]bool)
+	for _, name := range window.Days {
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return nil, 0, 0, fmt.Errorf("invalid day %q", name)
+		}
+		days[day] = true
+	}
+	if len(window.Days) == 0 {
+		for _, day := range weekdays {
+			days[day] = true
+		}
+	}
+
+	start, err := parseClock(window.Start)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	end, err := parseClock(window.End)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if start == end {
+		return nil, 0, 0, errors.New("start and end must differ")
+	}
+	length := end - start
+	if length < 0 {
+		length += 24 * time.Hour
+	}
+
+	if _, err := time.LoadLocation(window.Timezone); err != nil {
+		return nil, 0, 0, fmt.Errorf("invalid timezone %q", window.Timezone)
+	}
+	return days, start, length, nil
+}
+
+// clockOn returns the time of day clock on the day offset days from t, in
+// t's location
+func clockOn(t time.Time, offset int, clock time.Duration) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()+offset, int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, t.Location())
+}
+
+// parseClock parses HH:MM as an offset from midnight
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExecutionWindows_Open(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	// Outside business hours on weekdays, all day at weekends
+	windows := ExecutionWindows{
+		{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "18:00", End: "08:00", Timezone: "Europe/Berlin"},
+		{Days: []string{"sat", "sun"}, Start: "00:00", End: "23:59", Timezone: "Europe/Berlin"},
+	}
+	if err := windows.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		want     bool
+		wantNext time.Time
+	}{
+		{"weekday business hours", time.Date(2024, 1, 17, 9, 0, 0, 0, berlin), false, time.Date(2024, 1, 17, 18, 0, 0, 0, berlin)},
+		{"weekday evening", time.Date(2024, 1, 17, 20, 0, 0, 0, berlin), true, time.Time{}},
+		{"after midnight of a wrapping window", time.Date(2024, 1, 18, 7, 59, 0, 0, berlin), true, time.Time{}},
+		{"wrapping window closed", time.Date(2024, 1, 18, 8, 0, 0, 0, berlin), false, time.Date(2024, 1, 18, 18, 0, 0, 0, berlin)},
+		{"Monday morning after a Friday window", time.Date(2024, 1, 22, 7, 0, 0, 0, berlin), false, time.Date(2024, 1, 22, 18, 0, 0, 0, berlin)},
+		{"UTC instant in a Berlin window", time.Date(2024, 1, 17, 17, 30, 0, 0, time.UTC), true, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := windows.Open(tt.at); got != tt.want {
+				t.Errorf("Open() = %v, want %v", got, tt.want)
+			}
+			if !tt.want {
+				if got := windows.NextOpen(tt.at); !got.Equal(tt.wantNext) {
+					t.Errorf("NextOpen() = %v, want %v", got, tt.wantNext)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutionWindows_Closes(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data unavailable")
+	}
+	windows := ExecutionWindows{
+		{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "18:00", End: "08:00", Timezone: "Europe/Berlin"},
+		{Days: []string{"sat", "sun"}, Start: "00:00", End: "23:59", Timezone: "Europe/Berlin"},
+	}
+	always := ExecutionWindows{{Start: "00:00", End: "12:00"}, {Start: "12:00", End: "00:00"}}
+
+	tests := []struct {
+		name    string
+		windows ExecutionWindows
+		at      time.Time
+		want    time.Time
+	}{
+		{"weekday evening", windows, time.Date(2024, 1, 17, 20, 0, 0, 0, berlin), time.Date(2024, 1, 18, 8, 0, 0, 0, berlin)},
+		{"Friday window runs into Saturday's", windows, time.Date(2024, 1, 19, 20, 0, 0, 0, berlin), time.Date(2024, 1, 20, 23, 59, 0, 0, berlin)},
+		{"outside the windows", windows, time.Date(2024, 1, 17, 9, 0, 0, 0, berlin), time.Time{}},
+		{"no windows", nil, time.Date(2024, 1, 17, 9, 0, 0, 0, berlin), time.Time{}},
+		{"windows that never close", always, time.Date(2024, 1, 17, 9, 0, 0, 0, time.UTC), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.windows.Closes(tt.at); !got.Equal(tt.want) {
+				t.Errorf("Closes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutionWindows_Validate(t *testing.T) {
+	for _, w := range []ExecutionWindow{
+		{Start: "08:00", End: "24:00"},
+		{Start: "08:00", End: "08:00"},
+		{Days: []string{"monday"}, Start: "08:00", End: "18:00"},
+		{Start: "08:00", End: "18:00", Timezone: "Mars/Olympus"},
+	} {
+		if err := (ExecutionWindows{w}).Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want error", w)
+		}
+	}
+}
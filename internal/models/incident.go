@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// IncidentStatus is the state of a validation's incident
+type IncidentStatus string
+
+const (
+	IncidentOpen         IncidentStatus = "open"
+	IncidentAcknowledged IncidentStatus = "acknowledged" // failure alerts are silenced until recovery
+	IncidentResolved     IncidentStatus = "resolved"
+)
+
+// IncidentTransition is what a finished run did to its validation's incident
+// that is worth a notification
+type IncidentTransition string
+
+const (
+	IncidentOpened    IncidentTransition = "opened"    // the first failure
+	IncidentReminder  IncidentTransition = "reminder"  // a continuing failure due for another alert
+	IncidentRecovered IncidentTransition = "recovered" // the first pass after failures
+)
+
+// Incident tracks a validation from its first failed run to the next passing
+// one. A validation has at most one incident that is not resolved, enforced
+// by a partial unique index.
+type Incident struct {
+	ID           uint           `json:"id"`
+	ValidationID uint           `gorm:"index;uniqueIndex:idx_incidents_unresolved,where:status <> 'resolved';not null" json:"validation_id"`
+	Status       IncidentStatus `gorm:"index" json:"status"`
+	FirstRunID   uint           `json:"first_run_id"`
+	LastRunID    uint           `json:"last_run_id"`
+	FailedRuns   int            `json:"failed_runs"`
+	// NotifiedAt and NotifiedRuns record the last alert, as the time and the
+	// failed runs counted then
+	NotifiedAt     time.Time  `json:"notified_at"`
+	NotifiedRuns   int        `json:"notified_runs"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	AcknowledgedBy *uint      `json:"acknowledged_by,omitempty"` // user ID
+	Note           string     `json:"note,omitempty"`            // left when acknowledging
+	OpenedAt       time.Time  `json:"opened_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	ResolvedRunID  *uint      `json:"resolved_run_id,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// ReminderDue reports whether another alert is due for an open incident at
+// now under the repeat settings of n
+func (n NotificationSettings) ReminderDue(incident *Incident, now time.Time) bool {
+	if incident.Status != IncidentOpen {
+		return false
+	}
+	if n.RepeatAfterRuns > 0 && incident.FailedRuns-incident.NotifiedRuns >= n.RepeatAfterRuns {
+		return true
+	}
+	if n.RepeatAfterMinutes > 0 && !now.Before(incident.NotifiedAt.Add(time.Duration(n.RepeatAfterMinutes)*time.Minute)) {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+)
+
+// Notify modes choose which runs are announced by email and chat
+const (
+	NotifyFailureOnly  = "failure_only"  // runs that did not pass
+	NotifyStatusChange = "status_change" // runs whose status differs from the run before, and reminders
+	NotifyAlways       = "always"        // every run
+)
+
+// NotificationSettings says who hears about the runs of a validation or suite
+type NotificationSettings struct {
+	// Emails receive an alert for each run the mode announces
+	Emails []string `json:"emails,omitempty"`
+	// Digest also sends Emails the daily digest
+	Digest bool `json:"digest,omitempty"`
+	// Mode is one of the Notify modes; failure_only when empty
+	Mode string `json:"mode,omitempty"`
+	// Under status_change, a validation that keeps failing is announced again
+	// after RepeatAfterRuns more failed runs or RepeatAfterMinutes since the
+	// last alert, whichever comes first; zero never repeats
+	RepeatAfterRuns    int `json:"repeat_after_runs,omitempty"`
+	RepeatAfterMinutes int `json:"repeat_after_minutes,omitempty"`
+}
+
+func (n NotificationSettings) Value() (driver.Value, error) {
+	return json.Marshal(n)
+}
+
+func (n *NotificationSettings) Scan(value interface{}) error {
+	if value == nil {
+		*n = NotificationSettings{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan non-byte value into NotificationSettings")
+	}
+
+	return json.Unmarshal(bytes, n)
+}
+
+// Validate checks that every email is a plain address
+func (n NotificationSettings) Validate() error {
+	for i, email := range n.Emails {
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email {
+			return fmt.Errorf("notifications.emails[%d]: invalid address %q", i, email)
+		}
+	}
+	if n.Digest && len(n.Emails) == 0 {
+		return errors.New("notifications: digest requires emails")
+	}
+	switch n.Mode {
+	case "", NotifyFailureOnly, NotifyStatusChange, NotifyAlways:
+	default:
+		return fmt.Errorf("notifications: unknown mode %q", n.Mode)
+	}
+	if n.RepeatAfterRuns < 0 || n.RepeatAfterMinutes < 0 {
+		return errors.New("notifications: repeat_after_runs and repeat_after_minutes must not be negative")
+	}
+	return nil
+}
+
+// Announces reports whether a finished run with status is announced, given
+// the status of the run before it, empty for the first run. The first run
+// counts as a change only when it fails.
+func (n NotificationSettings) Announces(status, previous ValidationStatus) bool {
+	switch n.Mode {
+	case NotifyAlways:
+		return true
+	case NotifyStatusChange:
+		if previous == "" {
+			return status == ValidationStatusFailed
+		}
+		return status != previous
+	default:
+		return status == ValidationStatusFailed
+	}
+}
+
+// EmailMessage is an email queued for sending, retried until it is sent or
+// out of attempts
+type EmailMessage struct {
+	ID uint `json:"id"`
+	// Key identifies a message that must only be queued once, such as one
+	// day's digest to one address; empty for alerts
+	Key           *string        `gorm:"uniqueIndex" json:"key,omitempty"`
+	To            string         `gorm:"not null" json:"to"` // comma separated addresses
+	Subject       string         `json:"subject"`
+	Text          string         `gorm:"type:text" json:"-"`
+	HTML          string         `gorm:"type:text" json:"-"`
+	Status        DeliveryStatus `gorm:"index" json:"status"`
+	Attempts      int            `json:"attempts"`
+	Error         string         `json:"error,omitempty"` // error of the last attempt
+	NextAttemptAt *time.Time     `gorm:"index" json:"next_attempt_at,omitempty"`
+	SentAt        *time.Time     `json:"sent_at,omitempty"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotificationSettings_Announces(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		status   ValidationStatus
+		previous ValidationStatus
+		want     bool
+	}{
+		{"default announces failures", "", ValidationStatusFailed, ValidationStatusFailed, true},
+		{"default skips passes", "", ValidationStatusCompleted, ValidationStatusFailed, false},
+		{"failure_only skips passes", NotifyFailureOnly, ValidationStatusCompleted, "", false},
+		{"status_change announces recovery", NotifyStatusChange, ValidationStatusCompleted, ValidationStatusFailed, true},
+		{"status_change skips repeated failure", NotifyStatusChange, ValidationStatusFailed, ValidationStatusFailed, false},
+		{"status_change announces a failing first run", NotifyStatusChange, ValidationStatusFailed, "", true},
+		{"status_change skips a passing first run", NotifyStatusChange, ValidationStatusCompleted, "", false},
+		{"always announces passes", NotifyAlways, ValidationStatusCompleted, ValidationStatusCompleted, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NotificationSettings{Mode: tt.mode}
+			if got := n.Announces(tt.status, tt.previous); got != tt.want {
+				t.Errorf("Announces(%q, %q) = %v, want %v", tt.status, tt.previous, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotificationSettings_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       NotificationSettings
+		wantErr bool
+	}{
+		{"empty", NotificationSettings{}, false},
+		{"emails with digest", NotificationSettings{Emails: []string{"ops@example.com"}, Digest: true, Mode: NotifyAlways}, false},
+		{"display name", NotificationSettings{Emails: []string{"Ops <ops@example.com>"}}, true},
+		{"digest without emails", NotificationSettings{Digest: true}, true},
+		{"unknown mode", NotificationSettings{Mode: "sometimes"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.n.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNotificationSettings_ReminderDue(t *testing.T) {
+	notified := time.Date(2024, 1, 17, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		n        NotificationSettings
+		incident Incident
+		now      time.Time
+		want     bool
+	}{
+		{"never repeats by default", NotificationSettings{}, Incident{Status: IncidentOpen, FailedRuns: 50, NotifiedRuns: 1, NotifiedAt: notified}, notified.Add(24 * time.Hour), false},
+		{"runs not reached", NotificationSettings{RepeatAfterRuns: 3}, Incident{Status: IncidentOpen, FailedRuns: 3, NotifiedRuns: 1}, notified, false},
+		{"runs reached", NotificationSettings{RepeatAfterRuns: 3}, Incident{Status: IncidentOpen, FailedRuns: 4, NotifiedRuns: 1}, notified, true},
+		{"minutes not reached", NotificationSettings{RepeatAfterMinutes: 60}, Incident{Status: IncidentOpen, NotifiedAt: notified}, notified.Add(59 * time.Minute), false},
+		{"minutes reached", NotificationSettings{RepeatAfterMinutes: 60}, Incident{Status: IncidentOpen, NotifiedAt: notified}, notified.Add(time.Hour), true},
+		{"acknowledged", NotificationSettings{RepeatAfterRuns: 1}, Incident{Status: IncidentAcknowledged, FailedRuns: 9, NotifiedRuns: 1}, notified, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.n.ReminderDue(&tt.incident, tt.now); got != tt.want {
+				t.Errorf("ReminderDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
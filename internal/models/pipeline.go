@@ -0,0 +1,119 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Run conditions of a pipeline edge, checked against the upstream node's
+// outcome
+const (
+	RunOnSuccess = "on_success"
+	RunOnFailure = "on_failure"
+	RunAlways    = "always"
+)
+
+// ValidationStatusSkipped marks a pipeline node whose run conditions did not hold
+const ValidationStatusSkipped ValidationStatus = "skipped"
+
+// PipelineEdge makes a node depend on an upstream node
+type PipelineEdge struct {
+	Node      string `json:"node"`
+	Condition string `json:"condition"` // on_success (default), on_failure or always
+}
+
+// PipelineNode runs a validation once every edge's condition holds
+type PipelineNode struct {
+	Key          string         `json:"key"`
+	ValidationID uint           `json:"validation_id"`
+	DependsOn    []PipelineEdge `json:"depends_on,omitempty"`
+}
+
+// PipelineNodes holds the nodes of a pipeline
+type PipelineNodes []PipelineNode
+
+func (n PipelineNodes) Value() (driver.Value, error) {
+	return json.Marshal(n)
+}
+
+func (n *PipelineNodes) Scan(value interface{}) error {
+	if value == nil {
+		*n = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan non-byte value into PipelineNodes")
+	}
+
+	return json.Unmarshal(bytes, n)
+}
+
+// Pipeline is a graph of validations run in dependency order
+type Pipeline struct {
+	ID          uint             `json:"id"`
+	Name        string           `gorm:"not null" json:"name"`
+	Description string           `json:"description"`
+	Nodes       PipelineNodes    `gorm:"type:json" json:"nodes"`
+	Status      ValidationStatus `gorm:"default:'pending'" json:"status"` // status of the latest run
+	UserID      uint             `json:"user_id"`
+	User        *User            `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt   time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (p *Pipeline) BeforeCreate(tx *gorm.DB) error {
+	if p.Status == "" {
+		p.Status = ValidationStatusPending
+	}
+	return nil
+}
+
+// PipelineNodeRun records the outcome of one node in a pipeline run
+type PipelineNodeRun struct {
+	Key          string           `json:"key"`
+	ValidationID uint             `json:"validation_id"`
+	Status       ValidationStatus `json:"status"`
+	RunID        *uint            `json:"run_id,omitempty"` // the validation run, unless skipped
+	Reason       string           `json:"reason,omitempty"` // why the node was skipped or failed to start
+	StartedAt    *time.Time       `json:"started_at,omitempty"`
+	FinishedAt   *time.Time       `json:"finished_at,omitempty"`
+}
+
+// PipelineNodeRuns holds the node outcomes of a pipeline run
+type PipelineNodeRuns []PipelineNodeRun
+
+func (n PipelineNodeRuns) Value() (driver.Value, error) {
+	return json.Marshal(n)
+}
+
+func (n *PipelineNodeRuns) Scan(value interface{}) error {
+	if value == nil {
+		*n = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan non-byte value into PipelineNodeRuns")
+	}
+
+	return json.Unmarshal(bytes, n)
+}
+
+// PipelineRun records one execution of a pipeline
+type PipelineRun struct {
+	ID          uint             `json:"id"`
+	PipelineID  uint             `gorm:"index;not null" json:"pipeline_id"`
+	Status      ValidationStatus `json:"status"`
+	Nodes       PipelineNodeRuns `gorm:"type:json" json:"nodes"`
+	StartedAt   time.Time        `json:"started_at"`
+	FinishedAt  *time.Time       `json:"finished_at,omitempty"`
+	HeartbeatAt *time.Time       `json:"-"` // refreshed while an instance runs it
+	CreatedAt   time.Time        `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Missed tick policies
+const (
+	// MissedSkip drops ticks that passed while no scheduler was running
+	MissedSkip = "skip"
+	// MissedCatchUp runs every missed tick in order with its own run_date
+	MissedCatchUp = "catch_up"
+)
+
+// Schedule runs a validation on a cron expression
+type Schedule struct {
+	ID           uint         `json:"id"`
+	ValidationID uint         `gorm:"index;not null" json:"validation_id"`
+	Cron         string       `gorm:"not null" json:"cron"`
+	Timezone     string       `gorm:"default:'UTC'" json:"timezone"`
+	Enabled      bool         `json:"enabled"`
+	MissedPolicy string       `gorm:"default:'skip'" json:"missed_policy"`
+	Parameters   RunVariables `gorm:"type:json" json:"parameters,omitempty"`
+	// NextRunAt is the next tick; instances claim a tick by advancing it
+	NextRunAt *time.Time `gorm:"index" json:"next_run_at,omitempty"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (s *Schedule) BeforeCreate(tx *gorm.DB) error {
+	if s.Timezone == "" {
+		s.Timezone = "UTC"
+	}
+	if s.MissedPolicy == "" {
+		s.MissedPolicy = MissedSkip
+	}
+	return nil
+}
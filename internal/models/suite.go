@@ -0,0 +1,123 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SuiteMembers holds the validation IDs of a suite
+type SuiteMembers []uint
+
+func (m SuiteMembers) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+func (m *SuiteMembers) Scan(value interface{}) error {
+	if value == nil {
+		*m = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan non-byte value into SuiteMembers")
+	}
+
+	return json.Unmarshal(bytes, m)
+}
+
+// Suite groups validations that are run and reported on together
+type Suite struct {
+	ID            uint                 `json:"id"`
+	Name          string               `gorm:"not null" json:"name"`
+	Description   string               `json:"description"`
+	ValidationIDs SuiteMembers         `gorm:"type:json" json:"validation_ids"`
+	Status        ValidationStatus     `gorm:"default:'pending'" json:"status"` // status of the latest run
+	Notifications NotificationSettings `gorm:"type:json" json:"notifications"`
+	UserID        uint                 `json:"user_id"`
+	User          *User                `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt     time.Time            `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time            `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (s *Suite) BeforeCreate(tx *gorm.DB) error {
+	if s.Status == "" {
+		s.Status = ValidationStatusPending
+	}
+	return nil
+}
+
+// SuiteMemberRun records the outcome of one validation in a suite run
+type SuiteMemberRun struct {
+	ValidationID uint             `json:"validation_id"`
+	Name         string           `json:"name"`
+	Status       ValidationStatus `json:"status"`
+	Result       string           `json:"result,omitempty"` // the result status: success, failure or error
+	RunID        *uint            `json:"run_id,omitempty"`
+	Error        string           `json:"error,omitempty"`
+}
+
+// SuiteMemberRuns holds the member outcomes of a suite run
+type SuiteMemberRuns []SuiteMemberRun
+
+func (m SuiteMemberRuns) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+func (m *SuiteMemberRuns) Scan(value interface{}) error {
+	if value == nil {
+		*m = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan non-byte value into SuiteMemberRuns")
+	}
+
+	return json.Unmarshal(bytes, m)
+}
+
+// SuiteSummary counts the members of a suite run by outcome
+type SuiteSummary struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`  // completed with differences beyond the error margin
+	Errored int `json:"errored"` // could not complete
+	Pending int `json:"pending"` // not finished yet
+}
+
+func (s SuiteSummary) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *SuiteSummary) Scan(value interface{}) error {
+	if value == nil {
+		*s = SuiteSummary{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan non-byte value into SuiteSummary")
+	}
+
+	return json.Unmarshal(bytes, s)
+}
+
+// SuiteRun records one run of every validation in a suite
+type SuiteRun struct {
+	ID          uint             `json:"id"`
+	SuiteID     uint             `gorm:"index;not null" json:"suite_id"`
+	Status      ValidationStatus `json:"status"`
+	Summary     SuiteSummary     `gorm:"type:json" json:"summary"`
+	Members     SuiteMemberRuns  `gorm:"type:json" json:"members"`
+	StartedAt   time.Time        `gorm:"index" json:"started_at"`
+	FinishedAt  *time.Time       `json:"finished_at,omitempty"`
+	HeartbeatAt *time.Time       `json:"-"` // refreshed while an instance runs it
+	CreatedAt   time.Time        `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -14,6 +14,7 @@ type ValidationStatus string
 const (
 	ValidationStatusPending   ValidationStatus = "pending"
 	ValidationStatusRunning   ValidationStatus = "running"
+	ValidationStatusWaiting   ValidationStatus = "waiting" // deferred by a connection's execution windows or query limit
 	ValidationStatusCompleted ValidationStatus = "completed"
 	ValidationStatusFailed    ValidationStatus = "failed"
 )
@@ -59,19 +60,20 @@ func (v *ValidationResults) Scan(value interface{}) error {
 }
 
 type Validation struct {
-	ID                 uint              `json:"id"`
-	Name               string            `gorm:"not null" json:"name"`
-	SourceConnectionID uint              `json:"source_connection_id"`
-	TargetConnectionID uint              `json:"target_connection_id"`
-	SourceConnection   *Connection       `gorm:"foreignKey:SourceConnectionID" json:"source_connection,omitempty"`
-	TargetConnection   *Connection       `gorm:"foreignKey:TargetConnectionID" json:"target_connection,omitempty"`
-	Config             ValidationConfig  `gorm:"type:json" json:"config"`
-	Status             ValidationStatus  `gorm:"default:'pending'" json:"status"`
-	Results            ValidationResults `gorm:"type:json" json:"results,omitempty"`
-	UserID             uint              `json:"user_id"`
-	User               *User             `gorm:"foreignKey:UserID" json:"-"`
-	CreatedAt          time.Time         `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt          time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+	ID                 uint                 `json:"id"`
+	Name               string               `gorm:"not null" json:"name"`
+	SourceConnectionID uint                 `json:"source_connection_id"`
+	TargetConnectionID uint                 `json:"target_connection_id"`
+	SourceConnection   *Connection          `gorm:"foreignKey:SourceConnectionID" json:"source_connection,omitempty"`
+	TargetConnection   *Connection          `gorm:"foreignKey:TargetConnectionID" json:"target_connection,omitempty"`
+	Config             ValidationConfig     `gorm:"type:json" json:"config"`
+	Status             ValidationStatus     `gorm:"default:'pending'" json:"status"`
+	Results            ValidationResults    `gorm:"type:json" json:"results,omitempty"`
+	Notifications      NotificationSettings `gorm:"type:json" json:"notifications"`
+	UserID             uint                 `json:"user_id"`
+	User               *User                `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt          time.Time            `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time            `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 func (v *Validation) BeforeCreate(tx *gorm.DB) error {
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// RunTrigger records what started a run
+type RunTrigger string
+
+const (
+	RunTriggerManual    RunTrigger = "manual"
+	RunTriggerScheduled RunTrigger = "scheduled"
+	RunTriggerPipeline  RunTrigger = "pipeline"
+	RunTriggerSuite     RunTrigger = "suite"
+)
+
+// RunVariables holds the variable values a run resolved
+type RunVariables map[string]string
+
+func (v RunVariables) Value() (driver.Value, error) {
+	return json.Marshal(v)
+}
+
+func (v *RunVariables) Scan(value interface{}) error {
+	if value == nil {
+		*v = make(RunVariables)
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan non-byte value into RunVariables")
+	}
+
+	return json.Unmarshal(bytes, v)
+}
+
+// RunAttempt records one attempt of a run that is retried
+type RunAttempt struct {
+	Attempt     int       `json:"attempt"`
+	ExecutionID string    `json:"execution_id"`
+	Status      string    `json:"status"` // the result status: success, failure or error
+	Error       string    `json:"error,omitempty"`
+	ErrorClass  string    `json:"error_class,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+}
+
+// RunAttempts holds the attempts of a run
+type RunAttempts []RunAttempt
+
+func (a RunAttempts) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+func (a *RunAttempts) Scan(value interface{}) error {
+	if value == nil {
+		*a = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan non-byte value into RunAttempts")
+	}
+
+	return json.Unmarshal(bytes, a)
+}
+
+// ValidationRun records one execution of a validation
+type ValidationRun struct {
+	ID            uint              `json:"id"`
+	ValidationID  uint              `gorm:"index;not null" json:"validation_id"`
+	ExecutionID   string            `json:"execution_id"`
+	Trigger       RunTrigger        `gorm:"default:'manual'" json:"trigger"`
+	ScheduleID    *uint             `gorm:"index" json:"schedule_id,omitempty"`
+	ScheduledFor  *time.Time        `json:"scheduled_for,omitempty"` // the tick a scheduled run was started for
+	PipelineRunID *uint             `gorm:"index" json:"pipeline_run_id,omitempty"`
+	SuiteRunID    *uint             `gorm:"index" json:"suite_run_id,omitempty"`
+	Status        ValidationStatus  `json:"status"`
+	StatusReason  string            `json:"status_reason,omitempty"` // what a waiting run waits for
+	Variables     RunVariables      `gorm:"type:json" json:"variables"`
+	Results       ValidationResults `gorm:"type:json" json:"results,omitempty"`
+	Attempts      RunAttempts       `gorm:"type:json" json:"attempts"` // every attempt; Results holds the last one's
+	StartedAt     time.Time         `json:"started_at"`
+	FinishedAt    *time.Time        `json:"finished_at,omitempty"`
+	HeartbeatAt   *time.Time        `json:"-"` // refreshed while an instance runs it
+	CreatedAt     time.Time         `gorm:"autoCreateTime" json:"created_at"`
+}
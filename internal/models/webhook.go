@@ -0,0 +1,129 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Events a webhook can subscribe to
+const (
+	EventRunCompleted         = "run.completed"          // a run passed
+	EventRunFailed            = "run.failed"             // a run did not pass, including errors
+	EventRunThresholdBreached = "run.threshold_breached" // a run's differences exceeded its error margin
+	EventSuiteCompleted       = "suite.completed"        // every validation of a suite run passed
+	EventSuiteFailed          = "suite.failed"           // a suite run had a validation that did not pass
+	EventScheduleMissed       = "schedule.missed"        // a schedule skipped ticks
+	EventPing                 = "ping"                   // a test delivery
+)
+
+// WebhookEvents lists the subscribable events
+var WebhookEvents = []string{EventRunCompleted, EventRunFailed, EventRunThresholdBreached, EventSuiteCompleted, EventSuiteFailed, EventScheduleMissed}
+
+// WebhookType is the format a webhook posts in
+type WebhookType string
+
+const (
+	WebhookJSON  WebhookType = "json"  // the signed event payload
+	WebhookSlack WebhookType = "slack" // a Block Kit message for a Slack incoming webhook
+	WebhookTeams WebhookType = "teams" // an Adaptive Card for a Teams incoming webhook
+)
+
+// DeliveryStatus is the state of a webhook delivery
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed" // no attempts left
+)
+
+// EventTypes holds the events a webhook subscribes to
+type EventTypes []string
+
+func (e EventTypes) Value() (driver.Value, error) {
+	return json.Marshal(e)
+}
+
+func (e *EventTypes) Scan(value interface{}) error {
+	if value == nil {
+		*e = nil
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("cannot scan non-byte value into EventTypes")
+	}
+
+	return json.Unmarshal(bytes, e)
+}
+
+// Has reports whether event is in the list
+func (e EventTypes) Has(event string) bool {
+	for _, t := range e {
+		if t == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook posts to a URL when subscribed events occur, as a signed JSON
+// payload or a Slack or Teams message
+type Webhook struct {
+	ID        uint        `json:"id"`
+	Name      string      `gorm:"not null" json:"name"`
+	URL       string      `gorm:"not null" json:"url"`
+	Type      WebhookType `gorm:"default:'json'" json:"type"`
+	Secret    string      `json:"-"` // signs payloads; encrypted at rest like connection secrets
+	Events    EventTypes  `gorm:"type:json" json:"events"`
+	Enabled   bool        `json:"enabled"`
+	UserID    uint        `gorm:"index" json:"user_id"`
+	User      *User       `gorm:"foreignKey:UserID" json:"-"`
+	CreatedAt time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (w *Webhook) BeforeSave(tx *gorm.DB) error {
+	return w.transformSecret(secretCipher.Encrypt)
+}
+
+func (w *Webhook) AfterSave(tx *gorm.DB) error {
+	return w.transformSecret(secretCipher.Decrypt)
+}
+
+func (w *Webhook) AfterFind(tx *gorm.DB) error {
+	return w.transformSecret(secretCipher.Decrypt)
+}
+
+func (w *Webhook) transformSecret(fn func(string) (string, error)) error {
+	if w.Secret == "" {
+		return nil
+	}
+	secret, err := fn(w.Secret)
+	if err != nil {
+		return err
+	}
+	w.Secret = secret
+	return nil
+}
+
+// WebhookDelivery records one event sent, or being sent, to a webhook
+type WebhookDelivery struct {
+	ID             uint           `json:"id"`
+	WebhookID      uint           `gorm:"index;not null" json:"webhook_id"`
+	Event          string         `json:"event"`
+	Payload        string         `gorm:"type:text" json:"payload"`
+	Status         DeliveryStatus `gorm:"index" json:"status"`
+	Attempts       int            `json:"attempts"`
+	ResponseStatus int            `json:"response_status,omitempty"` // HTTP status of the last attempt
+	Error          string         `json:"error,omitempty"`           // error of the last attempt
+	NextAttemptAt  *time.Time     `gorm:"index" json:"next_attempt_at,omitempty"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
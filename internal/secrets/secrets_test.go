@@ -0,0 +1,62 @@
+package secrets
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c := NewCipher("test-key")
+
+	encrypted, err := c.Encrypt("s3cret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if !IsEncrypted(encrypted) || encrypted == "s3cret" {
+		t.Fatalf("Encrypt() = %q, want an encrypted value", encrypted)
+	}
+
+	again, _ := c.Encrypt(encrypted)
+	if again != encrypted {
+		t.Error("Encrypt() should not re-encrypt an encrypted value")
+	}
+
+	decrypted, err := c.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if decrypted != "s3cret" {
+		t.Errorf("Decrypt() = %q, want %q", decrypted, "s3cret")
+	}
+}
+
+func TestCipher_Plaintext(t *testing.T) {
+	c := NewCipher("test-key")
+
+	got, err := c.Decrypt("legacy-plaintext")
+	if err != nil || got != "legacy-plaintext" {
+		t.Errorf("Decrypt() = %q, %v; want plaintext passed through", got, err)
+	}
+}
+
+func TestCipher_WrongKey(t *testing.T) {
+	encrypted, _ := NewCipher("key-one").Encrypt("s3cret")
+
+	if _, err := NewCipher("key-two").Decrypt(encrypted); err == nil {
+		t.Error("Decrypt() with the wrong key should fail")
+	}
+}
+
+func TestCipher_Nil(t *testing.T) {
+	var c *Cipher
+
+	got, err := c.Encrypt("s3cret")
+	if err != nil || got != "s3cret" {
+		t.Errorf("nil Encrypt() = %q, %v; want plaintext", got, err)
+	}
+
+	encrypted, _ := NewCipher("key").Encrypt("s3cret")
+	if _, err := c.Decrypt(encrypted); !errors.Is(err, ErrNoKey) {
+		t.Errorf("nil Decrypt() error = %v, want ErrNoKey", err)
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+// admissionPollInterval bounds how long a waiting run sleeps before checking
+// its connections again
+const admissionPollInterval = 30 * time.Second
+
+// Blocked returns why a run of the validation could not start now, or "" if
+// it could. Connections must be preloaded.
+func (s *RunService) Blocked(v *models.Validation, config *validation.Config) string {
+	needs, limits := querySlots(v, config)
+	reason, _ := s.blocked(v, needs, limits, time.Now())
+	return reason
+}
+
+// admission is what an admitted attempt holds
+type admission struct {
+	slots  map[uint]int // released when the attempt ends
+	closes time.Time    // when a window closes and cuts the attempt off; zero if none does
+	cause  error        // why the attempt is cut off at closes
+}
+
+// admit waits until every connection of the validation is inside an
+// execution window and has free query slots, marking the run waiting in
+// the meantime
+func (s *RunService) admit(ctx context.Context, v *models.Validation, config *validation.Config, run *models.ValidationRun) (*admission, error) {
+	needs, limits := querySlots(v, config)
+	for {
+		changed := s.limiter.changed()
+		now := time.Now()
+		reason, wake := s.blocked(v, needs, limits, now)
+		if reason == "" {
+			if _, ok := s.limiter.tryAcquire(needs, limits); ok {
+				if run.Status == models.ValidationStatusWaiting {
+					s.setStatus(v, run, models.ValidationStatusRunning, "")
+				}
+				closes, cause := windowClose(v, now)
+				return &admission{slots: needs, closes: closes, cause: cause}, nil
+			}
+			continue // another run took the slots first
+		}
+		if run.Status != models.ValidationStatusWaiting || run.StatusReason != reason {
+			s.setStatus(v, run, models.ValidationStatusWaiting, reason)
+		}
+
+		wait := admissionPollInterval
+		if !wake.IsZero() && time.Until(wake) < wait {
+			wait = time.Until(wake)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-changed:
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("cancelled while %s", reason)
+		}
+		timer.Stop()
+	}
+}
+
+// attempt runs the validation once, cancelling it when an execution window
+// of its connections closes
+func (s *RunService) attempt(ctx context.Context, v *models.Validation, config *validation.Config, run *models.ValidationRun, admitted *admission) *validation.Result {
+	if admitted.closes.IsZero() {
+		return s.validations.Run(ctx, run.ID, v, config)
+	}
+	ctx, cancel := context.WithDeadlineCause(ctx, admitted.closes, admitted.cause)
+	defer cancel()
+	result := s.validations.Run(ctx, run.ID, v, config)
+	if result.Status == validation.StatusError && context.Cause(ctx) == admitted.cause {
+		cutOff := validation.ErrorEntry{Timestamp: time.Now().UTC(), Message: admitted.cause.Error(), Class: connectors.ErrorClassTimeout}
+		result.Errors = append([]validation.ErrorEntry{cutOff}, result.Errors...)
+	}
+	return result
+}
+
+// windowClose returns when the first execution window of the validation's
+// connections that is open at now closes, and why a run is cut off then.
+// The time is zero if no window closes.
+func windowClose(v *models.Validation, now time.Time) (time.Time, error) {
+	var closes time.Time
+	var cause error
+	for _, conn := range runConnections(v) {
+		if t := conn.ExecutionWindows.Closes(now); !t.IsZero() && (closes.IsZero() || t.Before(closes)) {
+			closes = t
+			cause = fmt.Errorf("cut off: the execution window of connection %q closed at %s", conn.Name, t.Format(time.RFC3339))
+		}
+	}
+	return closes, cause
+}
+
+// blocked returns why the validation's connections cannot take a run at
+// now, and when a closed window opens
+func (s *RunService) blocked(v *models.Validation, needs, limits map[uint]int, now time.Time) (string, time.Time) {
+	for _, conn := range runConnections(v) {
+		if !conn.ExecutionWindows.Open(now) {
+			opens := conn.ExecutionWindows.NextOpen(now)
+			return fmt.Sprintf("waiting for window: connection %q opens at %s", conn.Name, opens.Format(time.RFC3339)), opens
+		}
+	}
+	if id := s.limiter.blocking(needs, limits); id != 0 {
+		for _, conn := range runConnections(v) {
+			if conn.ID == id {
+				return fmt.Sprintf("waiting for a query slot: connection %q allows %d concurrent queries", conn.Name, conn.MaxConcurrentQueries), time.Time{}
+			}
+		}
+	}
+	return "", time.Time{}
+}
+
+func (s *RunService) setStatus(v *models.Validation, run *models.ValidationRun, status models.ValidationStatus, reason string) {
+	v.Status = status
+	s.db.Model(v).Update("status", v.Status)
+	run.Status = status
+	run.StatusReason = reason
+	s.db.Model(run).Updates(map[string]interface{}{"status": run.Status, "status_reason": run.StatusReason})
+}
+
+// querySlots returns the query slots a run needs on each of its connections
+// and their limits. A chunked data_match holds performance.concurrency
+// queries per side; it is lowered so that the run fits every limit alone,
+// querying the sides in turn when they share a connection allowing one query.
+func querySlots(v *models.Validation, config *validation.Config) (map[uint]int, map[uint]int) {
+	uses := make(map[uint]int)
+	limits := make(map[uint]int)
+	for _, conn := range []*models.Connection{v.SourceConnection, v.TargetConnection} {
+		if conn != nil {
+			uses[conn.ID]++
+			limits[conn.ID] = conn.MaxConcurrentQueries
+		}
+	}
+
+	perSide := 1
+	if config.Performance.Chunks > 1 {
+		perSide = config.Performance.Concurrency
+		for id, n := range uses {
+			if limits[id] > 0 && perSide*n > limits[id] {
+				perSide = max(1, limits[id]/n)
+			}
+		}
+		config.Performance.Concurrency = perSide
+	}
+
+	needs := make(map[uint]int, len(uses))
+	for id, n := range uses {
+		if limits[id] > 0 && perSide*n > limits[id] {
+			// Both sides on a connection allowing one query take turns
+			config.Performance.SequentialSides = true
+			n = 1
+		}
+		needs[id] = perSide * n
+	}
+	return needs, limits
+}
+
+// runConnections returns the distinct preloaded connections of a validation
+func runConnections(v *models.Validation) []*models.Connection {
+	var conns []*models.Connection
+	for _, conn := range []*models.Connection{v.SourceConnection, v.TargetConnection} {
+		if conn != nil && (len(conns) == 0 || conns[0].ID != conn.ID) {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+func TestQuerySlots(t *testing.T) {
+	prod := &models.Connection{ID: 1, Name: "prod", MaxConcurrentQueries: 2}
+	dw := &models.Connection{ID: 2, Name: "dw"}
+	legacy := &models.Connection{ID: 3, Name: "legacy", MaxConcurrentQueries: 1}
+
+	tests := []struct {
+		name            string
+		source, target  *models.Connection
+		chunks          int
+		wantNeeds       map[uint]int
+		wantConcurrency int
+		wantSequential  bool
+	}{
+		{"one query per side", prod, dw, 1, map[uint]int{1: 1, 2: 1}, 4, false},
+		{"chunk concurrency lowered to the limit", prod, dw, 8, map[uint]int{1: 2, 2: 2}, 2, false},
+		{"both sides on one limited connection", prod, prod, 8, map[uint]int{1: 2}, 1, false},
+		{"both sides on a single query connection", legacy, legacy, 1, map[uint]int{3: 1}, 4, true},
+		{"chunks on a single query connection", legacy, legacy, 8, map[uint]int{3: 1}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &models.Validation{SourceConnection: tt.source, TargetConnection: tt.target}
+			config := &validation.Config{Performance: validation.Performance{Chunks: tt.chunks, Concurrency: 4}}
+			needs, _ := querySlots(v, config)
+			if len(needs) != len(tt.wantNeeds) {
+				t.Fatalf("querySlots() = %v, want %v", needs, tt.wantNeeds)
+			}
+			for id, n := range tt.wantNeeds {
+				if needs[id] != n {
+					t.Errorf("querySlots() = %v, want %v", needs, tt.wantNeeds)
+				}
+			}
+			if config.Performance.Concurrency != tt.wantConcurrency {
+				t.Errorf("concurrency = %d, want %d", config.Performance.Concurrency, tt.wantConcurrency)
+			}
+			if config.Performance.SequentialSides != tt.wantSequential {
+				t.Errorf("sequential sides = %v, want %v", config.Performance.SequentialSides, tt.wantSequential)
+			}
+		})
+	}
+}
+
+func TestRunService_Blocked(t *testing.T) {
+	s := &RunService{limiter: newConnectionLimiter()}
+	prod := &models.Connection{ID: 1, Name: "prod", MaxConcurrentQueries: 2}
+	dw := &models.Connection{ID: 2, Name: "dw"}
+	v := &models.Validation{SourceConnection: prod, TargetConnection: dw}
+	config := &validation.Config{Performance: validation.Performance{Chunks: 1, Concurrency: 4}}
+
+	if reason := s.Blocked(v, config); reason != "" {
+		t.Fatalf("Blocked() = %q, want no reason", reason)
+	}
+
+	// Two runs fill prod's slots
+	needs, limits := querySlots(v, config)
+	for i := 0; i < 2; i++ {
+		if _, ok := s.limiter.tryAcquire(needs, limits); !ok {
+			t.Fatalf("tryAcquire() #%d failed", i+1)
+		}
+	}
+	if reason := s.Blocked(v, config); !strings.Contains(reason, `query slot: connection "prod"`) {
+		t.Errorf("Blocked() = %q, want a query slot reason", reason)
+	}
+	s.limiter.release(needs)
+	if reason := s.Blocked(v, config); reason != "" {
+		t.Errorf("Blocked() after release = %q, want no reason", reason)
+	}
+
+	dw.ExecutionWindows = models.ExecutionWindows{{Start: "18:00", End: "08:00", Timezone: "UTC"}}
+	noon := time.Date(2024, 1, 17, 12, 0, 0, 0, time.UTC)
+	reason, wake := s.blocked(v, needs, limits, noon)
+	if reason != `waiting for window: connection "dw" opens at 2024-01-17T18:00:00Z` || !wake.Equal(noon.Add(6*time.Hour)) {
+		t.Errorf("blocked() = %q, %v, want the window opening at 18:00", reason, wake)
+	}
+}
+
+func init() {
+	connectors.Register("sqlite", func() connectors.Connector { return &sqliteConnector{} })
+}
+
+// sqliteConnector connects to an empty in-memory SQLite database
+type sqliteConnector struct {
+	connectors.Connector
+}
+
+func (c *sqliteConnector) Type() string { return "sqlite" }
+
+func (c *sqliteConnector) ParseConfig(configMap map[string]interface{}) (interface{}, error) {
+	return nil, nil
+}
+
+func (c *sqliteConnector) Connect(config interface{}) (*sql.DB, error) {
+	return sql.Open("sqlite3", ":memory:")
+}
+
+func (c *sqliteConnector) QuoteIdentifier(name string) string { return `"` + name + `"` }
+
+func (c *sqliteConnector) Placeholder(n int) string { return "?" }
+
+func (c *sqliteConnector) NormalizeExpression(expr, databaseType string) string {
+	return "CAST(" + expr + " AS TEXT)"
+}
+
+func (c *sqliteConnector) HashExpression(texts []string, part int) string {
+	panic("not supported")
+}
+
+func TestWindowClose(t *testing.T) {
+	now := time.Date(2024, 1, 17, 20, 0, 0, 0, time.UTC)
+	prod := &models.Connection{ID: 1, Name: "prod", ExecutionWindows: models.ExecutionWindows{{Start: "18:00", End: "22:00"}}}
+	dw := &models.Connection{ID: 2, Name: "dw", ExecutionWindows: models.ExecutionWindows{{Start: "19:00", End: "21:00"}}}
+	open := &models.Connection{ID: 3, Name: "lake"}
+
+	closes, cause := windowClose(&models.Validation{SourceConnection: prod, TargetConnection: dw}, now)
+	if want := time.Date(2024, 1, 17, 21, 0, 0, 0, time.UTC); !closes.Equal(want) {
+		t.Errorf("windowClose() = %v, want %v", closes, want)
+	}
+	if cause == nil || !strings.Contains(cause.Error(), `connection "dw" closed at 2024-01-17T21:00:00Z`) {
+		t.Errorf("windowClose() cause = %v, want dw's window", cause)
+	}
+
+	if closes, _ := windowClose(&models.Validation{SourceConnection: open, TargetConnection: open}, now); !closes.IsZero() {
+		t.Errorf("windowClose() without windows = %v, want zero", closes)
+	}
+}
+
+func TestRunService_AttemptCutOffAtWindowClose(t *testing.T) {
+	s := NewRunService(nil)
+	conn := &models.Connection{ID: 1, Name: "prod", Type: "sqlite"}
+	v := &models.Validation{SourceConnection: conn, TargetConnection: conn}
+	// The queries never finish on their own
+	endless := "WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n) SELECT i FROM n"
+	config, err := s.validations.ParseConfig(models.ValidationConfig{
+		"comparison_type": "row_count",
+		"source_query":    endless,
+		"target_query":    endless,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admitted := &admission{
+		closes: time.Now().Add(200 * time.Millisecond),
+		cause:  errors.New(`cut off: the execution window of connection "prod" closed`),
+	}
+	done := make(chan *validation.Result, 1)
+	go func() {
+		done <- s.attempt(context.Background(), v, config, &models.ValidationRun{ID: 1}, admitted)
+	}()
+
+	select {
+	case result := <-done:
+		if result.Status != validation.StatusError || len(result.Errors) == 0 {
+			t.Fatalf("result = %+v, want an error", result)
+		}
+		if got := result.Errors[0]; got.Message != admitted.cause.Error() || got.Class != connectors.ErrorClassTimeout {
+			t.Errorf("first error = %+v, want the window close as a timeout", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("attempt() ran past the window close")
+	}
+}
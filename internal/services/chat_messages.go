@@ -0,0 +1,295 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+const (
+	// chatTopColumns is how many differing columns a chat message lists
+	chatTopColumns = 5
+	// chatMaxLines is how many suite members or errors a chat message lists
+	chatMaxLines = 10
+)
+
+// chatMessage is what Slack and Teams messages show of an event
+type chatMessage struct {
+	Title   string
+	Outcome string // good, bad or warning; empty for neutral messages
+	Facts   []chatFact
+	// Columns lists the columns with the most differing rows, most first
+	Columns []columnCount
+	Lines   []string
+	Link    string // absolute, or empty without a public URL
+	Action  string // label of the link
+}
+
+type chatFact struct {
+	Title string
+	Value string
+}
+
+type columnCount struct {
+	Column string
+	Rows   int
+}
+
+// newChatMessage summarizes an event for chat. Links are only set when
+// publicURL is, since chat clients need absolute URLs.
+func newChatMessage(event Event, publicURL string) chatMessage {
+	var m chatMessage
+	link := func(path string, action string) {
+		if publicURL != "" {
+			m.Link = publicURL + path
+			m.Action = action
+		}
+	}
+
+	switch {
+	case event.Type == models.EventPing:
+		m.Title = "CompareFlow test message"
+		m.Lines = []string{"This channel will receive CompareFlow notifications."}
+
+	case event.Schedule != nil:
+		name := ""
+		if event.Validation != nil {
+			name = event.Validation.Name
+			link(fmt.Sprintf("/validations/%d", event.Validation.ID), "View validation")
+		}
+		m.Title = fmt.Sprintf("Schedule of %s skipped %d runs", name, event.Missed)
+		m.Outcome = "warning"
+		m.Facts = []chatFact{{"Cron", event.Schedule.Cron}, {"Timezone", event.Schedule.Timezone}}
+		if next := event.Schedule.NextRunAt; next != nil {
+			m.Facts = append(m.Facts, chatFact{"Next run", next.Format(time.RFC3339)})
+		}
+
+	case event.Suite != nil && event.SuiteRun != nil:
+		run := event.SuiteRun
+		if run.Status == models.ValidationStatusCompleted {
+			m.Title = fmt.Sprintf("Suite %s passed", event.Suite.Name)
+			m.Outcome = "good"
+		} else {
+			m.Title = fmt.Sprintf("Suite %s failed", event.Suite.Name)
+			m.Outcome = "bad"
+		}
+		m.Facts = []chatFact{
+			{"Passed", strconv.Itoa(run.Summary.Passed)},
+			{"Failed", strconv.Itoa(run.Summary.Failed)},
+			{"Errored", strconv.Itoa(run.Summary.Errored)},
+			{"Total", strconv.Itoa(run.Summary.Total)},
+		}
+		for _, member := range run.Members {
+			if member.Status == models.ValidationStatusCompleted {
+				continue
+			}
+			line := fmt.Sprintf("%s: %s", member.Name, member.Result)
+			if member.Error != "" {
+				line += " - " + member.Error
+			}
+			m.Lines = append(m.Lines, line)
+		}
+		link(suiteRunPath(event.Suite.ID, run.ID), "View suite run")
+
+	case event.Validation != nil && event.Run != nil:
+		v, run, result := event.Validation, event.Run, event.Result
+		status := ""
+		if result != nil {
+			status = result.Status
+		}
+		switch {
+		case event.Transition == models.IncidentRecovered:
+			m.Title = fmt.Sprintf("%s recovered", v.Name)
+			m.Outcome = "good"
+		case status == validation.StatusSuccess:
+			m.Title = fmt.Sprintf("%s passed", v.Name)
+			m.Outcome = "good"
+		case status == validation.StatusFailure:
+			m.Title = fmt.Sprintf("%s failed: %d rows differ", v.Name, differingRows(result.Summary))
+			m.Outcome = "bad"
+		default:
+			m.Title = fmt.Sprintf("%s errored", v.Name)
+			m.Outcome = "warning"
+		}
+		m.Facts = []chatFact{{"Run", fmt.Sprintf("#%d (%s)", run.ID, run.Trigger)}}
+		if summary := incidentSummary(event); summary != "" {
+			m.Lines = append(m.Lines, summary)
+		}
+		if result != nil {
+			summary := result.Summary
+			m.Facts = append(m.Facts,
+				chatFact{"Source rows", strconv.FormatInt(summary.SourceRowCount, 10)},
+				chatFact{"Target rows", strconv.FormatInt(summary.TargetRowCount, 10)},
+			)
+			if pct, ok := mismatchPercent(summary); ok {
+				m.Facts = append(m.Facts, chatFact{"Mismatch", fmt.Sprintf("%.2f%%", pct)})
+			}
+			m.Facts = append(m.Facts, chatFact{"Duration", (time.Duration(result.DurationMS) * time.Millisecond).String()})
+			m.Columns = topColumns(result.Details.Differences, chatTopColumns)
+			for _, e := range result.Errors {
+				m.Lines = append(m.Lines, e.Message)
+			}
+		}
+		link(runPath(v.ID, run.ID), "View run")
+	}
+
+	if len(m.Lines) > chatMaxLines {
+		more := len(m.Lines) - chatMaxLines
+		m.Lines = append(m.Lines[:chatMaxLines], fmt.Sprintf("... and %d more", more))
+	}
+	return m
+}
+
+// mismatchPercent is the share of differing rows in the larger side
+func mismatchPercent(summary validation.Summary) (float64, bool) {
+	rows := summary.SourceRowCount
+	if summary.TargetRowCount > rows {
+		rows = summary.TargetRowCount
+	}
+	if rows == 0 {
+		return 0, false
+	}
+	return float64(differingRows(summary)) * 100 / float64(rows), true
+}
+
+// topColumns counts the differences per column and returns the n columns with
+// the most
+func topColumns(differences []validation.Difference, n int) []columnCount {
+	counts := make(map[string]int)
+	for _, d := range differences {
+		for _, column := range d.Columns {
+			counts[column]++
+		}
+	}
+	columns := make([]columnCount, 0, len(counts))
+	for column, rows := range counts {
+		columns = append(columns, columnCount{column, rows})
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		if columns[i].Rows != columns[j].Rows {
+			return columns[i].Rows > columns[j].Rows
+		}
+		return columns[i].Column < columns[j].Column
+	})
+	if len(columns) > n {
+		columns = columns[:n]
+	}
+	return columns
+}
+
+var slackEmoji = map[string]string{"good": ":white_check_mark: ", "bad": ":x: ", "warning": ":warning: "}
+
+// slackMessage renders a Block Kit message for a Slack incoming webhook
+func slackMessage(m chatMessage) map[string]interface{} {
+	mrkdwn := func(text string) map[string]interface{} {
+		return map[string]interface{}{"type": "mrkdwn", "text": text}
+	}
+	plain := func(text string) map[string]interface{} {
+		return map[string]interface{}{"type": "plain_text", "text": text, "emoji": true}
+	}
+
+	title := slackEmoji[m.Outcome] + m.Title
+	blocks := []interface{}{
+		map[string]interface{}{"type": "header", "text": plain(truncate(title, 150))},
+	}
+	if len(m.Facts) > 0 {
+		var fields []interface{}
+		for _, f := range m.Facts {
+			fields = append(fields, mrkdwn(fmt.Sprintf("*%s*\n%s", slackEscape(f.Title), slackEscape(f.Value))))
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "fields": fields})
+	}
+	if len(m.Columns) > 0 {
+		lines := []string{"*Top differing columns*"}
+		for _, c := range m.Columns {
+			lines = append(lines, fmt.Sprintf("`%s` %d rows", slackEscape(c.Column), c.Rows))
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "text": mrkdwn(strings.Join(lines, "\n"))})
+	}
+	if len(m.Lines) > 0 {
+		lines := make([]string, len(m.Lines))
+		for i, line := range m.Lines {
+			lines[i] = "• " + slackEscape(line)
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "text": mrkdwn(truncate(strings.Join(lines, "\n"), 3000))})
+	}
+	if m.Link != "" {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "actions",
+			"elements": []interface{}{
+				map[string]interface{}{"type": "button", "text": plain(m.Action), "url": m.Link},
+			},
+		})
+	}
+	return map[string]interface{}{"text": slackEscape(title), "blocks": blocks}
+}
+
+var teamsColor = map[string]string{"good": "Good", "bad": "Attention", "warning": "Warning"}
+
+// teamsMessage renders an Adaptive Card message for a Teams incoming webhook
+func teamsMessage(m chatMessage) map[string]interface{} {
+	heading := map[string]interface{}{
+		"type": "TextBlock", "text": m.Title, "weight": "Bolder", "size": "Medium", "wrap": true,
+	}
+	if color, ok := teamsColor[m.Outcome]; ok {
+		heading["color"] = color
+	}
+	body := []interface{}{heading}
+	if len(m.Facts) > 0 {
+		var facts []interface{}
+		for _, f := range m.Facts {
+			facts = append(facts, map[string]interface{}{"title": f.Title, "value": f.Value})
+		}
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": facts})
+	}
+	if len(m.Columns) > 0 {
+		var facts []interface{}
+		for _, c := range m.Columns {
+			facts = append(facts, map[string]interface{}{"title": c.Column, "value": fmt.Sprintf("%d rows", c.Rows)})
+		}
+		body = append(body,
+			map[string]interface{}{"type": "TextBlock", "text": "Top differing columns", "weight": "Bolder", "spacing": "Medium"},
+			map[string]interface{}{"type": "FactSet", "facts": facts},
+		)
+	}
+	for _, line := range m.Lines {
+		body = append(body, map[string]interface{}{"type": "TextBlock", "text": "- " + line, "wrap": true, "spacing": "Small"})
+	}
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+	if m.Link != "" {
+		card["actions"] = []interface{}{
+			map[string]interface{}{"type": "Action.OpenUrl", "title": m.Action, "url": m.Link},
+		}
+	}
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	}
+}
+
+// slackEscape escapes the characters Slack mrkdwn treats as markup
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+func TestChatMessages(t *testing.T) {
+	result := &validation.Result{
+		Status:     validation.StatusFailure,
+		DurationMS: 2000,
+		Summary:    validation.Summary{SourceRowCount: 200, TargetRowCount: 199, MismatchedRows: 5, MissingInTarget: 1},
+		Details: validation.Details{Differences: []validation.Difference{
+			{Type: "mismatch", Columns: []string{"amount", "status"}},
+			{Type: "mismatch", Columns: []string{"amount"}},
+			{Type: "mismatch", Columns: []string{"<note>"}},
+		}},
+	}
+	event := Event{
+		Type:       models.EventRunFailed,
+		Validation: &models.Validation{ID: 3, Name: "orders"},
+		Run:        &models.ValidationRun{ID: 42, Trigger: models.RunTriggerScheduled},
+		Result:     result,
+	}
+
+	m := newChatMessage(event, "https://cf.example.com")
+	if want := "orders failed: 6 rows differ"; m.Title != want {
+		t.Errorf("Title = %q, want %q", m.Title, want)
+	}
+	wantColumns := []columnCount{{"amount", 2}, {"<note>", 1}, {"status", 1}}
+	if len(m.Columns) != len(wantColumns) {
+		t.Fatalf("Columns = %v, want %v", m.Columns, wantColumns)
+	}
+	for i := range wantColumns {
+		if m.Columns[i] != wantColumns[i] {
+			t.Errorf("Columns = %v, want %v", m.Columns, wantColumns)
+		}
+	}
+
+	slack := marshalUnescaped(t, slackMessage(m))
+	for _, want := range []string{
+		`"type":"header"`,
+		`*Mismatch*\n3.00%`,
+		"`&lt;note&gt;` 1 rows",
+		`"url":"https://cf.example.com/validations/3/runs/42"`,
+	} {
+		if !strings.Contains(slack, want) {
+			t.Errorf("Slack message does not contain %s:\n%s", want, slack)
+		}
+	}
+
+	teams := marshalUnescaped(t, teamsMessage(m))
+	for _, want := range []string{
+		`"contentType":"application/vnd.microsoft.card.adaptive"`,
+		`"color":"Attention"`,
+		`{"title":"Mismatch","value":"3.00%"}`,
+		`"type":"Action.OpenUrl"`,
+	} {
+		if !strings.Contains(teams, want) {
+			t.Errorf("Teams message does not contain %s:\n%s", want, teams)
+		}
+	}
+
+	// Without a public URL there is nothing to link to
+	if m := newChatMessage(event, ""); m.Link != "" {
+		t.Errorf("Link = %q without a public URL, want none", m.Link)
+	}
+}
+
+func marshalUnescaped(t *testing.T, v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
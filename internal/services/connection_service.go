@@ -1,9 +1,12 @@
 package services
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/compareflow/compareflow/internal/connectors"
+	_ "github.com/compareflow/compareflow/internal/connectors/all"
+	"github.com/compareflow/compareflow/internal/connectors/diagnostics"
 	"github.com/compareflow/compareflow/internal/models"
 )
 
@@ -34,6 +37,23 @@ func (s *ConnectionService) TestConnection(conn *models.Connection) error {
 	return connector.TestConnection(config)
 }
 
+// DiagnoseConnection runs a staged connection test reporting the outcome of each step
+func (s *ConnectionService) DiagnoseConnection(ctx context.Context, conn *models.Connection) (*diagnostics.Report, error) {
+	// Get the appropriate connector
+	connector, err := connectors.Get(string(conn.Type))
+	if err != nil {
+		return nil, err
+	}
+	
+	// Parse the config
+	config, err := connector.ParseConfig(conn.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	
+	return diagnostics.Run(ctx, connector, config), nil
+}
+
 // GetTables returns a list of tables from the connection
 func (s *ConnectionService) GetTables(conn *models.Connection) ([]string, error) {
 	// Get the appropriate connector
@@ -95,6 +115,26 @@ func (s *ConnectionService) ValidateConnectionConfig(connType models.ConnectionT
 	return err
 }
 
+// ParseDSN converts a connection string into a connection config for the given connector type
+func (s *ConnectionService) ParseDSN(connType models.ConnectionType, dsn string) (models.ConnectionConfig, error) {
+	// Get the appropriate connector
+	connector, err := connectors.Get(string(connType))
+	if err != nil {
+		return nil, err
+	}
+	
+	parser, ok := connector.(connectors.DSNParser)
+	if !ok {
+		return nil, fmt.Errorf("connector %s does not support connection strings", connType)
+	}
+	
+	config, err := parser.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return models.ConnectionConfig(config), nil
+}
+
 // ExecuteQuery executes a query on the connection and returns the results
 func (s *ConnectionService) ExecuteQuery(conn *models.Connection, query string) ([]map[string]interface{}, error) {
 	// Get the appropriate connector
@@ -128,6 +168,10 @@ func (s *ConnectionService) ExecuteQuery(conn *models.Connection, query string)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
 	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
 	
 	// Prepare result slice
 	var results []map[string]interface{}
@@ -148,14 +192,13 @@ func (s *ConnectionService) ExecuteQuery(conn *models.Connection, query string)
 		// Create a map for this row
 		rowMap := make(map[string]interface{})
 		for i, col := range columns {
-			val := values[i]
-			
-			// Handle byte arrays (common for some SQL types)
-			if b, ok := val.([]byte); ok {
-				val = string(b)
+			// Map driver types into canonical values so results read the same across connectors
+			val, err := connectors.MapValue(connector, values[i], columnTypes[i].DatabaseTypeName())
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", col, err)
 			}
 			
-			rowMap[col] = val
+			rowMap[col] = val.Interface()
 		}
 		
 		results = append(results, rowMap)
@@ -167,4 +210,9 @@ func (s *ConnectionService) ExecuteQuery(conn *models.Connection, query string)
 // GetSupportedConnectors returns a list of all supported connector types
 func (s *ConnectionService) GetSupportedConnectors() []string {
 	return connectors.List()
-}
\ No newline at end of file
+}
+
+// DescribeConnectors returns the config schema and capabilities of all supported connectors
+func (s *ConnectionService) DescribeConnectors() []connectors.Descriptor {
+	return connectors.Descriptors()
+}
@@ -0,0 +1,421 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/config"
+	"github.com/compareflow/compareflow/internal/models"
+)
+
+const (
+	// emailPollInterval is how often due messages and the digest are checked
+	// when no new alert wakes the sender
+	emailPollInterval = 30 * time.Second
+	// emailTimeout bounds one send, from dialing to QUIT
+	emailTimeout = 30 * time.Second
+	// emailLease is how long a send in progress keeps other instances off a message
+	emailLease = 2 * time.Minute
+	// emailMaxAttempts includes the first attempt
+	emailMaxAttempts = 6
+	// emailBackoff is the wait after the first failed attempt; it doubles after each
+	emailBackoff = time.Minute
+)
+
+// EmailService emails alerts for the runs of validations and suites with
+// recipients, and a daily digest of their runs. Messages are stored before
+// they are sent, so every instance sharing the database may run a sender.
+type EmailService struct {
+	db        *gorm.DB
+	smtp      config.SMTPConfig
+	publicURL string
+	wake      chan struct{}
+	// digestDay is the last UTC day this instance queued the digest for
+	digestDay string
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewEmailService creates a new email service instance
+func NewEmailService(db *gorm.DB, smtp config.SMTPConfig, publicURL string) *EmailService {
+	if smtp.Port == 0 {
+		smtp.Port = 587
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &EmailService{
+		db:        db,
+		smtp:      smtp,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		wake:      make(chan struct{}, 1),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Notify queues an alert for a finished run or suite run to its recipients
+// when its notify mode announces it
+func (s *EmailService) Notify(event Event) {
+	var (
+		to  []string
+		msg email
+		err error
+	)
+	switch event.Type {
+	case models.EventRunCompleted, models.EventRunFailed:
+		if event.Validation == nil || event.Run == nil {
+			return
+		}
+		to = event.Validation.Notifications.Emails
+		if len(to) == 0 || !event.Announced() {
+			return
+		}
+		msg, err = s.runAlert(event)
+	case models.EventSuiteCompleted, models.EventSuiteFailed:
+		if event.Suite == nil || event.SuiteRun == nil {
+			return
+		}
+		to = event.Suite.Notifications.Emails
+		if len(to) == 0 || !event.Announced() {
+			return
+		}
+		msg, err = s.suiteAlert(event)
+	default:
+		return
+	}
+	if err != nil {
+		log.Printf("email: failed to render %s alert: %v", event.Type, err)
+		return
+	}
+
+	if err := s.queue(nil, to, msg); err != nil {
+		log.Printf("email: %v", err)
+		return
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// queue stores a message to be sent now. A message whose key was already
+// queued is dropped.
+func (s *EmailService) queue(key *string, to []string, msg email) error {
+	now := time.Now().UTC()
+	message := &models.EmailMessage{
+		Key:           key,
+		To:            strings.Join(to, ","),
+		Subject:       msg.Subject,
+		Text:          msg.Text,
+		HTML:          msg.HTML,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &now,
+	}
+	if err := s.db.Create(message).Error; err != nil {
+		if key != nil && s.queued(*key) {
+			return nil // another instance queued it first
+		}
+		return fmt.Errorf("failed to queue message: %w", err)
+	}
+	return nil
+}
+
+// queued reports whether a message with key was queued
+func (s *EmailService) queued(key string) bool {
+	var count int64
+	s.db.Model(&models.EmailMessage{}).Where("key = ?", key).Count(&count)
+	return count > 0
+}
+
+// queueDigests queues the digest of the last 24 hours to every recipient
+// once the day's digest hour has passed. Each digest has a key of its day
+// and address, so instances sharing the database queue it once.
+func (s *EmailService) queueDigests(now time.Time) {
+	day := now.Format("2006-01-02")
+	if now.Hour() < s.smtp.DigestHour || s.digestDay == day {
+		return
+	}
+	until := time.Date(now.Year(), now.Month(), now.Day(), s.smtp.DigestHour, 0, 0, 0, time.UTC)
+	since := until.AddDate(0, 0, -1)
+
+	var validations []models.Validation
+	if err := s.db.Select("id", "name", "user_id", "notifications").Find(&validations).Error; err != nil {
+		log.Printf("email: failed to fetch validations for the digest: %v", err)
+		return
+	}
+	var suites []models.Suite
+	if err := s.db.Select("id", "name", "user_id", "notifications").Find(&suites).Error; err != nil {
+		log.Printf("email: failed to fetch suites for the digest: %v", err)
+		return
+	}
+
+	// Digests go to each address once per owner, so users never see another
+	// user's validations
+	type recipient struct {
+		userID  uint
+		address string
+	}
+	digests := make(map[recipient][]digestRow)
+	add := func(userID uint, settings models.NotificationSettings, row digestRow) {
+		for _, address := range settings.Emails {
+			r := recipient{userID, address}
+			digests[r] = append(digests[r], row)
+		}
+	}
+	for _, v := range validations {
+		if !v.Notifications.Digest {
+			continue
+		}
+		var runs []models.ValidationRun
+		s.db.Select("status").Where("validation_id = ? AND started_at >= ? AND started_at < ?", v.ID, since, until).
+			Order("id").Find(&runs)
+		row := digestRow{Kind: "validation", Name: v.Name, Link: s.link("/validations/%d", v.ID)}
+		for _, run := range runs {
+			row.count(run.Status)
+		}
+		add(v.UserID, v.Notifications, row)
+	}
+	for _, suite := range suites {
+		if !suite.Notifications.Digest {
+			continue
+		}
+		var runs []models.SuiteRun
+		s.db.Select("status").Where("suite_id = ? AND started_at >= ? AND started_at < ?", suite.ID, since, until).
+			Order("id").Find(&runs)
+		row := digestRow{Kind: "suite", Name: suite.Name, Link: s.link("/suites/%d", suite.ID)}
+		for _, run := range runs {
+			row.count(run.Status)
+		}
+		add(suite.UserID, suite.Notifications, row)
+	}
+
+	for r, rows := range digests {
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].Kind != rows[j].Kind {
+				return rows[i].Kind > rows[j].Kind // validations first
+			}
+			return rows[i].Name < rows[j].Name
+		})
+		failed := 0
+		for _, row := range rows {
+			failed += row.Failed
+		}
+		subject := fmt.Sprintf("[CompareFlow] Daily digest: %d failed runs", failed)
+		msg, err := digestTemplate.render(subject, digestData{Until: until, Rows: rows, Link: s.link("/")})
+		if err != nil {
+			log.Printf("email: failed to render digest: %v", err)
+			continue
+		}
+		key := fmt.Sprintf("digest:%s:%d:%s", day, r.userID, r.address)
+		if err := s.queue(&key, []string{r.address}, msg); err != nil {
+			log.Printf("email: %v", err)
+		}
+	}
+	s.digestDay = day
+}
+
+// Start sends due messages and the daily digest in the background until
+// Stop is called
+func (s *EmailService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(emailPollInterval)
+		defer ticker.Stop()
+		for {
+			now := time.Now().UTC()
+			s.queueDigests(now)
+			s.sendDue(now)
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the sender and waits for the send in progress
+func (s *EmailService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// sendDue claims and sends every pending message that is due
+func (s *EmailService) sendDue(now time.Time) {
+	var due []models.EmailMessage
+	if err := s.db.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("id").Limit(100).Find(&due).Error; err != nil {
+		log.Printf("email: failed to fetch due messages: %v", err)
+		return
+	}
+
+	for i := range due {
+		message := &due[i]
+		// Claim the attempt by moving next_attempt_at past the lease
+		result := s.db.Model(&models.EmailMessage{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", message.ID, models.DeliveryPending, message.NextAttemptAt.UTC()).
+			Update("next_attempt_at", now.Add(emailLease))
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue // another instance claimed it
+		}
+
+		s.attempt(message)
+		if s.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// attempt sends a message once and records the outcome, scheduling a retry
+// if attempts are left
+func (s *EmailService) attempt(message *models.EmailMessage) {
+	message.Attempts++
+	err := s.send(message)
+	now := time.Now().UTC()
+	switch {
+	case err == nil:
+		message.Status = models.DeliveryDelivered
+		message.Error = ""
+		message.SentAt = &now
+		message.NextAttemptAt = nil
+	case message.Attempts >= emailMaxAttempts:
+		message.Status = models.DeliveryFailed
+		message.Error = err.Error()
+		message.NextAttemptAt = nil
+	default:
+		next := now.Add(emailBackoff << (message.Attempts - 1))
+		message.Error = err.Error()
+		message.NextAttemptAt = &next
+	}
+	if err != nil {
+		log.Printf("email: message %d attempt %d: %v", message.ID, message.Attempts, err)
+	}
+	s.db.Save(message)
+}
+
+// send delivers a message over SMTP
+func (s *EmailService) send(message *models.EmailMessage) error {
+	to := strings.Split(message.To, ",")
+	body, err := buildMessage(s.smtp.From, to, message)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(s.smtp.Host, strconv.Itoa(s.smtp.Port))
+	dialer := &net.Dialer{Timeout: emailTimeout}
+	var conn net.Conn
+	if s.smtp.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: s.smtp.Host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(emailTimeout))
+
+	client, err := smtp.NewClient(conn, s.smtp.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && !s.smtp.TLS {
+		if err := client.StartTLS(&tls.Config{ServerName: s.smtp.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if s.smtp.Username != "" {
+		auth := smtp.PlainAuth("", s.smtp.Username, s.smtp.Password, s.smtp.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+	if err := client.Mail(s.smtp.From); err != nil {
+		return fmt.Errorf("sender rejected: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMessage renders a message as a multipart/alternative MIME email with
+// text and HTML parts
+func buildMessage(from string, to []string, message *models.EmailMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", from)
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	header("Date", time.Now().UTC().Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<compareflow-%d-%d@%s>", message.ID, message.Attempts, domainOf(from)))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build message: %w", err)
+		}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, fmt.Errorf("failed to build message: %w", err)
+		}
+		qp.Close()
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to build message: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// domainOf returns the domain of an address, for message IDs
+func domainOf(address string) string {
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return strings.Trim(address[i+1:], "> ")
+	}
+	return "compareflow"
+}
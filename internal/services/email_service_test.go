@@ -0,0 +1,172 @@
+package services
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/config"
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+func TestEmailService_RunAlert(t *testing.T) {
+	s := NewEmailService(nil, config.SMTPConfig{Host: "localhost"}, "https://cf.example.com/")
+	result := &validation.Result{
+		Status:     validation.StatusFailure,
+		DurationMS: 1500,
+		Summary:    validation.Summary{SourceRowCount: 100, TargetRowCount: 99, MatchedRows: 97, MismatchedRows: 2, MissingInTarget: 1},
+	}
+	for i := 0; i < 12; i++ {
+		result.Details.Differences = append(result.Details.Differences, validation.Difference{
+			Type:    "mismatch",
+			Key:     map[string]interface{}{"region": "<eu>", "id": i},
+			Columns: []string{"amount"},
+		})
+	}
+
+	msg, err := s.runAlert(Event{
+		Type:       models.EventRunFailed,
+		Validation: &models.Validation{ID: 3, Name: "orders"},
+		Run:        &models.ValidationRun{ID: 42, Trigger: models.RunTriggerManual},
+		Result:     result,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "[CompareFlow] orders failed: 3 rows differ"; msg.Subject != want {
+		t.Errorf("Subject = %q, want %q", msg.Subject, want)
+	}
+	for _, want := range []string{
+		"Source rows:        100",
+		"Mismatched rows:    2",
+		"  - mismatch id=0, region=<eu> (amount)",
+		"... and 2 more",
+		"View the run: https://cf.example.com/validations/3/runs/42",
+	} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Text does not contain %q:\n%s", want, msg.Text)
+		}
+	}
+	for _, want := range []string{
+		"id=0, region=&lt;eu&gt;",
+		`<a href="https://cf.example.com/validations/3/runs/42">`,
+	} {
+		if !strings.Contains(msg.HTML, want) {
+			t.Errorf("HTML does not contain %q:\n%s", want, msg.HTML)
+		}
+	}
+}
+
+// smtpSink accepts mail like a local SMTP sink, rejecting recipients in reject
+type smtpSink struct {
+	listener net.Listener
+	reject   string
+	messages chan string
+}
+
+func newSMTPSink(t *testing.T, reject string) *smtpSink {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &smtpSink{listener: l, reject: reject, messages: make(chan string, 10)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return sink
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 sink ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(cmd, "RCPT") && s.reject != "" && strings.Contains(line, s.reject):
+			reply("550 no such user")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.messages <- data.String()
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *smtpSink) config() config.SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return config.SMTPConfig{Host: host, Port: p, From: "compareflow@example.com"}
+}
+
+func TestEmailService_Send(t *testing.T) {
+	sink := newSMTPSink(t, "gone@")
+	s := NewEmailService(nil, sink.config(), "")
+	message := &models.EmailMessage{
+		ID:      1,
+		To:      "ops@example.com,dba@example.com",
+		Subject: "[CompareFlow] orders failed: 3 rows differ",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+	}
+
+	if err := s.send(message); err != nil {
+		t.Fatalf("send() error = %v", err)
+	}
+	select {
+	case data := <-sink.messages:
+		for _, want := range []string{
+			"To: ops@example.com, dba@example.com",
+			"Subject: [CompareFlow] orders failed: 3 rows differ",
+			"Content-Type: multipart/alternative",
+			"plain body",
+			"<p>html body</p>",
+		} {
+			if !strings.Contains(data, want) {
+				t.Errorf("message does not contain %q:\n%s", want, data)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sink received no message")
+	}
+
+	message.To = "gone@example.com"
+	if err := s.send(message); err == nil || !strings.Contains(err.Error(), "gone@example.com rejected") {
+		t.Errorf("send() error = %v, want the recipient rejected", err)
+	}
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+// emailSampleSize is how many differences an alert shows
+const emailSampleSize = 10
+
+// email is a rendered message
+type email struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// emailTemplate renders one kind of message from the same data as text and HTML
+type emailTemplate struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+func newEmailTemplate(name, text, html string) emailTemplate {
+	return emailTemplate{
+		text: texttemplate.Must(texttemplate.New(name).Parse(emailOutcome + text)),
+		html: htmltemplate.Must(htmltemplate.New(name).Parse(emailOutcome + emailLayout + html)),
+	}
+}
+
+func (t emailTemplate) render(subject string, data interface{}) (email, error) {
+	var text, html bytes.Buffer
+	if err := t.text.Execute(&text, data); err != nil {
+		return email{}, fmt.Errorf("failed to render text: %w", err)
+	}
+	if err := t.html.Execute(&html, data); err != nil {
+		return email{}, fmt.Errorf("failed to render HTML: %w", err)
+	}
+	return email{Subject: subject, Text: text.String(), HTML: html.String()}, nil
+}
+
+// runAlertData is rendered by runAlertTemplate
+type runAlertData struct {
+	Name        string
+	Result      string // failure or error
+	RunID       uint
+	Trigger     models.RunTrigger
+	StartedAt   time.Time
+	Duration    time.Duration
+	Variables   map[string]string
+	Summary     validation.Summary
+	Differences []differenceSample
+	// More counts differences not shown; Truncated means the run stopped
+	// collecting them
+	More      int
+	Truncated bool
+	Errors    []string
+	// Incident describes a reminder or recovery
+	Incident string
+	Link     string
+}
+
+// differenceSample is one difference of an alert
+type differenceSample struct {
+	Type    string
+	Key     string
+	Columns string
+}
+
+// runAlert renders the alert for a finished run
+func (s *EmailService) runAlert(event Event) (email, error) {
+	v, run, result := event.Validation, event.Run, event.Result
+	data := runAlertData{
+		Name:      v.Name,
+		RunID:     run.ID,
+		Trigger:   run.Trigger,
+		StartedAt: run.StartedAt,
+		Variables: run.Variables,
+		Incident:  incidentSummary(event),
+		Link:      s.publicURL + runPath(v.ID, run.ID),
+	}
+	if result != nil {
+		data.Result = result.Status
+		data.Duration = time.Duration(result.DurationMS) * time.Millisecond
+		data.Summary = result.Summary
+		data.Truncated = result.Details.Truncated
+		for i, d := range result.Details.Differences {
+			if i == emailSampleSize {
+				data.More = len(result.Details.Differences) - i
+				break
+			}
+			data.Differences = append(data.Differences, differenceSample{
+				Type:    d.Type,
+				Key:     formatKey(d.Key),
+				Columns: strings.Join(d.Columns, ", "),
+			})
+		}
+		for _, e := range result.Errors {
+			data.Errors = append(data.Errors, e.Message)
+		}
+	}
+
+	var subject string
+	switch {
+	case event.Transition == models.IncidentRecovered:
+		subject = fmt.Sprintf("[CompareFlow] %s recovered", v.Name)
+	case data.Result == validation.StatusSuccess:
+		subject = fmt.Sprintf("[CompareFlow] %s passed", v.Name)
+	case data.Result == validation.StatusError:
+		subject = fmt.Sprintf("[CompareFlow] %s errored", v.Name)
+	default:
+		subject = fmt.Sprintf("[CompareFlow] %s failed: %d rows differ", v.Name, differingRows(data.Summary))
+	}
+	return runAlertTemplate.render(subject, data)
+}
+
+// suiteAlertData is rendered by suiteAlertTemplate
+type suiteAlertData struct {
+	Name      string
+	Passed    bool
+	RunID     uint
+	StartedAt time.Time
+	Summary   models.SuiteSummary
+	Members   []suiteMemberSample // members that did not pass
+	Link      string
+}
+
+type suiteMemberSample struct {
+	Name   string
+	Result string
+	Error  string
+	Link   string
+}
+
+// suiteAlert renders the alert for a finished suite run
+func (s *EmailService) suiteAlert(event Event) (email, error) {
+	suite, run := event.Suite, event.SuiteRun
+	data := suiteAlertData{
+		Name:      suite.Name,
+		Passed:    run.Status == models.ValidationStatusCompleted,
+		RunID:     run.ID,
+		StartedAt: run.StartedAt,
+		Summary:   run.Summary,
+		Link:      s.publicURL + suiteRunPath(suite.ID, run.ID),
+	}
+	for _, m := range run.Members {
+		if m.Status == models.ValidationStatusCompleted {
+			continue
+		}
+		sample := suiteMemberSample{Name: m.Name, Result: m.Result, Error: m.Error}
+		if m.RunID != nil {
+			sample.Link = s.publicURL + runPath(m.ValidationID, *m.RunID)
+		}
+		data.Members = append(data.Members, sample)
+	}
+
+	subject := fmt.Sprintf("[CompareFlow] Suite %s failed: %d of %d validations did not pass",
+		suite.Name, data.Summary.Total-data.Summary.Passed, data.Summary.Total)
+	if run.Status == models.ValidationStatusCompleted {
+		subject = fmt.Sprintf("[CompareFlow] Suite %s passed", suite.Name)
+	}
+	return suiteAlertTemplate.render(subject, data)
+}
+
+// digestData is rendered by digestTemplate
+type digestData struct {
+	Until time.Time
+	Rows  []digestRow
+	Link  string
+}
+
+// digestRow summarizes a day of runs of one validation or suite
+type digestRow struct {
+	Kind   string // validation or suite
+	Name   string
+	Runs   int
+	Passed int
+	Failed int
+	Status models.ValidationStatus // of the latest run
+	Link   string
+}
+
+// count adds a run with status to the row; runs come oldest first
+func (r *digestRow) count(status models.ValidationStatus) {
+	r.Runs++
+	switch status {
+	case models.ValidationStatusCompleted:
+		r.Passed++
+	case models.ValidationStatusFailed:
+		r.Failed++
+	}
+	r.Status = status
+}
+
+// link returns an absolute URL of a web UI page
+func (s *EmailService) link(format string, args ...interface{}) string {
+	return s.publicURL + fmt.Sprintf(format, args...)
+}
+
+// differingRows counts the rows that differ between source and target
+func differingRows(summary validation.Summary) int64 {
+	return summary.MismatchedRows + summary.MissingInTarget + summary.ExtraInTarget + summary.DuplicateRows
+}
+
+// formatKey formats a row key as sorted column=value pairs
+func formatKey(key map[string]interface{}) string {
+	columns := make([]string, 0, len(key))
+	for column := range key {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	pairs := make([]string, len(columns))
+	for i, column := range columns {
+		pairs[i] = fmt.Sprintf("%s=%v", column, key[column])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// emailOutcome describes the result of a run
+const emailOutcome = `{{define "outcome"}}{{if eq .Result "success"}}passed{{else if eq .Result "error"}}could not complete{{else}}found differences{{end}}{{end}}`
+
+const emailLayout = `{{define "style"}}font-family:Arial,Helvetica,sans-serif;font-size:14px;color:#1f2937{{end}}` +
+	`{{define "cell"}}padding:4px 8px;border:1px solid #e5e7eb;text-align:left{{end}}`
+
+var runAlertTemplate = newEmailTemplate("run_alert", `Validation "{{.Name}}" {{template "outcome" .}}.
+{{- if .Incident}}
+{{.Incident}}.
+{{- end}}
+
+Run:       #{{.RunID}} ({{.Trigger}})
+Started:   {{.StartedAt.Format "2006-01-02 15:04:05 MST"}}
+Duration:  {{.Duration}}
+{{- range $name, $value := .Variables}}
+{{$name}}: {{$value}}
+{{- end}}
+{{if .Errors}}
+Errors:
+{{- range .Errors}}
+  - {{.}}
+{{- end}}
+{{end}}
+Source rows:        {{.Summary.SourceRowCount}}
+Target rows:        {{.Summary.TargetRowCount}}
+Matched rows:       {{.Summary.MatchedRows}}
+Mismatched rows:    {{.Summary.MismatchedRows}}
+Missing in target:  {{.Summary.MissingInTarget}}
+Extra in target:    {{.Summary.ExtraInTarget}}
+{{- if .Summary.DuplicateRows}}
+Duplicate keys:     {{.Summary.DuplicateRows}}
+{{- end}}
+{{if .Differences}}
+Sample differences:
+{{- range .Differences}}
+  - {{.Type}} {{.Key}}{{if .Columns}} ({{.Columns}}){{end}}
+{{- end}}
+{{- if .More}}
+  ... and {{.More}} more{{if .Truncated}} (collection stopped at the run's limit){{end}}
+{{- end}}
+{{end}}
+View the run: {{.Link}}
+`, `<div style="{{template "style"}}">
+<p>Validation <strong>{{.Name}}</strong> {{template "outcome" .}}.{{if .Incident}}<br>{{.Incident}}.{{end}}</p>
+<p>Run #{{.RunID}} ({{.Trigger}}), started {{.StartedAt.Format "2006-01-02 15:04:05 MST"}}, took {{.Duration}}.
+{{- range $name, $value := .Variables}}<br>{{$name}}: {{$value}}{{end}}</p>
+{{- if .Errors}}
+<ul>{{range .Errors}}<li style="color:#b91c1c">{{.}}</li>{{end}}</ul>
+{{- end}}
+<table style="border-collapse:collapse">
+<tr><th style="{{template "cell"}}">Source rows</th><td style="{{template "cell"}}">{{.Summary.SourceRowCount}}</td></tr>
+<tr><th style="{{template "cell"}}">Target rows</th><td style="{{template "cell"}}">{{.Summary.TargetRowCount}}</td></tr>
+<tr><th style="{{template "cell"}}">Matched rows</th><td style="{{template "cell"}}">{{.Summary.MatchedRows}}</td></tr>
+<tr><th style="{{template "cell"}}">Mismatched rows</th><td style="{{template "cell"}}">{{.Summary.MismatchedRows}}</td></tr>
+<tr><th style="{{template "cell"}}">Missing in target</th><td style="{{template "cell"}}">{{.Summary.MissingInTarget}}</td></tr>
+<tr><th style="{{template "cell"}}">Extra in target</th><td style="{{template "cell"}}">{{.Summary.ExtraInTarget}}</td></tr>
+{{- if .Summary.DuplicateRows}}
+<tr><th style="{{template "cell"}}">Duplicate keys</th><td style="{{template "cell"}}">{{.Summary.DuplicateRows}}</td></tr>
+{{- end}}
+</table>
+{{- if .Differences}}
+<p>Sample differences:</p>
+<table style="border-collapse:collapse">
+<tr><th style="{{template "cell"}}">Type</th><th style="{{template "cell"}}">Key</th><th style="{{template "cell"}}">Columns</th></tr>
+{{- range .Differences}}
+<tr><td style="{{template "cell"}}">{{.Type}}</td><td style="{{template "cell"}}">{{.Key}}</td><td style="{{template "cell"}}">{{.Columns}}</td></tr>
+{{- end}}
+</table>
+{{- if .More}}<p>... and {{.More}} more{{if .Truncated}} (collection stopped at the run's limit){{end}}</p>{{end}}
+{{- end}}
+<p><a href="{{.Link}}">View the run</a></p>
+</div>
+`)
+
+var suiteAlertTemplate = newEmailTemplate("suite_alert", `Suite "{{.Name}}" {{if .Passed}}passed{{else}}failed{{end}}.
+
+Run:      #{{.RunID}}
+Started:  {{.StartedAt.Format "2006-01-02 15:04:05 MST"}}
+Passed {{.Summary.Passed}}, failed {{.Summary.Failed}}, errored {{.Summary.Errored}} of {{.Summary.Total}} validations.
+{{if .Members}}
+Did not pass:
+{{- range .Members}}
+  - {{.Name}}: {{.Result}}{{if .Error}} - {{.Error}}{{end}}{{if .Link}}
+    {{.Link}}{{end}}
+{{- end}}
+{{end}}
+View the suite run: {{.Link}}
+`, `<div style="{{template "style"}}">
+<p>Suite <strong>{{.Name}}</strong> {{if .Passed}}passed{{else}}failed{{end}}.</p>
+<p>Run #{{.RunID}}, started {{.StartedAt.Format "2006-01-02 15:04:05 MST"}}.<br>
+Passed {{.Summary.Passed}}, failed {{.Summary.Failed}}, errored {{.Summary.Errored}} of {{.Summary.Total}} validations.</p>
+{{- if .Members}}
+<table style="border-collapse:collapse">
+<tr><th style="{{template "cell"}}">Validation</th><th style="{{template "cell"}}">Result</th><th style="{{template "cell"}}">Error</th></tr>
+{{- range .Members}}
+<tr><td style="{{template "cell"}}">{{if .Link}}<a href="{{.Link}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</td><td style="{{template "cell"}}">{{.Result}}</td><td style="{{template "cell"}}">{{.Error}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<p><a href="{{.Link}}">View the suite run</a></p>
+</div>
+`)
+
+var digestTemplate = newEmailTemplate("digest", `CompareFlow runs in the 24 hours to {{.Until.Format "2006-01-02 15:04 MST"}}
+{{range .Rows}}
+{{.Name}} ({{.Kind}}): {{.Runs}} runs, {{.Passed}} passed, {{.Failed}} failed{{if .Runs}}, latest {{.Status}}{{end}}
+  {{.Link}}
+{{- end}}
+
+Open CompareFlow: {{.Link}}
+`, `<div style="{{template "style"}}">
+<p>CompareFlow runs in the 24 hours to {{.Until.Format "2006-01-02 15:04 MST"}}</p>
+<table style="border-collapse:collapse">
+<tr><th style="{{template "cell"}}">Name</th><th style="{{template "cell"}}">Runs</th><th style="{{template "cell"}}">Passed</th><th style="{{template "cell"}}">Failed</th><th style="{{template "cell"}}">Latest</th></tr>
+{{- range .Rows}}
+<tr><td style="{{template "cell"}}"><a href="{{.Link}}">{{.Name}}</a> ({{.Kind}})</td><td style="{{template "cell"}}">{{.Runs}}</td><td style="{{template "cell"}}">{{.Passed}}</td><td style="{{template "cell"}}">{{.Failed}}</td><td style="{{template "cell"}}">{{if .Runs}}{{.Status}}{{end}}</td></tr>
+{{- end}}
+</table>
+<p><a href="{{.Link}}">Open CompareFlow</a></p>
+</div>
+`)
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+// Event is a run, suite or schedule occurrence notifications subscribe to
+type Event struct {
+	Type       string // one of the models.Event values
+	Time       time.Time
+	UserID     uint
+	Validation *models.Validation
+	Run        *models.ValidationRun
+	Result     *validation.Result
+	Suite      *models.Suite
+	SuiteRun   *models.SuiteRun
+	Schedule   *models.Schedule
+	Missed     int // ticks a schedule skipped
+	// Previous is the status of the suite run before this one, empty for the
+	// first
+	Previous models.ValidationStatus
+	// Incident is the incident of the run's validation, if the run opened,
+	// continued or resolved one, and Transition what the run did to it
+	Incident   *models.Incident
+	Transition models.IncidentTransition
+}
+
+// Announced reports whether the notification settings of the event's
+// validation or suite announce it. Failures of an acknowledged incident are
+// never announced, and under status_change a validation's runs are announced
+// when they open, remind of or resolve an incident. Events of other kinds are
+// always announced.
+func (e Event) Announced() bool {
+	switch {
+	case e.Run != nil && e.Validation != nil:
+		if e.Incident != nil && e.Incident.Status == models.IncidentAcknowledged {
+			return false
+		}
+		if e.Validation.Notifications.Mode == models.NotifyStatusChange {
+			return e.Transition != ""
+		}
+		return e.Validation.Notifications.Announces(e.Run.Status, "")
+	case e.SuiteRun != nil && e.Suite != nil:
+		return e.Suite.Notifications.Announces(e.SuiteRun.Status, e.Previous)
+	}
+	return true
+}
+
+// EventListener receives events. It is called on the goroutine that ran the
+// validation and must not block.
+type EventListener func(Event)
+
+// Subscribe registers a listener for the events of every run
+func (s *RunService) Subscribe(listener EventListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *RunService) emit(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	for _, listener := range s.listeners {
+		listener(event)
+	}
+}
+
+// runPath is the web UI path of a validation run
+func runPath(validationID, runID uint) string {
+	return fmt.Sprintf("/validations/%d/runs/%d", validationID, runID)
+}
+
+// suiteRunPath is the web UI path of a suite run
+func suiteRunPath(suiteID, runID uint) string {
+	return fmt.Sprintf("/suites/%d/runs/%d", suiteID, runID)
+}
+
+// runEvents returns the events a finished run raises
+func runEvents(result *validation.Result) []string {
+	if result.Passed() {
+		return []string{models.EventRunCompleted}
+	}
+	if result.Status == validation.StatusFailure {
+		return []string{models.EventRunFailed, models.EventRunThresholdBreached}
+	}
+	return []string{models.EventRunFailed}
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+const (
+	// heartbeatInterval is how often the runs in progress are marked alive
+	// and abandoned runs are looked for
+	heartbeatInterval = 30 * time.Second
+	// abandonedAfter is how long a run may go without a heartbeat before it
+	// is taken as abandoned by an instance that stopped
+	abandonedAfter = 3 * heartbeatInterval
+)
+
+// validationRunsTable names the table of models.ValidationRun in liveRuns
+const validationRunsTable = "validation_runs"
+
+// errAbandoned fails the runs an instance stopped without finishing
+var errAbandoned = errors.New("run interrupted: the server running it stopped")
+
+// activeStatuses are the statuses of runs that have not finished
+var activeStatuses = []models.ValidationStatus{models.ValidationStatusRunning, models.ValidationStatusWaiting}
+
+// Executor runs validations, pipelines and suites in the background so that
+// requests return as soon as a run is recorded. While started it keeps the
+// runs in progress on this instance alive with heartbeats, and fails the
+// runs whose instance stopped without finishing them, including those of a
+// previous process of this instance.
+type Executor struct {
+	db      *gorm.DB
+	runs    *RunService
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewExecutor creates a new executor instance
+func NewExecutor(db *gorm.DB, runs *RunService) *Executor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Executor{db: db, runs: runs, ctx: ctx, cancel: cancel}
+}
+
+// Go runs fn in the background. Its context is cancelled by Stop, which
+// waits for fn to return.
+func (e *Executor) Go(fn func(ctx context.Context)) {
+	e.mu.Lock()
+	if e.stopped {
+		e.mu.Unlock()
+		fn(e.ctx) // records the run as cancelled
+		return
+	}
+	e.wg.Add(1)
+	e.mu.Unlock()
+
+	go func() {
+		defer e.wg.Done()
+		fn(e.ctx)
+	}()
+}
+
+// Start sends heartbeats and fails abandoned runs in the background until
+// Stop is called
+func (e *Executor) Start() {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			now := time.Now().UTC()
+			e.heartbeat(now)
+			e.recover(now.Add(-abandonedAfter))
+			select {
+			case <-ticker.C:
+			case <-e.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop cancels the runs in progress and waits for them to be recorded
+func (e *Executor) Stop() {
+	e.mu.Lock()
+	e.stopped = true
+	e.mu.Unlock()
+	e.cancel()
+	e.wg.Wait()
+}
+
+// heartbeat marks the runs in progress on this instance alive
+func (e *Executor) heartbeat(now time.Time) {
+	for table, ids := range e.runs.live.snapshot() {
+		if err := e.db.Table(table).Where("id IN ?", ids).Update("heartbeat_at", now).Error; err != nil {
+			log.Printf("executor: failed to record heartbeats in %s: %v", table, err)
+		}
+	}
+}
+
+// abandoned selects the runs without a heartbeat since a cutoff
+const abandoned = "status IN ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)"
+
+// recover fails the runs without a heartbeat since cutoff
+func (e *Executor) recover(cutoff time.Time) {
+	var runs []models.ValidationRun
+	if err := e.db.Where(abandoned, activeStatuses, cutoff).Find(&runs).Error; err != nil {
+		log.Printf("executor: failed to fetch abandoned runs: %v", err)
+	}
+	for i := range runs {
+		if err := e.runs.abandon(&runs[i], cutoff); err != nil {
+			log.Printf("executor: run %d: %v", runs[i].ID, err)
+		}
+	}
+
+	var pipelineRuns []models.PipelineRun
+	if err := e.db.Where(abandoned, activeStatuses, cutoff).Find(&pipelineRuns).Error; err != nil {
+		log.Printf("executor: failed to fetch abandoned pipeline runs: %v", err)
+	}
+	for i := range pipelineRuns {
+		if err := e.abandonPipelineRun(&pipelineRuns[i], cutoff); err != nil {
+			log.Printf("executor: pipeline run %d: %v", pipelineRuns[i].ID, err)
+		}
+	}
+
+	var suiteRuns []models.SuiteRun
+	if err := e.db.Where(abandoned, activeStatuses, cutoff).Find(&suiteRuns).Error; err != nil {
+		log.Printf("executor: failed to fetch abandoned suite runs: %v", err)
+	}
+	suites := NewSuiteService(e.db, e.runs)
+	for i := range suiteRuns {
+		if err := suites.abandon(&suiteRuns[i], cutoff); err != nil {
+			log.Printf("executor: suite run %d: %v", suiteRuns[i].ID, err)
+		}
+	}
+}
+
+// claim marks an abandoned run as handled, reporting false if it was not
+// abandoned or another instance claimed it first
+func claim(db *gorm.DB, table string, id uint, cutoff time.Time) (bool, error) {
+	result := db.Table(table).Where("id = ? AND "+abandoned, id, activeStatuses, cutoff).
+		Update("heartbeat_at", time.Now().UTC())
+	return result.RowsAffected > 0, result.Error
+}
+
+// abandon fails a run left unfinished by an instance that stopped
+func (s *RunService) abandon(run *models.ValidationRun, cutoff time.Time) error {
+	if claimed, err := claim(s.db, validationRunsTable, run.ID, cutoff); !claimed {
+		return err
+	}
+
+	var v models.Validation
+	if err := s.db.First(&v, run.ValidationID).Error; err != nil {
+		return err
+	}
+	result := validation.ErrorResult(errAbandoned)
+	run.Attempts = append(run.Attempts, newAttempt(len(run.Attempts)+1, result))
+	s.finish(&v, run, result)
+	return nil
+}
+
+// abandonPipelineRun fails a pipeline run left unfinished by an instance
+// that stopped, along with its nodes that had not finished
+func (e *Executor) abandonPipelineRun(run *models.PipelineRun, cutoff time.Time) error {
+	if claimed, err := claim(e.db, pipelineRunsTable, run.ID, cutoff); !claimed {
+		return err
+	}
+
+	finished := time.Now().UTC()
+	for i, node := range run.Nodes {
+		if node.Status == models.ValidationStatusPending || node.Status == models.ValidationStatusRunning {
+			run.Nodes[i].Status = models.ValidationStatusFailed
+			run.Nodes[i].Reason = errAbandoned.Error()
+			run.Nodes[i].FinishedAt = &finished
+		}
+	}
+	run.Status = models.ValidationStatusFailed
+	run.FinishedAt = &finished
+	if err := e.db.Save(run).Error; err != nil {
+		return err
+	}
+	return e.db.Model(&models.Pipeline{}).Where("id = ?", run.PipelineID).Update("status", run.Status).Error
+}
+
+// liveRuns are the runs in progress on this instance, by table
+type liveRuns struct {
+	mu  sync.Mutex
+	ids map[string]map[uint]struct{}
+}
+
+func (l *liveRuns) add(table string, id uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ids == nil {
+		l.ids = make(map[string]map[uint]struct{})
+	}
+	if l.ids[table] == nil {
+		l.ids[table] = make(map[uint]struct{})
+	}
+	l.ids[table][id] = struct{}{}
+}
+
+func (l *liveRuns) remove(table string, id uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.ids[table], id)
+}
+
+func (l *liveRuns) snapshot() map[string][]uint {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	snapshot := make(map[string][]uint, len(l.ids))
+	for table, ids := range l.ids {
+		for id := range ids {
+			snapshot[table] = append(snapshot[table], id)
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+func TestExecutor_Recover(t *testing.T) {
+	db := openTestDB(t, "executor_recover", &models.Validation{}, &models.ValidationRun{}, &models.Incident{}, &models.PipelineRun{}, &models.SuiteRun{})
+	runs := NewRunService(db)
+	e := NewExecutor(db, runs)
+
+	v := models.Validation{Name: "orders", Status: models.ValidationStatusRunning}
+	db.Create(&v)
+
+	now := time.Now().UTC()
+	stale := now.Add(-10 * time.Minute)
+	fresh := now.Add(-time.Minute)
+	finished := stale
+	records := []models.ValidationRun{
+		{ValidationID: v.ID, Status: models.ValidationStatusRunning, StartedAt: stale, HeartbeatAt: &stale},
+		{ValidationID: v.ID, Status: models.ValidationStatusWaiting, StartedAt: stale}, // before heartbeats were kept
+		{ValidationID: v.ID, Status: models.ValidationStatusRunning, StartedAt: stale, HeartbeatAt: &fresh},
+		{ValidationID: v.ID, Status: models.ValidationStatusCompleted, StartedAt: stale, FinishedAt: &finished},
+	}
+	for i := range records {
+		db.Create(&records[i])
+	}
+
+	e.recover(now.Add(-abandonedAfter))
+
+	want := []models.ValidationStatus{
+		models.ValidationStatusFailed,
+		models.ValidationStatusFailed,
+		models.ValidationStatusRunning,
+		models.ValidationStatusCompleted,
+	}
+	for i, record := range records {
+		var run models.ValidationRun
+		db.First(&run, record.ID)
+		if run.Status != want[i] {
+			t.Errorf("run %d: status = %q, want %q", run.ID, run.Status, want[i])
+		}
+		if want[i] == models.ValidationStatusFailed {
+			if run.FinishedAt == nil || len(run.Attempts) != 1 || run.Attempts[0].Error != errAbandoned.Error() {
+				t.Errorf("run %d: finished at %v with attempts %+v, want one abandoned attempt", run.ID, run.FinishedAt, run.Attempts)
+			}
+		}
+	}
+
+	// Recovering again finds nothing left to fail
+	e.recover(now.Add(-abandonedAfter))
+	var incident models.Incident
+	db.Where("validation_id = ?", v.ID).First(&incident)
+	if incident.FailedRuns != 2 {
+		t.Errorf("incident failed runs = %d, want 2", incident.FailedRuns)
+	}
+}
+
+func TestExecutor_RecoverPipelineRuns(t *testing.T) {
+	db := openTestDB(t, "executor_recover_pipelines", &models.ValidationRun{}, &models.Pipeline{}, &models.PipelineRun{}, &models.SuiteRun{})
+	e := NewExecutor(db, NewRunService(db))
+
+	pipeline := models.Pipeline{Name: "orders", Status: models.ValidationStatusRunning}
+	db.Create(&pipeline)
+
+	now := time.Now().UTC()
+	stale := now.Add(-10 * time.Minute)
+	fresh := now.Add(-time.Minute)
+	nodes := func() models.PipelineNodeRuns {
+		return models.PipelineNodeRuns{
+			{Key: "counts", ValidationID: 1, Status: models.ValidationStatusCompleted},
+			{Key: "match", ValidationID: 2, Status: models.ValidationStatusRunning},
+			{Key: "detail", ValidationID: 3, Status: models.ValidationStatusPending},
+		}
+	}
+	abandonedRun := models.PipelineRun{PipelineID: pipeline.ID, Status: models.ValidationStatusRunning, Nodes: nodes(), StartedAt: stale, HeartbeatAt: &stale}
+	liveRun := models.PipelineRun{PipelineID: pipeline.ID, Status: models.ValidationStatusRunning, Nodes: nodes(), StartedAt: stale, HeartbeatAt: &fresh}
+	db.Create(&abandonedRun)
+	db.Create(&liveRun)
+
+	e.recover(now.Add(-abandonedAfter))
+
+	var run models.PipelineRun
+	db.First(&run, abandonedRun.ID)
+	if run.Status != models.ValidationStatusFailed || run.FinishedAt == nil {
+		t.Errorf("abandoned run: status = %q, finished at %v, want failed and finished", run.Status, run.FinishedAt)
+	}
+	want := []models.ValidationStatus{models.ValidationStatusCompleted, models.ValidationStatusFailed, models.ValidationStatusFailed}
+	for i, node := range run.Nodes {
+		if node.Status != want[i] {
+			t.Errorf("node %s: status = %q, want %q", node.Key, node.Status, want[i])
+		}
+	}
+	var live models.PipelineRun
+	db.First(&live, liveRun.ID)
+	if live.Status != models.ValidationStatusRunning {
+		t.Errorf("live run: status = %q, want running", live.Status)
+	}
+	db.First(&pipeline, pipeline.ID)
+	if pipeline.Status != models.ValidationStatusFailed {
+		t.Errorf("pipeline status = %q, want failed", pipeline.Status)
+	}
+}
+
+func TestExecutor_RecoverSuiteRuns(t *testing.T) {
+	db := openTestDB(t, "executor_recover_suites", &models.ValidationRun{}, &models.PipelineRun{}, &models.Suite{}, &models.SuiteRun{})
+	e := NewExecutor(db, NewRunService(db))
+
+	suite := models.Suite{Name: "nightly", Status: models.ValidationStatusRunning, ValidationIDs: models.SuiteMembers{1, 2}}
+	db.Create(&suite)
+
+	stale := time.Now().UTC().Add(-10 * time.Minute)
+	record := models.SuiteRun{SuiteID: suite.ID, Status: models.ValidationStatusRunning, StartedAt: stale, HeartbeatAt: &stale,
+		Members: models.SuiteMemberRuns{
+			{ValidationID: 1, Status: models.ValidationStatusCompleted, Result: validation.StatusSuccess},
+			{ValidationID: 2, Status: models.ValidationStatusPending},
+		}}
+	db.Create(&record)
+
+	e.recover(time.Now().UTC().Add(-abandonedAfter))
+
+	var run models.SuiteRun
+	db.First(&run, record.ID)
+	if run.Status != models.ValidationStatusFailed || run.FinishedAt == nil {
+		t.Errorf("suite run: status = %q, finished at %v, want failed and finished", run.Status, run.FinishedAt)
+	}
+	if want := (models.SuiteSummary{Total: 2, Passed: 1, Errored: 1}); run.Summary != want {
+		t.Errorf("summary = %+v, want %+v", run.Summary, want)
+	}
+	if m := run.Members[1]; m.Error != errAbandoned.Error() {
+		t.Errorf("member error = %q, want %q", m.Error, errAbandoned.Error())
+	}
+	db.First(&suite, suite.ID)
+	if suite.Status != models.ValidationStatusFailed {
+		t.Errorf("suite status = %q, want failed", suite.Status)
+	}
+}
+
+func TestExecutor_Heartbeat(t *testing.T) {
+	db := openTestDB(t, "executor_heartbeat", &models.ValidationRun{})
+	runs := NewRunService(db)
+	e := NewExecutor(db, runs)
+
+	stale := time.Now().UTC().Add(-10 * time.Minute)
+	live := models.ValidationRun{ValidationID: 1, Status: models.ValidationStatusRunning, HeartbeatAt: &stale}
+	other := models.ValidationRun{ValidationID: 1, Status: models.ValidationStatusRunning, HeartbeatAt: &stale}
+	db.Create(&live)
+	db.Create(&other)
+	runs.live.add(validationRunsTable, live.ID)
+
+	now := time.Now().UTC()
+	e.heartbeat(now)
+
+	for _, tt := range []struct {
+		id   uint
+		want time.Time
+	}{{live.ID, now}, {other.ID, stale}} {
+		var run models.ValidationRun
+		db.First(&run, tt.id)
+		if run.HeartbeatAt == nil || !run.HeartbeatAt.Equal(tt.want) {
+			t.Errorf("run %d: heartbeat = %v, want %v", tt.id, run.HeartbeatAt, tt.want)
+		}
+	}
+}
+
+func TestExecutor_Stop(t *testing.T) {
+	e := NewExecutor(nil, NewRunService(nil))
+
+	done := make(chan struct{})
+	e.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		close(done)
+	})
+	e.Stop()
+	select {
+	case <-done:
+	default:
+		t.Fatal("Stop() returned before the run did")
+	}
+
+	// A run started after Stop runs at once with a cancelled context
+	var err error
+	e.Go(func(ctx context.Context) {
+		err = ctx.Err()
+	})
+	if err != context.Canceled {
+		t.Errorf("context error after Stop = %v, want %v", err, context.Canceled)
+	}
+}
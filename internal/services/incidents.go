@@ -0,0 +1,124 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+)
+
+// trackIncident folds a finished run into the validation's incident, opening
+// one on the first failure and resolving it on the first pass. It returns the
+// incident the run belongs to, if any, and the transition to notify about,
+// empty when a failure continues without a reminder being due.
+func (s *RunService) trackIncident(v *models.Validation, run *models.ValidationRun) (*models.Incident, models.IncidentTransition) {
+	now := time.Now().UTC()
+	failed := run.Status == models.ValidationStatusFailed
+
+	for attempt := 1; ; attempt++ {
+		var incident models.Incident
+		err := s.db.Where("validation_id = ? AND status <> ?", v.ID, models.IncidentResolved).Order("id DESC").First(&incident).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			log.Printf("incidents: validation %d: failed to fetch incident: %v", v.ID, err)
+			return nil, ""
+		}
+		open := err == nil
+
+		// Only the fields a run changes are written, so an acknowledgement
+		// made meanwhile is kept
+		var transition models.IncidentTransition
+		var fields []string
+		switch {
+		case !open && !failed:
+			return nil, ""
+		case !open:
+			incident = models.Incident{
+				ValidationID: v.ID,
+				Status:       models.IncidentOpen,
+				FirstRunID:   run.ID,
+				LastRunID:    run.ID,
+				FailedRuns:   1,
+				NotifiedAt:   now,
+				NotifiedRuns: 1,
+				OpenedAt:     now,
+			}
+			err := s.db.Create(&incident).Error
+			if err == nil {
+				return &incident, models.IncidentOpened
+			}
+			if attempt == 1 {
+				// The unique index on unresolved incidents rejects a second
+				// one: a concurrent run opened it first, so count this run
+				// in that incident
+				continue
+			}
+			log.Printf("incidents: validation %d: failed to open incident: %v", v.ID, err)
+			return nil, ""
+		case !failed:
+			incident.Status = models.IncidentResolved
+			incident.ResolvedAt = &now
+			incident.ResolvedRunID = &run.ID
+			transition = models.IncidentRecovered
+			fields = []string{"status", "resolved_at", "resolved_run_id"}
+		default:
+			return s.countFailedRun(v, &incident, run, now)
+		}
+		if err := s.db.Model(&incident).Select(fields).Updates(&incident).Error; err != nil {
+			log.Printf("incidents: incident %d: failed to update: %v", incident.ID, err)
+		}
+		return &incident, transition
+	}
+}
+
+// countFailedRun adds a failed run to an open incident. The count is
+// incremented in the database and read back, so that concurrent runs are
+// all counted, and a reminder is claimed so that only one of them sends it.
+func (s *RunService) countFailedRun(v *models.Validation, incident *models.Incident, run *models.ValidationRun, now time.Time) (*models.Incident, models.IncidentTransition) {
+	err := s.db.Model(incident).Updates(map[string]interface{}{
+		"failed_runs": gorm.Expr("failed_runs + 1"),
+		"last_run_id": run.ID,
+	}).Error
+	if err == nil {
+		err = s.db.First(incident, incident.ID).Error
+	}
+	if err != nil {
+		log.Printf("incidents: incident %d: failed to update: %v", incident.ID, err)
+		return incident, ""
+	}
+
+	if !v.Notifications.ReminderDue(incident, now) {
+		return incident, ""
+	}
+	claim := s.db.Model(&models.Incident{}).
+		Where("id = ? AND notified_runs = ?", incident.ID, incident.NotifiedRuns).
+		Updates(map[string]interface{}{"notified_at": now, "notified_runs": incident.FailedRuns})
+	if claim.Error != nil {
+		log.Printf("incidents: incident %d: failed to record reminder: %v", incident.ID, claim.Error)
+		return incident, ""
+	}
+	if claim.RowsAffected == 0 {
+		return incident, "" // a concurrent run sent it
+	}
+	incident.NotifiedAt = now
+	incident.NotifiedRuns = incident.FailedRuns
+	return incident, models.IncidentReminder
+}
+
+// incidentSummary describes the incident of a run event for alerts, empty
+// when there is nothing to add
+func incidentSummary(event Event) string {
+	incident := event.Incident
+	if incident == nil {
+		return ""
+	}
+	switch event.Transition {
+	case models.IncidentRecovered:
+		return fmt.Sprintf("Recovered after %d failed runs since %s", incident.FailedRuns, incident.OpenedAt.Format("2006-01-02 15:04 MST"))
+	case models.IncidentReminder:
+		return fmt.Sprintf("Still failing: %d failed runs since %s", incident.FailedRuns, incident.OpenedAt.Format("2006-01-02 15:04 MST"))
+	}
+	return ""
+}
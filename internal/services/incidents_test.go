@@ -0,0 +1,123 @@
+package services
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+)
+
+func TestRunService_TrackIncident(t *testing.T) {
+	db := openTestDB(t, "incidents", &models.Incident{})
+
+	s := NewRunService(db)
+	v := &models.Validation{ID: 5, Name: "orders", Notifications: models.NotificationSettings{
+		Mode:            models.NotifyStatusChange,
+		RepeatAfterRuns: 3,
+	}}
+	failed, passed := models.ValidationStatusFailed, models.ValidationStatusCompleted
+
+	steps := []struct {
+		status      models.ValidationStatus
+		acknowledge bool // acknowledge the incident before the run
+		transition  models.IncidentTransition
+		announced   bool
+	}{
+		{passed, false, "", false},
+		{failed, false, models.IncidentOpened, true},
+		{failed, false, "", false},
+		{failed, false, "", false},
+		{failed, false, models.IncidentReminder, true},
+		{failed, true, "", false},
+		{failed, false, "", false},
+		{passed, false, models.IncidentRecovered, true},
+		{passed, false, "", false},
+	}
+
+	for i, step := range steps {
+		if step.acknowledge {
+			db.Model(&models.Incident{}).Where("validation_id = ? AND status = ?", v.ID, models.IncidentOpen).
+				Update("status", models.IncidentAcknowledged)
+		}
+		run := &models.ValidationRun{ID: uint(i + 1), ValidationID: v.ID, Status: step.status}
+		incident, transition := s.trackIncident(v, run)
+		if transition != step.transition {
+			t.Errorf("run %d: transition = %q, want %q", run.ID, transition, step.transition)
+		}
+		event := Event{Type: models.EventRunFailed, Validation: v, Run: run, Incident: incident, Transition: transition}
+		if got := event.Announced(); got != step.announced {
+			t.Errorf("run %d: Announced() = %v, want %v", run.ID, got, step.announced)
+		}
+	}
+
+	var incidents []models.Incident
+	db.Find(&incidents)
+	if len(incidents) != 1 {
+		t.Fatalf("got %d incidents, want 1", len(incidents))
+	}
+	incident := incidents[0]
+	if incident.Status != models.IncidentResolved || incident.FailedRuns != 6 ||
+		incident.FirstRunID != 2 || incident.LastRunID != 7 || incident.ResolvedRunID == nil || *incident.ResolvedRunID != 8 {
+		t.Errorf("incident = %+v, want resolved by run 8 after 6 failed runs from 2 to 7", incident)
+	}
+}
+
+func TestRunService_TrackIncidentConcurrentOpen(t *testing.T) {
+	db := openTestDB(t, "incidents_race", &models.Incident{})
+
+	// Another run of the validation opens the incident between this run's
+	// lookup and its insert
+	raced := false
+	if err := db.Callback().Create().Before("gorm:create").Register("test:race", func(*gorm.DB) {
+		if !raced {
+			raced = true
+			db.Exec("INSERT INTO incidents (validation_id, status, first_run_id, last_run_id, failed_runs) VALUES (5, 'open', 1, 1, 1)")
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewRunService(db)
+	v := &models.Validation{ID: 5, Name: "orders"}
+	incident, transition := s.trackIncident(v, &models.ValidationRun{ID: 2, ValidationID: v.ID, Status: models.ValidationStatusFailed})
+	if incident == nil || transition != "" || incident.FirstRunID != 1 || incident.FailedRuns != 2 {
+		t.Fatalf("trackIncident() = %+v, %q, want run 2 counted in the incident run 1 opened", incident, transition)
+	}
+
+	var count int64
+	db.Model(&models.Incident{}).Where("validation_id = ?", v.ID).Count(&count)
+	if count != 1 {
+		t.Errorf("got %d incidents, want 1", count)
+	}
+}
+
+func TestRunService_TrackIncidentConcurrentFailure(t *testing.T) {
+	db := openTestDB(t, "incidents_count_race", &models.Incident{})
+	db.Exec("INSERT INTO incidents (validation_id, status, first_run_id, last_run_id, failed_runs, notified_runs) VALUES (5, 'open', 1, 1, 1, 1)")
+
+	// Another failed run of the validation is counted between this run's
+	// lookup and its update
+	raced := false
+	if err := db.Callback().Update().Before("gorm:update").Register("test:race", func(*gorm.DB) {
+		if !raced {
+			raced = true
+			db.Exec("UPDATE incidents SET failed_runs = failed_runs + 1, last_run_id = 2 WHERE validation_id = 5")
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewRunService(db)
+	v := &models.Validation{ID: 5, Name: "orders"}
+	incident, _ := s.trackIncident(v, &models.ValidationRun{ID: 3, ValidationID: v.ID, Status: models.ValidationStatusFailed})
+	if incident == nil || incident.FailedRuns != 3 || incident.LastRunID != 3 {
+		t.Fatalf("trackIncident() = %+v, want runs 2 and 3 both counted", incident)
+	}
+
+	var stored models.Incident
+	db.First(&stored, incident.ID)
+	if stored.FailedRuns != 3 {
+		t.Errorf("stored failed runs = %d, want 3", stored.FailedRuns)
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import "sync"
+
+// connectionLimiter counts the queries runs hold open per connection on this
+// instance
+type connectionLimiter struct {
+	mu    sync.Mutex
+	inUse map[uint]int
+	// released is closed and replaced whenever slots are given back
+	released chan struct{}
+}
+
+func newConnectionLimiter() *connectionLimiter {
+	return &connectionLimiter{inUse: make(map[uint]int), released: make(chan struct{})}
+}
+
+// tryAcquire takes slots on every connection in needs, or none if any
+// connection would exceed its limit, returning that connection. A limit of
+// 0 is unlimited.
+func (l *connectionLimiter) tryAcquire(needs, limits map[uint]int) (uint, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if id := l.full(needs, limits); id != 0 {
+		return id, false
+	}
+	for id, n := range needs {
+		l.inUse[id] += n
+	}
+	return 0, true
+}
+
+// blocking returns a connection without room for needs, or 0 if all have room
+func (l *connectionLimiter) blocking(needs, limits map[uint]int) uint {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.full(needs, limits)
+}
+
+func (l *connectionLimiter) full(needs, limits map[uint]int) uint {
+	for id, n := range needs {
+		if limits[id] > 0 && l.inUse[id]+n > limits[id] {
+			return id
+		}
+	}
+	return 0
+}
+
+// release gives back slots taken by tryAcquire and wakes the waiting runs
+func (l *connectionLimiter) release(needs map[uint]int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, n := range needs {
+		if l.inUse[id] -= n; l.inUse[id] <= 0 {
+			delete(l.inUse, id)
+		}
+	}
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// changed returns a channel closed at the next release
+func (l *connectionLimiter) changed() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.released
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+)
+
+// PipelineService runs pipelines, starting each node once its upstream
+// nodes have finished
+type PipelineService struct {
+	db   *gorm.DB
+	runs *RunService
+}
+
+// NewPipelineService creates a new pipeline service instance
+func NewPipelineService(db *gorm.DB, runs *RunService) *PipelineService {
+	return &PipelineService{db: db, runs: runs}
+}
+
+// OrderPipeline checks a pipeline's nodes and returns them so that every
+// node follows the nodes it depends on. Missing edge conditions are set to
+// on_success.
+func OrderPipeline(nodes models.PipelineNodes) (models.PipelineNodes, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("pipeline must have at least one node")
+	}
+
+	index := make(map[string]int, len(nodes))
+	for i, node := range nodes {
+		if node.Key == "" {
+			return nil, fmt.Errorf("node %d: key is required", i+1)
+		}
+		if node.ValidationID == 0 {
+			return nil, fmt.Errorf("node %q: validation_id is required", node.Key)
+		}
+		if _, ok := index[node.Key]; ok {
+			return nil, fmt.Errorf("duplicate node %q", node.Key)
+		}
+		index[node.Key] = i
+	}
+
+	pending := make([]int, len(nodes))
+	downstream := make([][]int, len(nodes))
+	for i := range nodes {
+		for j := range nodes[i].DependsOn {
+			edge := &nodes[i].DependsOn[j]
+			if edge.Condition == "" {
+				edge.Condition = models.RunOnSuccess // Set default
+			}
+			switch edge.Condition {
+			case models.RunOnSuccess, models.RunOnFailure, models.RunAlways:
+			default:
+				return nil, fmt.Errorf("node %q: condition must be on_success, on_failure or always", nodes[i].Key)
+			}
+			upstream, ok := index[edge.Node]
+			if !ok {
+				return nil, fmt.Errorf("node %q depends on unknown node %q", nodes[i].Key, edge.Node)
+			}
+			pending[i]++
+			downstream[upstream] = append(downstream[upstream], i)
+		}
+	}
+
+	// Kahn's algorithm, keeping the declared order among ready nodes
+	ordered := make(models.PipelineNodes, 0, len(nodes))
+	var ready []int
+	for i := range nodes {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, nodes[i])
+		for _, d := range downstream[i] {
+			if pending[d]--; pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+	if len(ordered) != len(nodes) {
+		for i := range nodes {
+			if pending[i] > 0 {
+				return nil, fmt.Errorf("pipeline has a dependency cycle through node %q", nodes[i].Key)
+			}
+		}
+	}
+	return ordered, nil
+}
+
+// conditionHolds reports whether an edge lets its node run after the
+// upstream node finished with status
+func conditionHolds(condition string, status models.ValidationStatus) bool {
+	switch condition {
+	case models.RunAlways:
+		return true
+	case models.RunOnFailure:
+		return status == models.ValidationStatusFailed
+	default:
+		return status == models.ValidationStatusCompleted
+	}
+}
+
+// pipelineRunsTable names the table of models.PipelineRun in liveRuns
+const pipelineRunsTable = "pipeline_runs"
+
+// Start records a new run of the pipeline with every node pending
+func (s *PipelineService) Start(pipeline *models.Pipeline) (*models.PipelineRun, error) {
+	nodes, err := OrderPipeline(pipeline.Nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	run := &models.PipelineRun{
+		PipelineID:  pipeline.ID,
+		Status:      models.ValidationStatusRunning,
+		StartedAt:   now,
+		HeartbeatAt: &now,
+	}
+	for _, node := range nodes {
+		run.Nodes = append(run.Nodes, models.PipelineNodeRun{
+			Key:          node.Key,
+			ValidationID: node.ValidationID,
+			Status:       models.ValidationStatusPending,
+		})
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to record pipeline run: %w", err)
+	}
+	pipeline.Status = models.ValidationStatusRunning
+	s.db.Model(pipeline).Update("status", pipeline.Status)
+	return run, nil
+}
+
+// Execute runs the nodes of a started pipeline run, running independent
+// nodes concurrently. A node whose edge conditions do not all hold is
+// skipped, and a skipped node satisfies only always edges, so a failure
+// skips the checks below it. The pipeline run fails if any node fails.
+func (s *PipelineService) Execute(ctx context.Context, pipeline *models.Pipeline, run *models.PipelineRun) {
+	s.runs.live.add(pipelineRunsTable, run.ID)
+	defer s.runs.live.remove(pipelineRunsTable, run.ID)
+
+	// Start checked the pipeline, and its nodes are in run.Nodes' order
+	nodes, _ := OrderPipeline(pipeline.Nodes)
+
+	var mu sync.Mutex
+	done := make(map[string]chan struct{}, len(nodes))
+	for _, node := range nodes {
+		done[node.Key] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node models.PipelineNode) {
+			defer wg.Done()
+			defer close(done[node.Key])
+
+			for _, edge := range node.DependsOn {
+				<-done[edge.Node]
+			}
+
+			mu.Lock()
+			reason := ""
+			for _, edge := range node.DependsOn {
+				upstream := run.Nodes[nodeIndex(run.Nodes, edge.Node)]
+				if !conditionHolds(edge.Condition, upstream.Status) {
+					reason = fmt.Sprintf("%q %s, edge requires %s", edge.Node, upstream.Status, edge.Condition)
+					break
+				}
+			}
+			if reason != "" {
+				run.Nodes[i].Status = models.ValidationStatusSkipped
+				run.Nodes[i].Reason = reason
+				s.db.Model(run).Update("nodes", run.Nodes)
+				mu.Unlock()
+				return
+			}
+			started := time.Now().UTC()
+			run.Nodes[i].Status = models.ValidationStatusRunning
+			run.Nodes[i].StartedAt = &started
+			s.db.Model(run).Update("nodes", run.Nodes)
+			mu.Unlock()
+
+			status, runID, reason := s.runNode(ctx, node, run)
+
+			mu.Lock()
+			finished := time.Now().UTC()
+			run.Nodes[i].Status = status
+			run.Nodes[i].RunID = runID
+			run.Nodes[i].Reason = reason
+			run.Nodes[i].FinishedAt = &finished
+			s.db.Model(run).Update("nodes", run.Nodes)
+			mu.Unlock()
+		}(i, node)
+	}
+	wg.Wait()
+
+	run.Status = models.ValidationStatusCompleted
+	for _, node := range run.Nodes {
+		if node.Status == models.ValidationStatusFailed {
+			run.Status = models.ValidationStatusFailed
+		}
+	}
+	finished := time.Now().UTC()
+	run.FinishedAt = &finished
+	s.db.Save(run)
+
+	pipeline.Status = run.Status
+	s.db.Model(pipeline).Update("status", pipeline.Status)
+}
+
+// runNode runs a node's validation for the pipeline run's date, returning
+// its status, its run and why it could not start
+func (s *PipelineService) runNode(ctx context.Context, node models.PipelineNode, pipelineRun *models.PipelineRun) (models.ValidationStatus, *uint, string) {
+	var v models.Validation
+	if err := s.db.Preload("SourceConnection").Preload("TargetConnection").
+		First(&v, node.ValidationID).Error; err != nil {
+		return models.ValidationStatusFailed, nil, fmt.Sprintf("failed to fetch validation %d: %v", node.ValidationID, err)
+	}
+
+	req := RunRequest{
+		Trigger:       models.RunTriggerPipeline,
+		LogicalDate:   pipelineRun.StartedAt,
+		PipelineRunID: &pipelineRun.ID,
+	}
+	var run *models.ValidationRun
+	config, err := s.runs.Prepare(&v, req)
+	if err != nil {
+		run, err = s.runs.Fail(&v, req, err)
+	} else {
+		if run, err = s.runs.Start(&v, config, req); err == nil {
+			s.runs.Execute(ctx, &v, config, run)
+		}
+	}
+	if err != nil {
+		return models.ValidationStatusFailed, nil, err.Error()
+	}
+	return run.Status, &run.ID, ""
+}
+
+func nodeIndex(nodes models.PipelineNodeRuns, key string) int {
+	for i, node := range nodes {
+		if node.Key == key {
+			return i
+		}
+	}
+	return -1
+}
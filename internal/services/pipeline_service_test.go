@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/compareflow/compareflow/internal/models"
+)
+
+func TestOrderPipeline(t *testing.T) {
+	edge := func(node, condition string) []models.PipelineEdge {
+		return []models.PipelineEdge{{Node: node, Condition: condition}}
+	}
+
+	tests := []struct {
+		name    string
+		nodes   models.PipelineNodes
+		want    []string
+		wantErr bool
+	}{
+		{"dependencies first", models.PipelineNodes{
+			{Key: "facts", ValidationID: 3, DependsOn: edge("dims", "")},
+			{Key: "dims", ValidationID: 2, DependsOn: edge("counts", "")},
+			{Key: "counts", ValidationID: 1},
+		}, []string{"counts", "dims", "facts"}, false},
+		{"cycle", models.PipelineNodes{
+			{Key: "a", ValidationID: 1, DependsOn: edge("b", "")},
+			{Key: "b", ValidationID: 2, DependsOn: edge("a", "")},
+		}, nil, true},
+		{"unknown node", models.PipelineNodes{{Key: "a", ValidationID: 1, DependsOn: edge("b", "")}}, nil, true},
+		{"duplicate key", models.PipelineNodes{{Key: "a", ValidationID: 1}, {Key: "a", ValidationID: 2}}, nil, true},
+		{"unknown condition", models.PipelineNodes{
+			{Key: "a", ValidationID: 1},
+			{Key: "b", ValidationID: 2, DependsOn: edge("a", "on_warning")},
+		}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered, err := OrderPipeline(tt.nodes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OrderPipeline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for i, key := range tt.want {
+				if ordered[i].Key != key {
+					t.Errorf("OrderPipeline()[%d] = %s, want %s", i, ordered[i].Key, key)
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineService_ExecuteSkipsDownstream(t *testing.T) {
+	db := openTestDB(t, "pipelines", &models.Pipeline{}, &models.PipelineRun{}, &models.Validation{})
+
+	// No validation exists, so every node that runs fails
+	pipeline := models.Pipeline{Name: "orders", Nodes: models.PipelineNodes{
+		{Key: "counts", ValidationID: 1},
+		{Key: "match", ValidationID: 2, DependsOn: []models.PipelineEdge{{Node: "counts"}}},
+		{Key: "alert", ValidationID: 3, DependsOn: []models.PipelineEdge{{Node: "counts", Condition: models.RunOnFailure}}},
+		{Key: "detail", ValidationID: 4, DependsOn: []models.PipelineEdge{{Node: "match"}}},
+		{Key: "cleanup", ValidationID: 5, DependsOn: []models.PipelineEdge{{Node: "detail", Condition: models.RunAlways}}},
+	}}
+	if err := db.Create(&pipeline).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	pipelines := NewPipelineService(db, NewRunService(db))
+	run, err := pipelines.Start(&pipeline)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	pipelines.Execute(context.Background(), &pipeline, run)
+
+	want := map[string]models.ValidationStatus{
+		"counts":  models.ValidationStatusFailed,
+		"match":   models.ValidationStatusSkipped,
+		"alert":   models.ValidationStatusFailed,
+		"detail":  models.ValidationStatusSkipped,
+		"cleanup": models.ValidationStatusFailed,
+	}
+	for _, node := range run.Nodes {
+		if node.Status != want[node.Key] {
+			t.Errorf("node %s status = %s (%s), want %s", node.Key, node.Status, node.Reason, want[node.Key])
+		}
+	}
+	if run.Status != models.ValidationStatusFailed {
+		t.Errorf("run status = %s, want failed", run.Status)
+	}
+
+	var stored models.PipelineRun
+	if err := db.First(&stored, run.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Nodes) != len(want) || stored.FinishedAt == nil {
+		t.Errorf("stored run = %+v, want every node and a finish time", stored)
+	}
+}
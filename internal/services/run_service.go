@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+// RunRequest describes how a run was started
+type RunRequest struct {
+	Trigger models.RunTrigger
+	// LogicalDate sets the built-in run_date
+	LogicalDate time.Time
+	// Parameters override the config's variables
+	Parameters    map[string]string
+	ScheduleID    *uint
+	ScheduledFor  *time.Time
+	PipelineRunID *uint
+	SuiteRunID    *uint
+}
+
+// RunService runs validations and records every run
+type RunService struct {
+	db          *gorm.DB
+	validations *ValidationService
+	limiter     *connectionLimiter
+	listeners   []EventListener
+	live        liveRuns
+}
+
+// NewRunService creates a new run service instance
+func NewRunService(db *gorm.DB) *RunService {
+	return &RunService{db: db, validations: NewValidationService(), limiter: newConnectionLimiter()}
+}
+
+// Validations returns the service that executes the comparisons
+func (s *RunService) Validations() *ValidationService {
+	return s.validations
+}
+
+// Prepare parses a validation's config and resolves the variables for a run
+func (s *RunService) Prepare(v *models.Validation, req RunRequest) (*validation.Config, error) {
+	config, err := s.validations.ParseConfig(v.Config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validation config: %w", err)
+	}
+	if err := config.ResolveVariables(req.LogicalDate, req.Parameters); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	return config, nil
+}
+
+// Start records a new run of a prepared validation for Execute to run. The
+// run and the validation are marked running, or waiting if the validation's
+// connections cannot take the run now.
+func (s *RunService) Start(v *models.Validation, config *validation.Config, req RunRequest) (*models.ValidationRun, error) {
+	run := s.newRun(v, req)
+	run.Variables = models.RunVariables(config.Variables)
+	if reason := s.Blocked(v, config); reason != "" {
+		run.Status = models.ValidationStatusWaiting
+		run.StatusReason = reason
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to record run: %w", err)
+	}
+
+	v.Status = run.Status
+	s.db.Model(v).Update("status", v.Status)
+	return run, nil
+}
+
+// Execute runs a run recorded by Start, storing the result on the validation
+// and the run. Each attempt first waits for its connections' execution
+// windows and query slots, and is cut off when a window closes. Errors of
+// a class the config's retry policy covers are retried with backoff; every
+// attempt is recorded on the run.
+func (s *RunService) Execute(ctx context.Context, v *models.Validation, config *validation.Config, run *models.ValidationRun) {
+	s.live.add(validationRunsTable, run.ID)
+	defer s.live.remove(validationRunsTable, run.ID)
+
+	for attempt := 1; ; attempt++ {
+		admitted, err := s.admit(ctx, v, config, run)
+		if err != nil {
+			result := validation.ErrorResult(err)
+			run.Attempts = append(run.Attempts, newAttempt(attempt, result))
+			s.finish(v, run, result)
+			return
+		}
+		result := s.attempt(ctx, v, config, run, admitted)
+		s.limiter.release(admitted.slots)
+		run.Attempts = append(run.Attempts, newAttempt(attempt, result))
+		if !config.Retry.Retryable(result, attempt) || ctx.Err() != nil {
+			s.finish(v, run, result)
+			return
+		}
+		s.db.Model(run).Update("attempts", run.Attempts)
+
+		select {
+		case <-time.After(config.Retry.Backoff(attempt)):
+		case <-ctx.Done():
+			s.finish(v, run, result)
+			return
+		}
+	}
+}
+
+// Fail records a run that could not start, e.g. because the config no
+// longer parses
+func (s *RunService) Fail(v *models.Validation, req RunRequest, err error) (*models.ValidationRun, error) {
+	run := s.newRun(v, req)
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to record run: %w", err)
+	}
+	result := validation.ErrorResult(err)
+	run.Attempts = models.RunAttempts{newAttempt(1, result)}
+	s.finish(v, run, result)
+	return run, nil
+}
+
+func (s *RunService) newRun(v *models.Validation, req RunRequest) *models.ValidationRun {
+	now := time.Now().UTC()
+	return &models.ValidationRun{
+		ValidationID:  v.ID,
+		Trigger:       req.Trigger,
+		ScheduleID:    req.ScheduleID,
+		ScheduledFor:  req.ScheduledFor,
+		PipelineRunID: req.PipelineRunID,
+		SuiteRunID:    req.SuiteRunID,
+		Status:        models.ValidationStatusRunning,
+		StartedAt:     now,
+		HeartbeatAt:   &now,
+	}
+}
+
+func newAttempt(attempt int, result *validation.Result) models.RunAttempt {
+	a := models.RunAttempt{
+		Attempt:     attempt,
+		ExecutionID: result.ExecutionID,
+		Status:      result.Status,
+		StartedAt:   result.StartTime,
+		FinishedAt:  result.EndTime,
+	}
+	if len(result.Errors) > 0 {
+		a.Error = result.Errors[0].Message
+		a.ErrorClass = result.Errors[0].Class
+	}
+	return a
+}
+
+// finish stores a result on the validation and its run
+func (s *RunService) finish(v *models.Validation, run *models.ValidationRun, result *validation.Result) {
+	if result.Passed() {
+		v.Status = models.ValidationStatusCompleted
+	} else {
+		v.Status = models.ValidationStatusFailed
+	}
+	v.Results = models.ValidationResults(result.ToMap())
+	s.db.Model(v).Updates(map[string]interface{}{"status": v.Status, "results": v.Results})
+
+	finished := result.EndTime
+	run.ExecutionID = result.ExecutionID
+	run.Status = v.Status
+	run.StatusReason = ""
+	run.Results = v.Results
+	run.FinishedAt = &finished
+	s.db.Save(run)
+
+	incident, transition := s.trackIncident(v, run)
+	for _, event := range runEvents(result) {
+		s.emit(Event{Type: event, UserID: v.UserID, Validation: v, Run: run, Result: result,
+			Incident: incident, Transition: transition})
+	}
+}
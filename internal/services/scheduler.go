@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/cron"
+	"github.com/compareflow/compareflow/internal/models"
+)
+
+const (
+	// schedulerPollInterval is how often due schedules are looked up
+	schedulerPollInterval = 30 * time.Second
+	// missedAfter is how late a tick may still start under the skip policy
+	missedAfter = 2 * time.Minute
+	// maxCatchUp bounds the missed ticks one schedule runs under catch_up;
+	// older ones are skipped
+	maxCatchUp = 24
+	// schedulerWorkers is how many scheduled runs execute at once
+	schedulerWorkers = 4
+)
+
+// Scheduler starts the runs of enabled schedules. Every instance sharing the
+// database may run one: a tick is claimed by moving the schedule's
+// next_run_at on, which only one instance succeeds at.
+type Scheduler struct {
+	db     *gorm.DB
+	runs   *RunService
+	queue  chan scheduledRun
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// scheduledRun is a claimed tick waiting for a worker
+type scheduledRun struct {
+	schedule models.Schedule
+	tick     time.Time
+}
+
+// NewScheduler creates a new scheduler instance
+func NewScheduler(db *gorm.DB, runs *RunService) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		db:     db,
+		runs:   runs,
+		queue:  make(chan scheduledRun, 100),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start polls for due schedules in the background until Stop is called
+func (s *Scheduler) Start() {
+	for i := 0; i < schedulerWorkers; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			for r := range s.queue {
+				s.execute(r)
+			}
+		}()
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(s.queue)
+		ticker := time.NewTicker(schedulerPollInterval)
+		defer ticker.Stop()
+		for {
+			s.poll(time.Now().UTC())
+			select {
+			case <-ticker.C:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops polling, cancels the scheduled runs in progress and waits for them
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// poll claims and enqueues the ticks of every due schedule
+func (s *Scheduler) poll(now time.Time) {
+	var due []models.Schedule
+	if err := s.db.Where("enabled = ? AND next_run_at <= ?", true, now).Find(&due).Error; err != nil {
+		log.Printf("scheduler: failed to fetch due schedules: %v", err)
+		return
+	}
+
+	for _, schedule := range due {
+		if err := s.claim(schedule, now); err != nil {
+			log.Printf("scheduler: schedule %d: %v", schedule.ID, err)
+		}
+	}
+}
+
+// claim advances a due schedule past now, enqueueing the ticks it claims.
+// Under catch_up every missed tick is claimed and run in order.
+func (s *Scheduler) claim(schedule models.Schedule, now time.Time) error {
+	expr, loc, err := ParseSchedule(schedule.Cron, schedule.Timezone)
+	if err != nil {
+		return err
+	}
+
+	for schedule.NextRunAt != nil && !schedule.NextRunAt.After(now) {
+		fire, next, skipped := planTick(expr, loc, schedule.MissedPolicy, *schedule.NextRunAt, now)
+		var nextRunAt *time.Time
+		if !next.IsZero() {
+			next = next.UTC()
+			nextRunAt = &next
+		}
+		updates := map[string]interface{}{"next_run_at": nextRunAt}
+		if fire != nil {
+			updates["last_run_at"] = now
+		}
+		result := s.db.Model(&models.Schedule{}).
+			Where("id = ? AND next_run_at = ?", schedule.ID, schedule.NextRunAt.UTC()).
+			Updates(updates)
+		if result.Error != nil {
+			return fmt.Errorf("failed to claim tick: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil // another instance claimed it
+		}
+
+		if skipped > 0 {
+			log.Printf("scheduler: schedule %d: skipped %d missed tick(s)", schedule.ID, skipped)
+			s.missed(schedule, skipped)
+		}
+		if fire != nil {
+			select {
+			case s.queue <- scheduledRun{schedule: schedule, tick: *fire}:
+			case <-s.ctx.Done():
+				return nil
+			}
+		}
+		schedule.NextRunAt = nextRunAt
+	}
+	return nil
+}
+
+// missed raises schedule.missed for ticks a schedule skipped
+func (s *Scheduler) missed(schedule models.Schedule, skipped int) {
+	var v models.Validation
+	if err := s.db.Select("id", "name", "user_id").First(&v, schedule.ValidationID).Error; err != nil {
+		log.Printf("scheduler: schedule %d: failed to fetch validation %d: %v", schedule.ID, schedule.ValidationID, err)
+		return
+	}
+	s.runs.emit(Event{Type: models.EventScheduleMissed, UserID: v.UserID, Validation: &v, Schedule: &schedule, Missed: skipped})
+}
+
+// execute runs a claimed tick as a scheduled run
+func (s *Scheduler) execute(r scheduledRun) {
+	var v models.Validation
+	if err := s.db.Preload("SourceConnection").Preload("TargetConnection").
+		First(&v, r.schedule.ValidationID).Error; err != nil {
+		log.Printf("scheduler: schedule %d: failed to fetch validation %d: %v", r.schedule.ID, r.schedule.ValidationID, err)
+		return
+	}
+
+	_, loc, _ := ParseSchedule(r.schedule.Cron, r.schedule.Timezone)
+	tick := r.tick.UTC()
+	req := RunRequest{
+		Trigger:      models.RunTriggerScheduled,
+		LogicalDate:  r.tick.In(loc),
+		Parameters:   r.schedule.Parameters,
+		ScheduleID:   &r.schedule.ID,
+		ScheduledFor: &tick,
+	}
+	config, err := s.runs.Prepare(&v, req)
+	if err != nil {
+		_, err = s.runs.Fail(&v, req, err)
+	} else {
+		var run *models.ValidationRun
+		if run, err = s.runs.Start(&v, config, req); err == nil {
+			s.runs.Execute(s.ctx, &v, config, run)
+		}
+	}
+	if err != nil {
+		log.Printf("scheduler: schedule %d: %v", r.schedule.ID, err)
+	}
+}
+
+// planTick decides what to do with a due tick. It returns the tick to run,
+// if any, the next tick to wait for and how many missed ticks are skipped.
+func planTick(expr *cron.Schedule, loc *time.Location, policy string, tick, now time.Time) (*time.Time, time.Time, int) {
+	if policy != models.MissedCatchUp {
+		if now.Sub(tick) <= missedAfter {
+			return &tick, expr.Next(tick.In(loc)), 0
+		}
+		skipped := 0
+		for t := tick; !t.IsZero() && !t.After(now); t = expr.Next(t.In(loc)) {
+			skipped++
+		}
+		return nil, expr.Next(now.In(loc)), skipped
+	}
+
+	// Catch up oldest first, keeping only the most recent maxCatchUp ticks
+	var due []time.Time
+	for t := tick; !t.IsZero() && !t.After(now); t = expr.Next(t.In(loc)) {
+		due = append(due, t)
+	}
+	skipped := 0
+	if len(due) > maxCatchUp {
+		skipped = len(due) - maxCatchUp
+		tick = due[skipped]
+	}
+	return &tick, expr.Next(tick.In(loc)), skipped
+}
+
+// ParseSchedule parses a schedule's cron expression and time zone
+func ParseSchedule(expr, timezone string) (*cron.Schedule, *time.Location, error) {
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid timezone %q", timezone)
+	}
+	return schedule, loc, nil
+}
+
+// NextRun returns the first tick of a schedule after now, or nil if its
+// expression never matches
+func NextRun(schedule *models.Schedule, now time.Time) (*time.Time, error) {
+	expr, loc, err := ParseSchedule(schedule.Cron, schedule.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	next := expr.Next(now.In(loc))
+	if next.IsZero() {
+		return nil, nil
+	}
+	next = next.UTC()
+	return &next, nil
+}
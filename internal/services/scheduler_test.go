@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/cron"
+	"github.com/compareflow/compareflow/internal/models"
+)
+
+func TestPlanTick(t *testing.T) {
+	hourly, _ := cron.Parse("@hourly")
+	tick := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		policy      string
+		now         time.Time
+		wantFire    *time.Time
+		wantNext    time.Time
+		wantSkipped int
+	}{
+		{"on time", models.MissedSkip, tick.Add(30 * time.Second), &tick, tick.Add(time.Hour), 0},
+		{"skip missed", models.MissedSkip, tick.Add(150 * time.Minute), nil, tick.Add(3 * time.Hour), 3},
+		{"catch up", models.MissedCatchUp, tick.Add(150 * time.Minute), &tick, tick.Add(time.Hour), 0},
+		{"catch up too far behind", models.MissedCatchUp, tick.Add(30 * time.Hour), ptr(tick.Add(7 * time.Hour)), tick.Add(8 * time.Hour), 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fire, next, skipped := planTick(hourly, time.UTC, tt.policy, tick, tt.now)
+			if (fire == nil) != (tt.wantFire == nil) || fire != nil && !fire.Equal(*tt.wantFire) {
+				t.Errorf("planTick() fire = %v, want %v", fire, tt.wantFire)
+			}
+			if !next.Equal(tt.wantNext) || skipped != tt.wantSkipped {
+				t.Errorf("planTick() next = %v, skipped = %d, want %v, %d", next, skipped, tt.wantNext, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestScheduler_ClaimOnce(t *testing.T) {
+	db := openTestDB(t, "scheduler", &models.Schedule{})
+
+	now := time.Date(2024, 1, 31, 10, 0, 30, 0, time.UTC)
+	due := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+	schedule := models.Schedule{ValidationID: 1, Cron: "0 * * * *", Enabled: true, NextRunAt: &due}
+	if err := db.Create(&schedule).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Two instances polling the same database start the tick once
+	first, second := NewScheduler(db, nil), NewScheduler(db, nil)
+	first.poll(now)
+	second.poll(now)
+	if got := len(first.queue) + len(second.queue); got != 1 {
+		t.Fatalf("queued runs = %d, want 1", got)
+	}
+
+	db.First(&schedule, schedule.ID)
+	if want := due.Add(time.Hour); schedule.NextRunAt == nil || !schedule.NextRunAt.Equal(want) {
+		t.Errorf("next_run_at = %v, want %v", schedule.NextRunAt, want)
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
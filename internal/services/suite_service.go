@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+// suiteWorkers is how many validations of a suite run at once
+const suiteWorkers = 4
+
+// suiteRunsTable names the table of models.SuiteRun in liveRuns
+const suiteRunsTable = "suite_runs"
+
+// ErrEmptySuite is returned when starting a suite without validations
+var ErrEmptySuite = errors.New("suite has no validations")
+
+// SuiteService runs every validation of a suite and rolls up their outcomes
+type SuiteService struct {
+	db   *gorm.DB
+	runs *RunService
+}
+
+// NewSuiteService creates a new suite service instance
+func NewSuiteService(db *gorm.DB, runs *RunService) *SuiteService {
+	return &SuiteService{db: db, runs: runs}
+}
+
+// Start records a new run of the suite with every member pending
+func (s *SuiteService) Start(suite *models.Suite) (*models.SuiteRun, error) {
+	if len(suite.ValidationIDs) == 0 {
+		return nil, ErrEmptySuite
+	}
+
+	now := time.Now().UTC()
+	run := &models.SuiteRun{
+		SuiteID:     suite.ID,
+		Status:      models.ValidationStatusRunning,
+		StartedAt:   now,
+		HeartbeatAt: &now,
+	}
+	for _, id := range suite.ValidationIDs {
+		run.Members = append(run.Members, models.SuiteMemberRun{ValidationID: id, Status: models.ValidationStatusPending})
+	}
+	run.Summary = summarizeSuite(run.Members)
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to record suite run: %w", err)
+	}
+
+	suite.Status = models.ValidationStatusRunning
+	s.db.Model(suite).Update("status", suite.Status)
+	return run, nil
+}
+
+// Execute runs the members of a started suite run, suiteWorkers at a time,
+// and stores the rollup. The run fails if any member does not pass.
+func (s *SuiteService) Execute(ctx context.Context, suite *models.Suite, run *models.SuiteRun) {
+	s.runs.live.add(suiteRunsTable, run.ID)
+	defer s.runs.live.remove(suiteRunsTable, run.ID)
+
+	var mu sync.Mutex
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < suiteWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				member := s.runMember(ctx, run, run.Members[i].ValidationID)
+
+				mu.Lock()
+				run.Members[i] = member
+				run.Summary = summarizeSuite(run.Members)
+				s.db.Model(run).Updates(map[string]interface{}{"members": run.Members, "summary": run.Summary})
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range run.Members {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+	s.finish(suite, run)
+}
+
+// finish stores the outcome of a suite run whose members are all done and
+// announces it
+func (s *SuiteService) finish(suite *models.Suite, run *models.SuiteRun) {
+	run.Status = models.ValidationStatusCompleted
+	if run.Summary.Passed != run.Summary.Total {
+		run.Status = models.ValidationStatusFailed
+	}
+	finished := time.Now().UTC()
+	run.FinishedAt = &finished
+	s.db.Save(run)
+
+	suite.Status = run.Status
+	s.db.Model(suite).Update("status", suite.Status)
+
+	event := models.EventSuiteCompleted
+	if run.Status == models.ValidationStatusFailed {
+		event = models.EventSuiteFailed
+	}
+	var previous models.SuiteRun
+	s.db.Select("status").Where("suite_id = ? AND id < ? AND finished_at IS NOT NULL", suite.ID, run.ID).
+		Order("id DESC").Limit(1).Find(&previous)
+	s.runs.emit(Event{Type: event, UserID: suite.UserID, Suite: suite, SuiteRun: run, Previous: previous.Status})
+}
+
+// abandon fails a suite run left unfinished by an instance that stopped,
+// along with its members that had not finished
+func (s *SuiteService) abandon(run *models.SuiteRun, cutoff time.Time) error {
+	if claimed, err := claim(s.db, suiteRunsTable, run.ID, cutoff); !claimed {
+		return err
+	}
+
+	var suite models.Suite
+	if err := s.db.First(&suite, run.SuiteID).Error; err != nil {
+		return err
+	}
+	for i, member := range run.Members {
+		if member.Status != models.ValidationStatusCompleted && member.Status != models.ValidationStatusFailed {
+			run.Members[i].Status = models.ValidationStatusFailed
+			run.Members[i].Result = validation.StatusError
+			run.Members[i].Error = errAbandoned.Error()
+		}
+	}
+	run.Summary = summarizeSuite(run.Members)
+	s.finish(&suite, run)
+	return nil
+}
+
+// runMember runs one validation of a suite run
+func (s *SuiteService) runMember(ctx context.Context, suiteRun *models.SuiteRun, validationID uint) models.SuiteMemberRun {
+	member := models.SuiteMemberRun{ValidationID: validationID, Status: models.ValidationStatusFailed, Result: validation.StatusError}
+
+	var v models.Validation
+	if err := s.db.Preload("SourceConnection").Preload("TargetConnection").
+		First(&v, validationID).Error; err != nil {
+		member.Error = fmt.Sprintf("failed to fetch validation %d: %v", validationID, err)
+		return member
+	}
+	member.Name = v.Name
+
+	req := RunRequest{
+		Trigger:     models.RunTriggerSuite,
+		LogicalDate: suiteRun.StartedAt,
+		SuiteRunID:  &suiteRun.ID,
+	}
+	var run *models.ValidationRun
+	config, err := s.runs.Prepare(&v, req)
+	if err != nil {
+		run, err = s.runs.Fail(&v, req, err)
+	} else {
+		if run, err = s.runs.Start(&v, config, req); err == nil {
+			s.runs.Execute(ctx, &v, config, run)
+		}
+	}
+	if err != nil {
+		member.Error = err.Error()
+		return member
+	}
+
+	member.Status = run.Status
+	member.RunID = &run.ID
+	if status, ok := run.Results["status"].(string); ok {
+		member.Result = status
+	}
+	if errs, ok := run.Results["errors"].([]interface{}); ok && len(errs) > 0 {
+		if entry, ok := errs[0].(map[string]interface{}); ok {
+			member.Error, _ = entry["message"].(string)
+		}
+	}
+	return member
+}
+
+// summarizeSuite counts members by outcome
+func summarizeSuite(members models.SuiteMemberRuns) models.SuiteSummary {
+	summary := models.SuiteSummary{Total: len(members)}
+	for _, m := range members {
+		switch {
+		case m.Status == models.ValidationStatusCompleted:
+			summary.Passed++
+		case m.Status != models.ValidationStatusFailed:
+			summary.Pending++
+		case m.Result == validation.StatusFailure:
+			summary.Failed++
+		default:
+			summary.Errored++
+		}
+	}
+	return summary
+}
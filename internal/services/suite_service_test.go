@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+func TestSummarizeSuite(t *testing.T) {
+	members := models.SuiteMemberRuns{
+		{Status: models.ValidationStatusCompleted, Result: validation.StatusSuccess},
+		{Status: models.ValidationStatusFailed, Result: validation.StatusFailure},
+		{Status: models.ValidationStatusFailed, Result: validation.StatusError},
+		{Status: models.ValidationStatusWaiting},
+		{Status: models.ValidationStatusPending},
+	}
+	want := models.SuiteSummary{Total: 5, Passed: 1, Failed: 1, Errored: 1, Pending: 2}
+	if got := summarizeSuite(members); got != want {
+		t.Errorf("summarizeSuite() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSuiteService_Execute(t *testing.T) {
+	db := openTestDB(t, "suites", &models.Suite{}, &models.SuiteRun{}, &models.Validation{}, &models.ValidationRun{}, &models.Incident{})
+
+	// Validation 1 has a config that no longer parses; validation 2 is gone
+	broken := models.Validation{Name: "orders", Config: models.ValidationConfig{"comparison_type": "unknown"}}
+	if err := db.Create(&broken).Error; err != nil {
+		t.Fatal(err)
+	}
+	suite := models.Suite{Name: "nightly", ValidationIDs: models.SuiteMembers{broken.ID, 99}}
+	if err := db.Create(&suite).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSuiteService(db, NewRunService(db))
+	run, err := s.Start(&suite)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	s.Execute(context.Background(), &suite, run)
+
+	var stored models.SuiteRun
+	if err := db.First(&stored, run.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.ValidationStatusFailed || stored.FinishedAt == nil {
+		t.Errorf("suite run = %s, finished %v, want failed and finished", stored.Status, stored.FinishedAt)
+	}
+	if want := (models.SuiteSummary{Total: 2, Errored: 2}); stored.Summary != want {
+		t.Errorf("summary = %+v, want %+v", stored.Summary, want)
+	}
+	if m := stored.Members[0]; m.Name != "orders" || m.RunID == nil || m.Error == "" {
+		t.Errorf("member = %+v, want a recorded run with its error", m)
+	}
+
+	var recorded models.ValidationRun
+	if err := db.First(&recorded, *stored.Members[0].RunID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if recorded.Trigger != models.RunTriggerSuite || recorded.SuiteRunID == nil || *recorded.SuiteRunID != run.ID {
+		t.Errorf("validation run = %+v, want it linked to the suite run", recorded)
+	}
+}
+
+func TestSuiteService_StartEmpty(t *testing.T) {
+	db := openTestDB(t, "suites_empty", &models.Suite{}, &models.SuiteRun{})
+
+	suite := models.Suite{Name: "nightly"}
+	if _, err := NewSuiteService(db, NewRunService(db)).Start(&suite); !errors.Is(err, ErrEmptySuite) {
+		t.Errorf("Start() error = %v, want %v", err, ErrEmptySuite)
+	}
+}
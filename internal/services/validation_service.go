@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+// ValidationService runs validations against their source and target connections
+type ValidationService struct {
+	mu       sync.RWMutex
+	progress map[uint]validation.Progress // by run ID
+}
+
+// NewValidationService creates a new validation service instance
+func NewValidationService() *ValidationService {
+	return &ValidationService{progress: make(map[uint]validation.Progress)}
+}
+
+// ParseConfig parses and validates a validation config
+func (s *ValidationService) ParseConfig(config models.ValidationConfig) (*validation.Config, error) {
+	return validation.ParseConfig(config)
+}
+
+// Run executes a validation for the run with the given ID. The returned result
+// reports connection and query failures with status error rather than as an
+// error.
+func (s *ValidationService) Run(ctx context.Context, runID uint, v *models.Validation, config *validation.Config) *validation.Result {
+	source, err := s.openSide(v.SourceConnection)
+	if err != nil {
+		return validation.ErrorResult(fmt.Errorf("source: %w", err))
+	}
+	defer source.DB.Close()
+
+	target, err := s.openSide(v.TargetConnection)
+	if err != nil {
+		return validation.ErrorResult(fmt.Errorf("target: %w", err))
+	}
+	defer target.DB.Close()
+
+	ctx = validation.WithProgress(ctx, func(p validation.Progress) {
+		s.mu.Lock()
+		s.progress[runID] = p
+		s.mu.Unlock()
+	})
+	defer func() {
+		s.mu.Lock()
+		delete(s.progress, runID)
+		s.mu.Unlock()
+	}()
+
+	return validation.Run(ctx, config, source, target)
+}
+
+// Progress returns the progress of a run that is currently executing
+func (s *ValidationService) Progress(runID uint) (validation.Progress, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.progress[runID]
+	return p, ok
+}
+
+// openSide connects to one end of a validation
+func (s *ValidationService) openSide(conn *models.Connection) (*validation.Side, error) {
+	if conn == nil {
+		return nil, fmt.Errorf("connection not loaded")
+	}
+
+	// Get the appropriate connector
+	connector, err := connectors.Get(string(conn.Type))
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the config
+	config, err := connector.ParseConfig(conn.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	// Connect to database
+	db, err := connector.Connect(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+
+	return &validation.Side{Connector: connector, DB: db}, nil
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/compareflow/compareflow/internal/models"
+)
+
+const (
+	// webhookPollInterval is how often due deliveries are looked up when no
+	// new event wakes the dispatcher
+	webhookPollInterval = 5 * time.Second
+	// webhookTimeout bounds one delivery attempt
+	webhookTimeout = 10 * time.Second
+	// webhookLease is how long an attempt in progress keeps other instances off a delivery
+	webhookLease = time.Minute
+	// webhookMaxAttempts includes the first attempt
+	webhookMaxAttempts = 6
+	// webhookBackoff is the wait after the first failed attempt; it doubles after each
+	webhookBackoff = 30 * time.Second
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-CompareFlow-Event"
+	HeaderDelivery  = "X-CompareFlow-Delivery"
+	HeaderTimestamp = "X-CompareFlow-Timestamp"
+	// HeaderSignature is "sha256=" and the hex HMAC-SHA256 of the timestamp,
+	// a dot and the body, keyed with the webhook's secret
+	HeaderSignature = "X-CompareFlow-Signature"
+)
+
+// WebhookService turns events into deliveries and sends them, retrying
+// failed attempts with backoff. Deliveries are stored before they are sent,
+// so every instance sharing the database may run a dispatcher.
+type WebhookService struct {
+	db        *gorm.DB
+	publicURL string // base of links in Slack and Teams messages
+	client    *http.Client
+	wake      chan struct{}
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewWebhookService creates a new webhook service instance
+func NewWebhookService(db *gorm.DB, publicURL string) *WebhookService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookService{
+		db:        db,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		client:    &http.Client{Timeout: webhookTimeout},
+		wake:      make(chan struct{}, 1),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Notify queues a delivery of the event to each of the user's enabled
+// webhooks that subscribe to it. Slack and Teams webhooks only get the runs
+// the notify mode of the validation or suite announces, and one message per
+// run.
+func (s *WebhookService) Notify(event Event) {
+	var hooks []models.Webhook
+	if err := s.db.Where("user_id = ? AND enabled = ?", event.UserID, true).Find(&hooks).Error; err != nil {
+		log.Printf("webhooks: failed to fetch webhooks: %v", err)
+		return
+	}
+
+	queued := false
+	for _, hook := range hooks {
+		if !hook.Events.Has(event.Type) {
+			continue
+		}
+		if hook.Type == models.WebhookSlack || hook.Type == models.WebhookTeams {
+			if !event.Announced() {
+				continue
+			}
+			if event.Type == models.EventRunThresholdBreached && hook.Events.Has(models.EventRunFailed) {
+				continue // the run.failed message covers it
+			}
+		}
+		if _, err := s.queue(&hook, event, true); err != nil {
+			log.Printf("webhooks: webhook %d: %v", hook.ID, err)
+			continue
+		}
+		queued = true
+	}
+	if queued {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Test sends a ping to the webhook now and returns its delivery. Pings are
+// not retried.
+func (s *WebhookService) Test(ctx context.Context, hook *models.Webhook) (*models.WebhookDelivery, error) {
+	delivery, err := s.queue(hook, Event{Type: models.EventPing, UserID: hook.UserID}, false)
+	if err != nil {
+		return nil, err
+	}
+	s.attempt(ctx, hook, delivery)
+	return delivery, nil
+}
+
+// queue stores a pending delivery of the event to hook, due now for the
+// dispatcher or left for the caller to attempt
+func (s *WebhookService) queue(hook *models.Webhook, event Event, due bool) (*models.WebhookDelivery, error) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	payload, err := json.Marshal(s.payload(hook, event))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+	delivery := &models.WebhookDelivery{
+		WebhookID: hook.ID,
+		Event:     event.Type,
+		Payload:   string(payload),
+		Status:    models.DeliveryPending,
+	}
+	if due {
+		delivery.NextAttemptAt = &event.Time
+	}
+	if err := s.db.Create(delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to record delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// Start sends due deliveries in the background until Stop is called
+func (s *WebhookService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(webhookPollInterval)
+		defer ticker.Stop()
+		for {
+			s.deliverDue(time.Now().UTC())
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the dispatcher and waits for the attempt in progress
+func (s *WebhookService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// deliverDue claims and attempts every pending delivery that is due
+func (s *WebhookService) deliverDue(now time.Time) {
+	var due []models.WebhookDelivery
+	if err := s.db.Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("id").Limit(100).Find(&due).Error; err != nil {
+		log.Printf("webhooks: failed to fetch due deliveries: %v", err)
+		return
+	}
+
+	for i := range due {
+		delivery := &due[i]
+		// Claim the attempt by moving next_attempt_at past the lease
+		lease := now.Add(webhookLease)
+		result := s.db.Model(&models.WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, models.DeliveryPending, delivery.NextAttemptAt.UTC()).
+			Update("next_attempt_at", lease)
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue // another instance claimed it
+		}
+
+		var hook models.Webhook
+		if err := s.db.First(&hook, delivery.WebhookID).Error; err != nil {
+			delivery.Status = models.DeliveryFailed
+			delivery.Error = "webhook deleted"
+			delivery.NextAttemptAt = nil
+			s.db.Save(delivery)
+			continue
+		}
+		s.attempt(s.ctx, &hook, delivery)
+		if s.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// attempt posts a delivery once and records the outcome, scheduling a retry
+// if attempts are left
+func (s *WebhookService) attempt(ctx context.Context, hook *models.Webhook, delivery *models.WebhookDelivery) {
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.Error = ""
+
+	status, err := s.post(ctx, hook, delivery)
+	delivery.ResponseStatus = status
+	now := time.Now().UTC()
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= webhookMaxAttempts || delivery.Event == models.EventPing:
+		delivery.Status = models.DeliveryFailed
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(webhookBackoff << (delivery.Attempts - 1))
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = &next
+	}
+	s.db.Save(delivery)
+}
+
+// post sends the delivery's payload, returning the response status. Any
+// status outside 2xx is an error.
+func (s *WebhookService) post(ctx context.Context, hook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CompareFlow-Webhook")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value of a payload sent at timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewWebhookSecret returns a random signing secret
+func NewWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// payload is the body sent to hook for an event
+func (s *WebhookService) payload(hook *models.Webhook, event Event) interface{} {
+	switch hook.Type {
+	case models.WebhookSlack:
+		return slackMessage(newChatMessage(event, s.publicURL))
+	case models.WebhookTeams:
+		return teamsMessage(newChatMessage(event, s.publicURL))
+	}
+	return eventPayload(event)
+}
+
+// eventPayload is the JSON body sent for an event
+func eventPayload(event Event) map[string]interface{} {
+	payload := map[string]interface{}{
+		"event":      event.Type,
+		"created_at": event.Time,
+	}
+	if v := event.Validation; v != nil {
+		payload["validation"] = map[string]interface{}{"id": v.ID, "name": v.Name}
+	}
+	if run := event.Run; run != nil {
+		payload["run"] = map[string]interface{}{
+			"id":           run.ID,
+			"execution_id": run.ExecutionID,
+			"trigger":      run.Trigger,
+			"status":       run.Status,
+			"variables":    run.Variables,
+			"attempts":     len(run.Attempts),
+			"started_at":   run.StartedAt,
+			"finished_at":  run.FinishedAt,
+		}
+	}
+	if result := event.Result; result != nil {
+		summary := map[string]interface{}{
+			"status":      result.Status,
+			"duration_ms": result.DurationMS,
+			"summary":     result.Summary,
+		}
+		if len(result.Errors) > 0 {
+			summary["errors"] = result.Errors
+		}
+		payload["result"] = summary
+	}
+	if incident := event.Incident; incident != nil {
+		payload["incident"] = map[string]interface{}{
+			"id":          incident.ID,
+			"status":      incident.Status,
+			"transition":  event.Transition,
+			"failed_runs": incident.FailedRuns,
+			"opened_at":   incident.OpenedAt,
+		}
+	}
+	if suite := event.Suite; suite != nil {
+		payload["suite"] = map[string]interface{}{"id": suite.ID, "name": suite.Name}
+	}
+	if run := event.SuiteRun; run != nil {
+		payload["suite_run"] = map[string]interface{}{
+			"id":          run.ID,
+			"status":      run.Status,
+			"summary":     run.Summary,
+			"started_at":  run.StartedAt,
+			"finished_at": run.FinishedAt,
+		}
+	}
+	if schedule := event.Schedule; schedule != nil {
+		payload["schedule"] = map[string]interface{}{
+			"id":          schedule.ID,
+			"cron":        schedule.Cron,
+			"timezone":    schedule.Timezone,
+			"next_run_at": schedule.NextRunAt,
+		}
+		payload["missed"] = event.Missed
+	}
+	return payload
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/models"
+	"github.com/compareflow/compareflow/internal/validation"
+)
+
+func TestRunEvents(t *testing.T) {
+	tests := []struct {
+		status string
+		want   []string
+	}{
+		{validation.StatusSuccess, []string{models.EventRunCompleted}},
+		{validation.StatusFailure, []string{models.EventRunFailed, models.EventRunThresholdBreached}},
+		{validation.StatusError, []string{models.EventRunFailed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			got := runEvents(&validation.Result{Status: tt.status})
+			if len(got) != len(tt.want) {
+				t.Fatalf("runEvents() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("runEvents() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookService_Deliver(t *testing.T) {
+	db := openTestDB(t, "webhooks", &models.Webhook{}, &models.WebhookDelivery{})
+
+	// The receiver fails the first request and accepts the rest
+	var calls int32
+	var header http.Header
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	hook := models.Webhook{Name: "ops", URL: receiver.URL, Secret: "s3cret", Enabled: true, UserID: 1,
+		Events: models.EventTypes{models.EventRunFailed}}
+	other := models.Webhook{Name: "passes", URL: receiver.URL, Secret: "x", Enabled: true, UserID: 1,
+		Events: models.EventTypes{models.EventRunCompleted}}
+	for _, h := range []*models.Webhook{&hook, &other} {
+		if err := db.Create(h).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewWebhookService(db, "")
+	now := time.Now().UTC()
+	s.Notify(Event{
+		Type:       models.EventRunFailed,
+		Time:       now,
+		UserID:     1,
+		Validation: &models.Validation{ID: 7, Name: "orders"},
+		Result:     &validation.Result{Status: validation.StatusError},
+	})
+
+	var deliveries []models.WebhookDelivery
+	db.Find(&deliveries)
+	if len(deliveries) != 1 || deliveries[0].WebhookID != hook.ID {
+		t.Fatalf("queued %+v, want one delivery to webhook %d", deliveries, hook.ID)
+	}
+
+	s.deliverDue(now)
+	var delivery models.WebhookDelivery
+	db.First(&delivery, deliveries[0].ID)
+	if delivery.Status != models.DeliveryPending || delivery.Attempts != 1 || delivery.ResponseStatus != 500 {
+		t.Fatalf("after a failed attempt: %+v, want pending with 1 attempt and status 500", delivery)
+	}
+	if delivery.NextAttemptAt == nil || delivery.NextAttemptAt.Before(now.Add(webhookBackoff)) {
+		t.Fatalf("next attempt at %v, want at least %v later", delivery.NextAttemptAt, webhookBackoff)
+	}
+
+	// Nothing is due before the backoff passes
+	s.deliverDue(now)
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("receiver called %d times before the retry was due, want 1", n)
+	}
+
+	s.deliverDue(delivery.NextAttemptAt.Add(time.Second))
+	db.First(&delivery, delivery.ID)
+	if delivery.Status != models.DeliveryDelivered || delivery.Attempts != 2 || delivery.DeliveredAt == nil {
+		t.Fatalf("after a retry: %+v, want delivered after 2 attempts", delivery)
+	}
+
+	if got := header.Get(HeaderEvent); got != models.EventRunFailed {
+		t.Errorf("%s = %q, want %q", HeaderEvent, got, models.EventRunFailed)
+	}
+	want := Sign("s3cret", header.Get(HeaderTimestamp), body)
+	if got := header.Get(HeaderSignature); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload["event"] != models.EventRunFailed || payload["validation"].(map[string]interface{})["name"] != "orders" {
+		t.Errorf("payload = %s", body)
+	}
+}
@@ -0,0 +1,286 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/values"
+)
+
+// chunk is a range of the first key column: lower < key <= upper. The first
+// chunk has no lower bound and also holds NULL keys; the last has no upper
+// bound so target rows beyond the source's largest key are still compared.
+type chunk struct {
+	index        int
+	lower, upper interface{}
+	first, last  bool
+}
+
+// chunkSide renders chunk queries for one side
+type chunkSide struct {
+	*Side
+	dialect connectors.Dialect
+	query   string
+	column  string // quoted name of the first key column
+}
+
+// chunkQuery returns the side's query restricted to the chunk and its arguments
+func (s *chunkSide) chunkQuery(ch chunk) (string, []interface{}) {
+	if ch.first && ch.last {
+		return s.query, nil
+	}
+
+	var conditions []string
+	var args []interface{}
+	if !ch.first {
+		args = append(args, ch.lower)
+		conditions = append(conditions, s.column+" > "+s.dialect.Placeholder(len(s.args)+len(args)))
+	}
+	if !ch.last {
+		args = append(args, ch.upper)
+		conditions = append(conditions, s.column+" <= "+s.dialect.Placeholder(len(s.args)+len(args)))
+	}
+	where := conditions[0]
+	if len(conditions) == 2 {
+		where += " AND " + conditions[1]
+	}
+	if ch.first {
+		where = "(" + s.column + " IS NULL OR " + where + ")"
+	}
+	return "SELECT * FROM (" + s.query + ") src WHERE " + where, args
+}
+
+// compareChunked compares source and target chunk by chunk, running up to
+// performance.concurrency chunks at once and retrying failed chunks
+func compareChunked(ctx context.Context, config *Config, source, target *Side) (*Result, error) {
+	progress := newTracker(ctx)
+	progress.stage(StagePlanning)
+
+	chunks, sourceChunks, targetChunks, err := planChunks(ctx, config, source, target)
+	if err != nil {
+		return nil, err
+	}
+	progress.chunks(len(chunks))
+	progress.stage(StageComparing)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*comparison, len(chunks))
+	var firstErr error
+	var errOnce sync.Once
+
+	work := make(chan chunk)
+	var wg sync.WaitGroup
+	for i := 0; i < config.Performance.Concurrency && i < len(chunks); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ch := range work {
+				c, err := runChunk(ctx, config, sourceChunks, targetChunks, ch, progress)
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = fmt.Errorf("chunk %d: %w", ch.index, err)
+						cancel()
+					})
+					continue
+				}
+				results[ch.index] = c
+			}
+		}()
+	}
+
+	for _, ch := range chunks {
+		select {
+		case work <- ch:
+		case <-ctx.Done():
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Merge in chunk order so differences come out sorted by key range
+	merged := newComparison(config)
+	for _, c := range results {
+		merged.merge(c)
+	}
+
+	result := merged.result(config)
+	if len(chunks) > 1 {
+		result.Details.Chunks = len(chunks)
+	}
+	return result, nil
+}
+
+// runChunk compares one chunk, retrying up to performance.chunk_retries times
+func runChunk(ctx context.Context, config *Config, source, target *chunkSide, ch chunk, progress *tracker) (*comparison, error) {
+	for attempt := 1; ; attempt++ {
+		progress.chunk(ch.index, func(c *ChunkProgress) {
+			c.Status = ChunkRunning
+			c.Attempts = attempt
+		})
+
+		c, err := compareChunk(ctx, config, source, target, ch)
+		if err == nil {
+			progress.chunk(ch.index, func(p *ChunkProgress) {
+				p.Status = ChunkDone
+				p.Rows = c.summary.SourceRowCount + c.summary.TargetRowCount
+				p.Error = ""
+			})
+			return c, nil
+		}
+
+		if attempt > *config.Performance.ChunkRetries || ctx.Err() != nil {
+			progress.chunk(ch.index, func(p *ChunkProgress) {
+				p.Status = ChunkFailed
+				p.Error = err.Error()
+			})
+			return nil, err
+		}
+
+		progress.chunk(ch.index, func(p *ChunkProgress) {
+			p.Status = ChunkRetrying
+			p.Error = err.Error()
+		})
+		select {
+		case <-time.After(time.Duration(attempt) * time.Second):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// compareChunk fetches the rows of one chunk from both sides and diffs them
+func compareChunk(ctx context.Context, config *Config, source, target *chunkSide, ch chunk) (*comparison, error) {
+	var sourceRows, targetRows *rowSet
+	err := config.both(
+		func() (err error) {
+			query, args := source.chunkQuery(ch)
+			sourceRows, err = fetchRows(ctx, source.Side, query, config.sourceColumns(), args...)
+			return err
+		},
+		func() (err error) {
+			query, args := target.chunkQuery(ch)
+			targetRows, err = fetchRows(ctx, target.Side, query, config.targetColumns(), args...)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c := newComparison(config)
+	c.summary.SourceRowCount = sourceRows.count()
+	c.summary.TargetRowCount = targetRows.count()
+	c.compare(sourceRows, targetRows)
+	return c, nil
+}
+
+// planChunks splits the source's first key column into performance.chunks
+// ranges of roughly equal row counts using NTILE. Each database applies the
+// range bounds itself, so only key columns that every database orders alike
+// are split; others are compared in a single chunk.
+func planChunks(ctx context.Context, config *Config, source, target *Side) ([]chunk, *chunkSide, *chunkSide, error) {
+	sourceChunks := &chunkSide{Side: source, query: config.SourceQuery}
+	targetChunks := &chunkSide{Side: target, query: config.TargetQuery}
+	single := []chunk{{index: 0, first: true, last: true}}
+	if config.Performance.Chunks <= 1 {
+		return single, sourceChunks, targetChunks, nil
+	}
+
+	for _, side := range []struct {
+		name   string
+		chunks *chunkSide
+		key    string
+	}{{"source", sourceChunks, config.KeyColumns[0]}, {"target", targetChunks, config.TargetColumn(config.KeyColumns[0])}} {
+		dialect, ok := side.chunks.Connector.(connectors.Dialect)
+		if !ok {
+			return nil, nil, nil, fmt.Errorf("%s: connector %s cannot split queries into chunks", side.name, side.chunks.Connector.Type())
+		}
+		columns, err := describeQuery(ctx, side.chunks.Side, side.chunks.query)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: %w", side.name, err)
+		}
+		names := make([]string, len(columns))
+		for i, column := range columns {
+			names[i] = column.Name()
+		}
+		idx := columnIndex(names, side.key)
+		if idx < 0 {
+			return nil, nil, nil, fmt.Errorf("%s: key column %q not found in query result", side.name, side.key)
+		}
+		if !values.Ordered(columns[idx].DatabaseTypeName()) {
+			return single, sourceChunks, targetChunks, nil
+		}
+		side.chunks.dialect = dialect
+		side.chunks.column = dialect.QuoteIdentifier(names[idx])
+	}
+
+	bounds, err := chunkBounds(ctx, sourceChunks, config.Performance.Chunks)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("source: %w", err)
+	}
+	if len(bounds) == 0 {
+		return single, sourceChunks, targetChunks, nil
+	}
+
+	chunks := make([]chunk, len(bounds)+1)
+	for i := range chunks {
+		chunks[i] = chunk{index: i, first: i == 0, last: i == len(bounds)}
+		if i > 0 {
+			chunks[i].lower = bounds[i-1]
+		}
+		if i < len(bounds) {
+			chunks[i].upper = bounds[i]
+		}
+	}
+	return chunks, sourceChunks, targetChunks, nil
+}
+
+// chunkBounds returns the distinct upper bounds of all but the last tile
+func chunkBounds(ctx context.Context, side *chunkSide, n int) ([]interface{}, error) {
+	query := fmt.Sprintf("SELECT MAX(%[1]s) FROM (SELECT %[1]s, NTILE(%[2]d) OVER (ORDER BY %[1]s) AS cf_chunk FROM (%[3]s) src WHERE %[1]s IS NOT NULL) tiles GROUP BY cf_chunk ORDER BY cf_chunk",
+		side.column, n, side.query)
+
+	rows, err := side.queryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute chunk bounds: %w", err)
+	}
+	defer rows.Close()
+
+	var maxima []interface{}
+	for rows.Next() {
+		var bound interface{}
+		if err := rows.Scan(&bound); err != nil {
+			return nil, fmt.Errorf("failed to scan chunk bound: %w", err)
+		}
+		// Drivers return some types as bytes; bind them as text on the other side
+		if b, ok := bound.([]byte); ok {
+			bound = string(b)
+		}
+		maxima = append(maxima, bound)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The last tile is open-ended, and duplicate keys can end adjacent tiles on the same value
+	var bounds []interface{}
+	for i := 0; i < len(maxima)-1; i++ {
+		if len(bounds) > 0 && values.Equal(values.Of(bounds[len(bounds)-1]), values.Of(maxima[i])) {
+			continue
+		}
+		bounds = append(bounds, maxima[i])
+	}
+	return bounds, nil
+}
@@ -0,0 +1,400 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Comparison types
+const (
+	TypeRowCount    = "row_count"
+	TypeDataMatch   = "data_match"
+	TypeColumnStats = "column_stats"
+)
+
+// Data match strategies
+const (
+	// StrategyFull fetches every row from both sides and compares them in memory
+	StrategyFull = "full"
+	// StrategyHash compares per-bucket row hashes computed in-database and only
+	// fetches rows from buckets that differ
+	StrategyHash = "hash"
+)
+
+// Unmapped column handling
+const (
+	// UnmappedIgnore skips columns that have no counterpart on the other side
+	UnmappedIgnore = "ignore"
+	// UnmappedFlag reports them in details.unmapped_columns
+	UnmappedFlag = "flag"
+)
+
+// Config is the typed form of a validation's configuration
+type Config struct {
+	ComparisonType  string                `json:"comparison_type"`
+	SourceQuery     string                `json:"source_query"`
+	TargetQuery     string                `json:"target_query"`
+	SourceTable     string                `json:"source_table"`
+	TargetTable     string                `json:"target_table"`
+	IncludeColumns  []string              `json:"include_columns"`
+	ExcludeColumns  []string              `json:"exclude_columns"`
+	Filter          string                `json:"filter"`
+	TargetFilter    string                `json:"target_filter"`
+	KeyColumns      []string              `json:"key_columns"`
+	ColumnMapping   []ColumnMapping       `json:"column_mapping"`
+	UnmappedColumns string                `json:"unmapped_columns"`
+	ColumnRules     map[string]ColumnRule `json:"column_rules"`
+	Stats           []StatsColumn         `json:"stats"`
+	GroupBy         []string              `json:"group_by"`
+	Strategy        string                `json:"strategy"`
+	Hash            HashOptions           `json:"hash"`
+	Sample          SampleOptions         `json:"sample"`
+	ErrorMargin     ErrorMargin           `json:"error_margin"`
+	Performance     Performance           `json:"performance"`
+	Retry           RetryPolicy           `json:"retry"`
+	// Variables holds the defaults of the {{name}} variables used in queries
+	// and filters; ResolveVariables replaces them with a run's values
+	Variables map[string]string `json:"variables"`
+
+	// compiled column mapping and rules, by lower-case column name
+	targetNames      map[string]string
+	sourceTransforms map[string]transform
+	targetTransforms map[string]transform
+	rules            map[string]*ColumnRule
+}
+
+// ColumnMapping pairs a source column with a differently named target column
+// and optionally transforms either value before comparison. Columns with the
+// same name on both sides are paired without a mapping.
+type ColumnMapping struct {
+	Source          string `json:"source"`
+	Target          string `json:"target"`
+	SourceTransform string `json:"source_transform,omitempty"`
+	TargetTransform string `json:"target_transform,omitempty"`
+}
+
+// HashOptions tunes the hash strategy
+type HashOptions struct {
+	// Buckets is the fan-out of each bisection level; it must be a power of two
+	Buckets int `json:"buckets"`
+	// LeafSize is the row count below which a mismatching bucket is fetched and diffed
+	LeafSize int `json:"leaf_size"`
+}
+
+// ErrorMargin is the number of differences tolerated before a validation fails
+type ErrorMargin struct {
+	Type  string  `json:"type"` // absolute or percentage
+	Value float64 `json:"value"`
+}
+
+// Performance limits how much work a run may do
+type Performance struct {
+	BatchSize      int `json:"batch_size"`
+	TimeoutSeconds int `json:"timeout_seconds"`
+	MaxDifferences int `json:"max_differences"`
+	// Chunks splits a full data_match into key ranges of the first key column
+	Chunks int `json:"chunks"`
+	// Concurrency is the number of chunks compared at once, i.e. the number
+	// of connections opened to each side
+	Concurrency int `json:"concurrency"`
+	// ChunkRetries is how often a failed chunk is retried before the run
+	// fails. Unset means 2; 0 disables retries.
+	ChunkRetries *int `json:"chunk_retries,omitempty"`
+	// SequentialSides queries the target only after the source instead of
+	// both at once. The server sets it when both sides share a connection
+	// that allows fewer concurrent queries than that needs.
+	SequentialSides bool `json:"-"`
+}
+
+// ParseConfig parses a raw validation config and applies defaults
+func ParseConfig(configMap map[string]interface{}) (*Config, error) {
+	var config Config
+
+	// Convert map to JSON and back to struct
+	jsonBytes, err := json.Marshal(configMap)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := json.Unmarshal(jsonBytes, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse validation config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *Config) Validate() error {
+	if c.ComparisonType == "" {
+		return fmt.Errorf("comparison_type is required")
+	}
+	if _, err := Get(c.ComparisonType); err != nil {
+		return err
+	}
+	if (c.SourceQuery == "") == (c.SourceTable == "") {
+		return fmt.Errorf("either source_query or source_table is required")
+	}
+	if (c.TargetQuery == "") == (c.TargetTable == "") {
+		return fmt.Errorf("either target_query or target_table is required")
+	}
+	if c.SourceTable == "" && c.TargetTable == "" &&
+		(len(c.IncludeColumns) > 0 || len(c.ExcludeColumns) > 0 || c.Filter != "" || c.TargetFilter != "") {
+		return fmt.Errorf("include_columns, exclude_columns and filter apply to source_table and target_table only")
+	}
+	if len(c.IncludeColumns) > 0 && len(c.ExcludeColumns) > 0 {
+		return fmt.Errorf("include_columns and exclude_columns are mutually exclusive")
+	}
+	for _, key := range c.KeyColumns {
+		if containsFold(c.ExcludeColumns, key) {
+			return fmt.Errorf("key column %q cannot be excluded", key)
+		}
+	}
+	for _, column := range c.GroupBy {
+		if containsFold(c.ExcludeColumns, column) {
+			return fmt.Errorf("group_by column %q cannot be excluded", column)
+		}
+	}
+	for _, stat := range c.Stats {
+		if containsFold(c.ExcludeColumns, stat.Column) {
+			return fmt.Errorf("stats column %q cannot be excluded", stat.Column)
+		}
+	}
+	if err := c.validateVariables(); err != nil {
+		return err
+	}
+
+	if c.ComparisonType == TypeDataMatch {
+		if len(c.KeyColumns) == 0 {
+			return fmt.Errorf("key_columns are required for data_match")
+		}
+		if c.Strategy == "" {
+			c.Strategy = StrategyFull // Set default
+		}
+		if c.Strategy != StrategyFull && c.Strategy != StrategyHash {
+			return fmt.Errorf("strategy must be %q or %q", StrategyFull, StrategyHash)
+		}
+	}
+
+	if c.ComparisonType == TypeColumnStats {
+		if len(c.Stats) == 0 {
+			return fmt.Errorf("stats are required for column_stats")
+		}
+		for i := range c.Stats {
+			if err := c.Stats[i].validate(); err != nil {
+				return fmt.Errorf("stats[%d]: %w", i, err)
+			}
+		}
+	}
+	if len(c.GroupBy) > 0 {
+		switch c.ComparisonType {
+		case TypeColumnStats:
+		case TypeRowCount:
+			if countQueryPattern.MatchString(c.SourceQuery) || countQueryPattern.MatchString(c.TargetQuery) {
+				return fmt.Errorf("group_by needs queries that return rows, not SELECT COUNT(...)")
+			}
+		default:
+			return fmt.Errorf("group_by is only supported by row_count and column_stats")
+		}
+	}
+
+	if err := c.compileMapping(); err != nil {
+		return err
+	}
+	c.rules = make(map[string]*ColumnRule)
+	for column, rule := range c.ColumnRules {
+		rule := rule
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("column_rules.%s: %w", column, err)
+		}
+		c.rules[strings.ToLower(column)] = &rule
+	}
+
+	switch c.UnmappedColumns {
+	case "":
+		c.UnmappedColumns = UnmappedIgnore // Set default
+	case UnmappedIgnore, UnmappedFlag:
+	default:
+		return fmt.Errorf("unmapped_columns must be %q or %q", UnmappedIgnore, UnmappedFlag)
+	}
+	if (c.Strategy == StrategyHash || c.ComparisonType == TypeColumnStats) && (len(c.sourceTransforms) > 0 || len(c.targetTransforms) > 0) {
+		return fmt.Errorf("column transforms are evaluated by CompareFlow and cannot be used with the hash strategy or column_stats")
+	}
+
+	if c.Hash.Buckets == 0 {
+		c.Hash.Buckets = 256 // Set default
+	}
+	if c.Hash.Buckets < 2 || c.Hash.Buckets > 1<<16 || bits.OnesCount(uint(c.Hash.Buckets)) != 1 {
+		return fmt.Errorf("hash.buckets must be a power of two between 2 and 65536")
+	}
+	if c.Hash.LeafSize == 0 {
+		c.Hash.LeafSize = 10000 // Set default
+	}
+
+	switch c.ErrorMargin.Type {
+	case "":
+		c.ErrorMargin.Type = "absolute" // Set default
+	case "absolute", "percentage":
+	default:
+		return fmt.Errorf("error_margin.type must be absolute or percentage")
+	}
+	if c.ErrorMargin.Value < 0 {
+		return fmt.Errorf("error_margin.value must not be negative")
+	}
+
+	if c.Performance.MaxDifferences == 0 {
+		c.Performance.MaxDifferences = 1000 // Set default
+	}
+	if c.Performance.Chunks < 0 || c.Performance.Concurrency < 0 || (c.Performance.ChunkRetries != nil && *c.Performance.ChunkRetries < 0) {
+		return fmt.Errorf("performance.chunks, concurrency and chunk_retries must not be negative")
+	}
+	if c.Performance.Chunks == 0 {
+		c.Performance.Chunks = 1 // Set default
+	}
+	if c.Performance.Concurrency == 0 {
+		c.Performance.Concurrency = 4 // Set default
+	}
+	if c.Performance.ChunkRetries == nil {
+		retries := 2 // Set default
+		c.Performance.ChunkRetries = &retries
+	}
+	if c.Sample.Enabled() {
+		if err := c.Sample.validate(); err != nil {
+			return err
+		}
+		if c.ComparisonType != TypeDataMatch || c.Strategy != StrategyFull {
+			return fmt.Errorf("sample is only supported by data_match with the %q strategy", StrategyFull)
+		}
+		if c.Performance.Chunks > 1 {
+			return fmt.Errorf("sample cannot be combined with performance.chunks")
+		}
+		for _, key := range c.KeyColumns {
+			if c.sourceTransforms[strings.ToLower(key)] != nil || c.targetTransforms[strings.ToLower(c.TargetColumn(key))] != nil {
+				return fmt.Errorf("sample fetches target rows by key, so key columns must not have a transform")
+			}
+		}
+	}
+	if err := c.Retry.validate(); err != nil {
+		return err
+	}
+	if c.Performance.Chunks > 1 && len(c.KeyColumns) > 0 {
+		key := strings.ToLower(c.KeyColumns[0])
+		if c.sourceTransforms[key] != nil || c.targetTransforms[strings.ToLower(c.TargetColumn(key))] != nil {
+			return fmt.Errorf("performance.chunks splits on the first key column, which must not have a transform")
+		}
+	}
+	return nil
+}
+
+// compileMapping checks column_mapping and parses its transforms
+func (c *Config) compileMapping() error {
+	c.targetNames = make(map[string]string)
+	c.sourceTransforms = make(map[string]transform)
+	c.targetTransforms = make(map[string]transform)
+
+	targets := make(map[string]bool)
+	for i := range c.ColumnMapping {
+		m := &c.ColumnMapping[i]
+		if m.Source == "" {
+			return fmt.Errorf("column_mapping[%d].source is required", i)
+		}
+		if m.Target == "" {
+			m.Target = m.Source // Set default
+		}
+
+		source, target := strings.ToLower(m.Source), strings.ToLower(m.Target)
+		if _, exists := c.targetNames[source]; exists {
+			return fmt.Errorf("column_mapping: source column %q is mapped twice", m.Source)
+		}
+		if targets[target] {
+			return fmt.Errorf("column_mapping: target column %q is mapped twice", m.Target)
+		}
+		c.targetNames[source] = m.Target
+		targets[target] = true
+
+		if m.SourceTransform != "" {
+			t, err := parseTransform(m.SourceTransform)
+			if err != nil {
+				return fmt.Errorf("column_mapping[%d].source_transform: %w", i, err)
+			}
+			c.sourceTransforms[source] = t
+		}
+		if m.TargetTransform != "" {
+			t, err := parseTransform(m.TargetTransform)
+			if err != nil {
+				return fmt.Errorf("column_mapping[%d].target_transform: %w", i, err)
+			}
+			c.targetTransforms[target] = t
+		}
+	}
+	return nil
+}
+
+// TargetColumn returns the target column paired with a source column
+func (c *Config) TargetColumn(source string) string {
+	if target, exists := c.targetNames[strings.ToLower(source)]; exists {
+		return target
+	}
+	return source
+}
+
+// ruleFor returns the comparison rule of a source column, or nil for exact comparison
+func (c *Config) ruleFor(column string) *ColumnRule {
+	if rule, exists := c.rules[strings.ToLower(column)]; exists {
+		return rule
+	}
+	return c.rules[DefaultRuleColumn]
+}
+
+// TargetKeyColumns returns the key columns under their target names
+func (c *Config) TargetKeyColumns() []string {
+	keys := make([]string, len(c.KeyColumns))
+	for i, key := range c.KeyColumns {
+		keys[i] = c.TargetColumn(key)
+	}
+	return keys
+}
+
+// TargetGroupBy returns the group_by columns under their target names
+func (c *Config) TargetGroupBy() []string {
+	columns := make([]string, len(c.GroupBy))
+	for i, column := range c.GroupBy {
+		columns[i] = c.TargetColumn(column)
+	}
+	return columns
+}
+
+// targetStats returns the stats columns under their target names
+func (c *Config) targetStats() []StatsColumn {
+	stats := make([]StatsColumn, len(c.Stats))
+	for i, column := range c.Stats {
+		stats[i] = StatsColumn{Column: c.TargetColumn(column.Column), Metrics: column.Metrics}
+	}
+	return stats
+}
+
+// sourceColumns describes how source rows are keyed and transformed
+func (c *Config) sourceColumns() sideColumns {
+	return sideColumns{keys: c.KeyColumns, transforms: c.sourceTransforms}
+}
+
+// targetColumns describes how target rows are keyed and transformed
+func (c *Config) targetColumns() sideColumns {
+	return sideColumns{keys: c.TargetKeyColumns(), transforms: c.targetTransforms}
+}
+
+// Allows reports whether differences out of total rows are within the margin
+func (m ErrorMargin) Allows(differences, total int64) bool {
+	if m.Type == "percentage" {
+		if total == 0 {
+			return differences == 0
+		}
+		return float64(differences)*100/float64(total) <= m.Value
+	}
+	return float64(differences) <= m.Value
+}
@@ -0,0 +1,31 @@
+package validation
+
+import (
+	"context"
+)
+
+func init() {
+	Register(TypeDataMatch, func() Validator {
+		return &DataMatchValidator{}
+	})
+}
+
+// DataMatchValidator compares rows matched by key_columns
+type DataMatchValidator struct{}
+
+// Type returns the comparison type
+func (v *DataMatchValidator) Type() string {
+	return TypeDataMatch
+}
+
+// Validate compares both sides with the configured strategy
+func (v *DataMatchValidator) Validate(ctx context.Context, config *Config, source, target *Side) (*Result, error) {
+	if config.Strategy == StrategyHash {
+		return compareHashed(ctx, config, source, target)
+	}
+	if config.Sample.Enabled() {
+		return compareSampled(ctx, config, source, target)
+	}
+
+	return compareChunked(ctx, config, source, target)
+}
@@ -0,0 +1,303 @@
+package validation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+)
+
+const (
+	// hashSpace is the range of key hashes produced by connectors.Dialect.HashExpression
+	hashSpace = int64(1) << 32
+
+	// nullMarker stands in for NULL when rows are hashed
+	nullMarker = "'#NULL#'"
+
+	// inListSize caps the number of literals in a single IN list
+	inListSize = 1000
+)
+
+// bucketStats aggregates the rows that fall into one bucket
+type bucketStats struct {
+	rows, sum1, sum2 int64
+}
+
+// hashedQuery computes key and row hashes for one side in-database
+type hashedQuery struct {
+	side    *Side
+	query   string
+	columns sideColumns
+	// keyHash is the key hash expression over the columns of query
+	keyHash string
+	// hashed selects cf_key_hash, cf_row_hash1 and cf_row_hash2 for every row of query
+	hashed string
+}
+
+// compareHashed bisects the key hash space: buckets whose row count and hash
+// sums agree are counted as matched, mismatching buckets are split further
+// until they are small enough to fetch and diff row by row
+func compareHashed(ctx context.Context, config *Config, source, target *Side) (*Result, error) {
+	sourceQuery, targetQuery, err := newHashedQueries(ctx, config, source, target)
+	if err != nil {
+		return nil, err
+	}
+
+	buckets := int64(config.Hash.Buckets)
+	stats := &HashStats{Buckets: config.Hash.Buckets}
+	c := newComparison(config)
+
+	width := hashSpace / buckets
+	parentWidth := hashSpace
+	var parents []int64
+	leaves := make(map[int64][]int64) // bucket width -> bucket ids
+
+	for level := 0; ; level++ {
+		stats.Levels = level + 1
+
+		var sourceBuckets, targetBuckets map[int64]bucketStats
+		err := config.both(
+			func() (err error) {
+				sourceBuckets, err = sourceQuery.buckets(ctx, width, parents, parentWidth)
+				return err
+			},
+			func() (err error) {
+				targetBuckets, err = targetQuery.buckets(ctx, width, parents, parentWidth)
+				return err
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		stats.Queries += 2
+
+		if level == 0 {
+			for _, b := range sourceBuckets {
+				c.summary.SourceRowCount += b.rows
+			}
+			for _, b := range targetBuckets {
+				c.summary.TargetRowCount += b.rows
+			}
+		}
+
+		var next []int64
+		for _, id := range bucketIDs(sourceBuckets, targetBuckets) {
+			s, t := sourceBuckets[id], targetBuckets[id]
+			stats.BucketsCompared++
+			if s == t {
+				c.summary.MatchedRows += s.rows
+				continue
+			}
+			stats.BucketsMismatched++
+
+			if s.rows <= int64(config.Hash.LeafSize) && t.rows <= int64(config.Hash.LeafSize) || width/buckets == 0 {
+				leaves[width] = append(leaves[width], id)
+			} else {
+				next = append(next, id)
+			}
+		}
+
+		if len(next) == 0 {
+			break
+		}
+		parents, parentWidth, width = next, width, width/buckets
+	}
+
+	// Diff the leaves from the widest buckets down so differences come out in a stable order
+	widths := make([]int64, 0, len(leaves))
+	for width := range leaves {
+		widths = append(widths, width)
+	}
+	sort.Slice(widths, func(i, j int) bool { return widths[i] > widths[j] })
+
+	for _, width := range widths {
+		ids := leaves[width]
+		var sourceRows, targetRows *rowSet
+		err := config.both(
+			func() (err error) {
+				sourceRows, err = sourceQuery.rows(ctx, width, ids)
+				return err
+			},
+			func() (err error) {
+				targetRows, err = targetQuery.rows(ctx, width, ids)
+				return err
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		stats.Queries += 2
+		stats.RowsFetched += sourceRows.count() + targetRows.count()
+		c.compare(sourceRows, targetRows)
+	}
+
+	result := c.result(config)
+	result.Details.Hash = stats
+	return result, nil
+}
+
+// newHashedQueries builds the hash expressions for both sides over the columns they share
+func newHashedQueries(ctx context.Context, config *Config, source, target *Side) (*hashedQuery, *hashedQuery, error) {
+	sourceDialect, ok := source.Connector.(connectors.Dialect)
+	if !ok {
+		return nil, nil, fmt.Errorf("source: connector %s does not support hash pushdown", source.Connector.Type())
+	}
+	targetDialect, ok := target.Connector.(connectors.Dialect)
+	if !ok {
+		return nil, nil, fmt.Errorf("target: connector %s does not support hash pushdown", target.Connector.Type())
+	}
+
+	var sourceColumns, targetColumns []*sql.ColumnType
+	err := config.both(
+		func() (err error) {
+			sourceColumns, err = describeQuery(ctx, source, config.SourceQuery)
+			return err
+		},
+		func() (err error) {
+			targetColumns, err = describeQuery(ctx, target, config.TargetQuery)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Hash the paired columns in source order, so both sides feed the same
+	// values into the digest
+	var sourceShared, targetShared []*sql.ColumnType
+	for _, column := range sourceColumns {
+		for _, other := range targetColumns {
+			if strings.EqualFold(config.TargetColumn(column.Name()), other.Name()) {
+				sourceShared = append(sourceShared, column)
+				targetShared = append(targetShared, other)
+				break
+			}
+		}
+	}
+
+	sourceQuery, err := newHashedQuery(source, sourceDialect, config.SourceQuery, sourceShared, config.sourceColumns())
+	if err != nil {
+		return nil, nil, fmt.Errorf("source: %w", err)
+	}
+	targetQuery, err := newHashedQuery(target, targetDialect, config.TargetQuery, targetShared, config.targetColumns())
+	if err != nil {
+		return nil, nil, fmt.Errorf("target: %w", err)
+	}
+	return sourceQuery, targetQuery, nil
+}
+
+func newHashedQuery(side *Side, dialect connectors.Dialect, query string, columns []*sql.ColumnType, columnSpec sideColumns) (*hashedQuery, error) {
+	texts := make([]string, len(columns))
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name()
+		expr := dialect.NormalizeExpression(dialect.QuoteIdentifier(column.Name()), column.DatabaseTypeName())
+		texts[i] = "COALESCE(" + expr + ", " + nullMarker + ")"
+	}
+
+	keyTexts := make([]string, len(columnSpec.keys))
+	for i, key := range columnSpec.keys {
+		idx := columnIndex(names, key)
+		if idx < 0 {
+			return nil, fmt.Errorf("key column %q not found in both query results", key)
+		}
+		keyTexts[i] = texts[idx]
+	}
+
+	keyHash := dialect.HashExpression(keyTexts, 0)
+	hashed := fmt.Sprintf("SELECT %s AS cf_key_hash, %s AS cf_row_hash1, %s AS cf_row_hash2 FROM (%s) src",
+		keyHash, dialect.HashExpression(texts, 0), dialect.HashExpression(texts, 1), query)
+
+	return &hashedQuery{side: side, query: query, columns: columnSpec, keyHash: keyHash, hashed: hashed}, nil
+}
+
+// describeQuery returns the result columns of query without fetching rows
+func describeQuery(ctx context.Context, side *Side, query string) ([]*sql.ColumnType, error) {
+	rows, err := side.queryContext(ctx, "SELECT * FROM ("+query+") src WHERE 1 = 0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+	return columns, nil
+}
+
+// buckets aggregates rows into buckets of width key hashes. When parents is set,
+// only rows in those buckets of parentWidth are considered.
+func (h *hashedQuery) buckets(ctx context.Context, width int64, parents []int64, parentWidth int64) (map[int64]bucketStats, error) {
+	bucket := fmt.Sprintf("FLOOR(cf_key_hash / %d)", width)
+	query := "SELECT " + bucket + " AS cf_bucket, COUNT(*) AS cf_rows, SUM(cf_row_hash1) AS cf_sum1, SUM(cf_row_hash2) AS cf_sum2" +
+		" FROM (" + h.hashed + ") hashed"
+	if parents != nil {
+		query += " WHERE " + inList(fmt.Sprintf("FLOOR(cf_key_hash / %d)", parentWidth), parents)
+	}
+	query += " GROUP BY " + bucket
+
+	rows, err := h.side.queryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute bucket hashes: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int64]bucketStats)
+	for rows.Next() {
+		// Some databases return FLOOR as a floating point number
+		var id float64
+		var stats bucketStats
+		if err := rows.Scan(&id, &stats.rows, &stats.sum1, &stats.sum2); err != nil {
+			return nil, fmt.Errorf("failed to scan bucket hashes: %w", err)
+		}
+		result[int64(id)] = stats
+	}
+	return result, rows.Err()
+}
+
+// rows fetches the rows whose key hash falls into the given buckets
+func (h *hashedQuery) rows(ctx context.Context, width int64, ids []int64) (*rowSet, error) {
+	query := "SELECT * FROM (" + h.query + ") src WHERE " +
+		inList(fmt.Sprintf("FLOOR(%s / %d)", h.keyHash, width), ids)
+	return fetchRows(ctx, h.side, query, h.columns)
+}
+
+// inList renders expr IN (...) split into lists of at most inListSize values
+func inList(expr string, values []int64) string {
+	var lists []string
+	for start := 0; start < len(values); start += inListSize {
+		end := start + inListSize
+		if end > len(values) {
+			end = len(values)
+		}
+		literals := make([]string, end-start)
+		for i, value := range values[start:end] {
+			literals[i] = strconv.FormatInt(value, 10)
+		}
+		lists = append(lists, expr+" IN ("+strings.Join(literals, ", ")+")")
+	}
+	if len(lists) == 1 {
+		return lists[0]
+	}
+	return "(" + strings.Join(lists, " OR ") + ")"
+}
+
+// bucketIDs returns the union of bucket ids in ascending order
+func bucketIDs(a, b map[int64]bucketStats) []int64 {
+	ids := make([]int64, 0, len(a))
+	for id := range a {
+		ids = append(ids, id)
+	}
+	for id := range b {
+		if _, exists := a[id]; !exists {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
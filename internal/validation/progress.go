@@ -0,0 +1,104 @@
+package validation
+
+import (
+	"context"
+	"sync"
+)
+
+// Run stages
+const (
+	StagePlanning  = "planning"
+	StageComparing = "comparing"
+)
+
+// Chunk statuses
+const (
+	ChunkPending  = "pending"
+	ChunkRunning  = "running"
+	ChunkRetrying = "retrying"
+	ChunkDone     = "done"
+	ChunkFailed   = "failed"
+)
+
+// Progress is a snapshot of a running validation
+type Progress struct {
+	Stage         string          `json:"stage"`
+	ChunksTotal   int             `json:"chunks_total"`
+	ChunksDone    int             `json:"chunks_done"`
+	RowsProcessed int64           `json:"rows_processed"`
+	Chunks        []ChunkProgress `json:"chunks,omitempty"`
+}
+
+// ChunkProgress reports the state of one key range
+type ChunkProgress struct {
+	Index    int    `json:"index"`
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	Rows     int64  `json:"rows"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ProgressFunc receives progress snapshots; it must not block
+type ProgressFunc func(Progress)
+
+type progressKey struct{}
+
+// WithProgress returns a context whose runs report progress to fn
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// tracker accumulates progress and publishes a copy on every change
+type tracker struct {
+	mu       sync.Mutex
+	fn       ProgressFunc
+	progress Progress
+}
+
+func newTracker(ctx context.Context) *tracker {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return &tracker{fn: fn}
+}
+
+// update applies change and publishes the result
+func (t *tracker) update(change func(p *Progress)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	change(&t.progress)
+	if t.fn == nil {
+		return
+	}
+	snapshot := t.progress
+	snapshot.Chunks = append([]ChunkProgress(nil), t.progress.Chunks...)
+	t.fn(snapshot)
+}
+
+// stage sets the current stage
+func (t *tracker) stage(stage string) {
+	t.update(func(p *Progress) { p.Stage = stage })
+}
+
+// chunks registers the chunks of a run as pending
+func (t *tracker) chunks(n int) {
+	t.update(func(p *Progress) {
+		p.ChunksTotal = n
+		p.Chunks = make([]ChunkProgress, n)
+		for i := range p.Chunks {
+			p.Chunks[i] = ChunkProgress{Index: i, Status: ChunkPending}
+		}
+	})
+}
+
+// chunk updates the state of one chunk
+func (t *tracker) chunk(index int, change func(c *ChunkProgress)) {
+	t.update(func(p *Progress) {
+		c := &p.Chunks[index]
+		wasDone := c.Status == ChunkDone
+		change(c)
+		if c.Status == ChunkDone && !wasDone {
+			p.ChunksDone++
+			p.RowsProcessed += c.Rows
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package validation
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// Result statuses
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+	StatusError   = "error"
+)
+
+// Result is the outcome of a validation run
+type Result struct {
+	ExecutionID string            `json:"execution_id"`
+	StartTime   time.Time         `json:"start_time"`
+	EndTime     time.Time         `json:"end_time"`
+	DurationMS  int64             `json:"duration_ms"`
+	Status      string            `json:"status"`
+	Variables   map[string]string `json:"variables,omitempty"` // values the queries were run with
+	Summary     Summary           `json:"summary"`
+	Details     Details           `json:"details"`
+	Errors      []ErrorEntry      `json:"errors,omitempty"`
+}
+
+// Summary holds the row level totals of a run
+type Summary struct {
+	SourceRowCount  int64   `json:"source_row_count"`
+	TargetRowCount  int64   `json:"target_row_count"`
+	MatchedRows     int64   `json:"matched_rows"`
+	MismatchedRows  int64   `json:"mismatched_rows"`
+	MissingInTarget int64   `json:"missing_in_target"`
+	ExtraInTarget   int64   `json:"extra_in_target"`
+	DuplicateRows   int64   `json:"duplicate_rows,omitempty"` // rows whose key occurred earlier on the same side
+	SuccessRate     float64 `json:"success_rate"`
+}
+
+// Details holds the individual differences and strategy statistics
+type Details struct {
+	// SourceQuery and TargetQuery are the SQL generated for source_table and target_table
+	SourceQuery     string           `json:"source_query,omitempty"`
+	TargetQuery     string           `json:"target_query,omitempty"`
+	Strategy        string           `json:"strategy,omitempty"`
+	Differences     []Difference     `json:"differences"`
+	Truncated       bool             `json:"truncated,omitempty"`
+	UnmappedColumns *UnmappedColumns `json:"unmapped_columns,omitempty"`
+	RuleMatches     []RuleMatch      `json:"rule_matches,omitempty"`
+	Chunks          int              `json:"chunks,omitempty"`
+	Hash            *HashStats       `json:"hash,omitempty"`
+	Sample          *SampleStats     `json:"sample,omitempty"`
+	// ColumnStats holds the column_stats metrics over the whole result, by source column
+	ColumnStats map[string]*ColumnStats `json:"column_stats,omitempty"`
+	// Groups lists the groups that differ when group_by is set
+	Groups []GroupStats `json:"groups,omitempty"`
+}
+
+// UnmappedColumns lists columns without a counterpart on the other side
+type UnmappedColumns struct {
+	Source []string `json:"source"`
+	Target []string `json:"target"`
+}
+
+// Difference describes a row that differs between source and target
+type Difference struct {
+	Key        map[string]interface{} `json:"key"`
+	Type       string                 `json:"type"` // missing, extra, mismatch or duplicate
+	SourceData map[string]interface{} `json:"source_data,omitempty"`
+	TargetData map[string]interface{} `json:"target_data,omitempty"`
+	Columns    []string               `json:"columns,omitempty"`
+}
+
+// HashStats reports how much work the hash strategy did
+type HashStats struct {
+	Buckets           int   `json:"buckets"`
+	Levels            int   `json:"levels"`
+	BucketsCompared   int64 `json:"buckets_compared"`
+	BucketsMismatched int64 `json:"buckets_mismatched"`
+	RowsFetched       int64 `json:"rows_fetched"`
+	Queries           int   `json:"queries"`
+}
+
+// ColumnStats holds the metrics of one column on both sides. Metrics that
+// were not requested are omitted.
+type ColumnStats struct {
+	SourceSum           interface{} `json:"source_sum,omitempty"`
+	TargetSum           interface{} `json:"target_sum,omitempty"`
+	SourceMin           interface{} `json:"source_min,omitempty"`
+	TargetMin           interface{} `json:"target_min,omitempty"`
+	SourceMax           interface{} `json:"source_max,omitempty"`
+	TargetMax           interface{} `json:"target_max,omitempty"`
+	SourceAvg           interface{} `json:"source_avg,omitempty"`
+	TargetAvg           interface{} `json:"target_avg,omitempty"`
+	SourceCountDistinct interface{} `json:"source_count_distinct,omitempty"`
+	TargetCountDistinct interface{} `json:"target_count_distinct,omitempty"`
+	SourceNullCount     interface{} `json:"source_null_count,omitempty"`
+	TargetNullCount     interface{} `json:"target_null_count,omitempty"`
+	// Mismatched lists the metrics that differ beyond the column's rule
+	Mismatched []string `json:"mismatched,omitempty"`
+}
+
+// set records a metric of both sides
+func (s *ColumnStats) set(metric string, source, target interface{}) {
+	switch metric {
+	case MetricSum:
+		s.SourceSum, s.TargetSum = source, target
+	case MetricMin:
+		s.SourceMin, s.TargetMin = source, target
+	case MetricMax:
+		s.SourceMax, s.TargetMax = source, target
+	case MetricAvg:
+		s.SourceAvg, s.TargetAvg = source, target
+	case MetricCountDistinct:
+		s.SourceCountDistinct, s.TargetCountDistinct = source, target
+	case MetricNullCount:
+		s.SourceNullCount, s.TargetNullCount = source, target
+	}
+}
+
+// GroupStats describes a group that differs between source and target
+type GroupStats struct {
+	Group      map[string]interface{} `json:"group"`
+	Type       string                 `json:"type"` // missing, extra or mismatch
+	SourceRows int64                  `json:"source_rows"`
+	TargetRows int64                  `json:"target_rows"`
+	// Difference is target_rows minus source_rows
+	Difference int64                   `json:"difference"`
+	Columns    map[string]*ColumnStats `json:"columns,omitempty"`
+}
+
+func newGroupStats(sourceRows, targetRows int64) GroupStats {
+	return GroupStats{SourceRows: sourceRows, TargetRows: targetRows, Difference: targetRows - sourceRows}
+}
+
+// ErrorEntry records an error that stopped a run
+type ErrorEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+	Details   string    `json:"details,omitempty"`
+	Class     string    `json:"class,omitempty"` // one of the connectors.ErrorClass values
+}
+
+// Passed reports whether the run completed without exceeding the error margin
+func (r *Result) Passed() bool {
+	return r.Status == StatusSuccess
+}
+
+// ToMap converts the result into the generic form stored on a validation
+func (r *Result) ToMap() map[string]interface{} {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return map[string]interface{}{"status": StatusError, "errors": []ErrorEntry{{Message: err.Error()}}}
+	}
+	var m map[string]interface{}
+	json.Unmarshal(data, &m)
+	return m
+}
+
+// successRate returns matched as a percentage of the larger side, rounded to two decimals
+func successRate(matched, source, target int64) float64 {
+	total := source
+	if target > total {
+		total = target
+	}
+	if total == 0 {
+		return 100
+	}
+	return math.Round(float64(matched)*10000/float64(total)) / 100
+}
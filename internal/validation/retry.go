@@ -0,0 +1,81 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+)
+
+// RetryPolicy reruns a validation whose run ended in an error of a retryable
+// class. Data mismatches are never retried.
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt; 1 disables retries
+	MaxAttempts int `json:"max_attempts"`
+	// BackoffSeconds is the wait before the second attempt; each later wait
+	// is BackoffMultiplier times longer, up to MaxBackoffSeconds
+	BackoffSeconds    int     `json:"backoff_seconds"`
+	BackoffMultiplier float64 `json:"backoff_multiplier"`
+	MaxBackoffSeconds int     `json:"max_backoff_seconds"`
+	// RetryOn lists the retryable error classes
+	RetryOn []string `json:"retry_on"`
+}
+
+var errorClasses = []string{
+	connectors.ErrorClassConnection,
+	connectors.ErrorClassTimeout,
+	connectors.ErrorClassDeadlock,
+	connectors.ErrorClassUnavailable,
+	connectors.ErrorClassOther,
+}
+
+// validate checks the retry settings and applies defaults
+func (p *RetryPolicy) validate() error {
+	if p.MaxAttempts < 0 || p.BackoffSeconds < 0 || p.BackoffMultiplier < 0 || p.MaxBackoffSeconds < 0 {
+		return fmt.Errorf("retry settings must not be negative")
+	}
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = 1 // Set default
+	}
+	if p.BackoffSeconds == 0 {
+		p.BackoffSeconds = 30 // Set default
+	}
+	if p.BackoffMultiplier == 0 {
+		p.BackoffMultiplier = 2 // Set default
+	}
+	if p.BackoffMultiplier < 1 {
+		return fmt.Errorf("retry.backoff_multiplier must be at least 1")
+	}
+	if p.MaxBackoffSeconds == 0 {
+		p.MaxBackoffSeconds = 600 // Set default
+	}
+	if p.RetryOn == nil {
+		// Set default: everything but other, which would fail the same way again
+		p.RetryOn = append([]string(nil), errorClasses[:len(errorClasses)-1]...)
+	}
+	for _, class := range p.RetryOn {
+		if !containsString(errorClasses, class) {
+			return fmt.Errorf("retry.retry_on: unknown error class %q", class)
+		}
+	}
+	return nil
+}
+
+// Backoff returns the wait after the given failed attempt
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	seconds := float64(p.BackoffSeconds) * math.Pow(p.BackoffMultiplier, float64(attempt-1))
+	if seconds > float64(p.MaxBackoffSeconds) {
+		seconds = float64(p.MaxBackoffSeconds)
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Retryable reports whether a run's result should be retried after the given
+// attempt
+func (p *RetryPolicy) Retryable(result *Result, attempt int) bool {
+	if attempt >= p.MaxAttempts || result.Status != StatusError || len(result.Errors) == 0 {
+		return false
+	}
+	return containsString(p.RetryOn, result.Errors[0].Class)
+}
@@ -0,0 +1,144 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+func init() {
+	Register(TypeRowCount, func() Validator {
+		return &RowCountValidator{}
+	})
+}
+
+// countQueryPattern matches queries that already return a count
+var countQueryPattern = regexp.MustCompile(`(?is)^\s*select\s+count\s*\(`)
+
+// RowCountValidator compares the number of rows returned by each side
+type RowCountValidator struct{}
+
+// Type returns the comparison type
+func (v *RowCountValidator) Type() string {
+	return TypeRowCount
+}
+
+// Validate counts the rows on both sides and applies the error margin to the difference
+func (v *RowCountValidator) Validate(ctx context.Context, config *Config, source, target *Side) (*Result, error) {
+	if len(config.GroupBy) > 0 {
+		return countGroups(ctx, config, source, target)
+	}
+
+	var sourceCount, targetCount int64
+	err := config.both(
+		func() error { return countRows(ctx, source, config.SourceQuery, &sourceCount) },
+		func() error { return countRows(ctx, target, config.TargetQuery, &targetCount) },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := Summary{SourceRowCount: sourceCount, TargetRowCount: targetCount}
+	if sourceCount > targetCount {
+		summary.MatchedRows = targetCount
+		summary.MissingInTarget = sourceCount - targetCount
+	} else {
+		summary.MatchedRows = sourceCount
+		summary.ExtraInTarget = targetCount - sourceCount
+	}
+	summary.SuccessRate = successRate(summary.MatchedRows, sourceCount, targetCount)
+
+	status := StatusSuccess
+	if !config.ErrorMargin.Allows(summary.MissingInTarget+summary.ExtraInTarget, sourceCount) {
+		status = StatusFailure
+	}
+	return &Result{Status: status, Summary: summary}, nil
+}
+
+// countRows runs query as a count. Queries that start with SELECT COUNT( are
+// run as they are; anything else is wrapped in SELECT COUNT(*).
+func countRows(ctx context.Context, side *Side, query string, count *int64) error {
+	if !countQueryPattern.MatchString(query) {
+		query = "SELECT COUNT(*) FROM (" + query + ") src"
+	}
+	if err := side.queryRowContext(ctx, query).Scan(count); err != nil {
+		return fmt.Errorf("failed to count rows: %w", err)
+	}
+	return nil
+}
+
+// countGroups counts rows per group_by group. Differences are added up per
+// group, so a group that is short does not cancel out one that has too many.
+func countGroups(ctx context.Context, config *Config, source, target *Side) (*Result, error) {
+	var sourceGroups, targetGroups *rowSet
+	err := config.both(
+		func() (err error) {
+			sourceGroups, err = newStatsQuery(source, config.SourceQuery, nil, config.GroupBy).run(ctx)
+			return err
+		},
+		func() (err error) {
+			targetGroups, err = newStatsQuery(target, config.TargetQuery, nil, config.TargetGroupBy()).run(ctx)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(sourceGroups.rows))
+	for key := range sourceGroups.rows {
+		keys = append(keys, key)
+	}
+	for key := range targetGroups.rows {
+		if _, exists := sourceGroups.rows[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var summary Summary
+	var groups []GroupStats
+	truncated := false
+	for _, key := range keys {
+		sourceRow, inSource := sourceGroups.rows[key]
+		targetRow, inTarget := targetGroups.rows[key]
+		group := newGroupStats(rowCount(sourceGroups, sourceRow), rowCount(targetGroups, targetRow))
+		summary.SourceRowCount += group.SourceRows
+		summary.TargetRowCount += group.TargetRows
+		if group.SourceRows > group.TargetRows {
+			summary.MatchedRows += group.TargetRows
+			summary.MissingInTarget += group.SourceRows - group.TargetRows
+		} else {
+			summary.MatchedRows += group.SourceRows
+			summary.ExtraInTarget += group.TargetRows - group.SourceRows
+		}
+
+		switch {
+		case !inTarget:
+			group.Group, group.Type = sourceGroups.keyMap(sourceRow), "missing"
+		case !inSource:
+			group.Group, group.Type = targetGroups.keyMap(targetRow), "extra"
+		case group.Difference != 0:
+			group.Group, group.Type = sourceGroups.keyMap(sourceRow), "mismatch"
+		default:
+			continue
+		}
+		if len(groups) >= config.Performance.MaxDifferences {
+			truncated = true
+			continue
+		}
+		groups = append(groups, group)
+	}
+	summary.SuccessRate = successRate(summary.MatchedRows, summary.SourceRowCount, summary.TargetRowCount)
+
+	status := StatusSuccess
+	if !config.ErrorMargin.Allows(summary.MissingInTarget+summary.ExtraInTarget, summary.SourceRowCount) {
+		status = StatusFailure
+	}
+	return &Result{
+		Status:  status,
+		Summary: summary,
+		Details: Details{Differences: []Difference{}, Truncated: truncated, Groups: groups},
+	}, nil
+}
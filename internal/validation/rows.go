@@ -0,0 +1,334 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/values"
+)
+
+// rowSet holds the rows of one side indexed by key
+type rowSet struct {
+	columns []string
+	keyIdx  []int
+	rows    map[string][]values.Value // the first row of each key
+	// duplicates holds the further rows of keys that occur more than once
+	duplicates map[string][][]values.Value
+}
+
+func newRowSet() *rowSet {
+	return &rowSet{rows: make(map[string][]values.Value), duplicates: make(map[string][][]values.Value)}
+}
+
+// add indexes a row, keeping it as a duplicate when its key was seen before
+func (s *rowSet) add(key string, row []values.Value) {
+	if _, exists := s.rows[key]; exists {
+		s.duplicates[key] = append(s.duplicates[key], row)
+		return
+	}
+	s.rows[key] = row
+}
+
+// count returns the number of rows fetched, duplicates included
+func (s *rowSet) count() int64 {
+	n := len(s.rows)
+	for _, rows := range s.duplicates {
+		n += len(rows)
+	}
+	return int64(n)
+}
+
+// sideColumns describes how one side's rows are keyed and transformed
+type sideColumns struct {
+	keys       []string
+	transforms map[string]transform // by lower-case column name
+}
+
+// fetchRows runs query, maps the values into canonical form, applies the
+// column transforms and indexes the rows by key
+func fetchRows(ctx context.Context, side *Side, query string, columnSpec sideColumns, args ...interface{}) (*rowSet, error) {
+	rows, err := side.queryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column types: %w", err)
+	}
+
+	set := newRowSet()
+	set.columns = columns
+	transforms := make([]transform, len(columns))
+	for i, column := range columns {
+		transforms[i] = columnSpec.transforms[strings.ToLower(column)]
+	}
+	for _, key := range columnSpec.keys {
+		idx := columnIndex(columns, key)
+		if idx < 0 {
+			return nil, fmt.Errorf("key column %q not found in query result", key)
+		}
+		set.keyIdx = append(set.keyIdx, idx)
+	}
+
+	for rows.Next() {
+		scanned := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range scanned {
+			pointers[i] = &scanned[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		row := make([]values.Value, len(columns))
+		for i, v := range scanned {
+			if row[i], err = connectors.MapValue(side.Connector, v, columnTypes[i].DatabaseTypeName()); err != nil {
+				return nil, fmt.Errorf("column %s: %w", columns[i], err)
+			}
+			if transforms[i] == nil {
+				continue
+			}
+			if row[i], err = transforms[i].apply(row[i]); err != nil {
+				return nil, fmt.Errorf("column %s: %w", columns[i], err)
+			}
+		}
+		set.add(set.key(row), row)
+	}
+
+	return set, rows.Err()
+}
+
+// key builds the lookup key of a row from the canonical key encoding of its
+// key columns, so that NULL and equal values of different types match
+func (s *rowSet) key(row []values.Value) string {
+	var b strings.Builder
+	for _, idx := range s.keyIdx {
+		b.WriteString(row[idx].Key())
+	}
+	return b.String()
+}
+
+// keyMap returns the key columns of a row by name
+func (s *rowSet) keyMap(row []values.Value) map[string]interface{} {
+	m := make(map[string]interface{}, len(s.keyIdx))
+	for _, idx := range s.keyIdx {
+		m[s.columns[idx]] = row[idx].Interface()
+	}
+	return m
+}
+
+// rowMap returns a row by column name
+func (s *rowSet) rowMap(row []values.Value) map[string]interface{} {
+	m := make(map[string]interface{}, len(s.columns))
+	for i, column := range s.columns {
+		m[column] = row[i].Interface()
+	}
+	return m
+}
+
+// columnIndex finds a column by case-insensitive name
+func columnIndex(columns []string, name string) int {
+	for i, column := range columns {
+		if strings.EqualFold(column, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// comparison accumulates the outcome of comparing row sets
+type comparison struct {
+	config      *Config
+	summary     Summary
+	differences []Difference
+	truncated   bool
+	unmapped    *UnmappedColumns
+	rules       ruleMatches
+}
+
+func newComparison(config *Config) *comparison {
+	return &comparison{config: config, rules: make(ruleMatches)}
+}
+
+// compare diffs two row sets by key. Only columns paired by name or
+// column_mapping are compared.
+func (c *comparison) compare(source, target *rowSet) {
+	type columnPair struct {
+		name           string
+		source, target int
+	}
+	var pairs []columnPair
+	paired := make([]bool, len(target.columns))
+	unmapped := &UnmappedColumns{Source: []string{}, Target: []string{}}
+	for i, column := range source.columns {
+		j := columnIndex(target.columns, c.config.TargetColumn(column))
+		if j < 0 {
+			unmapped.Source = append(unmapped.Source, column)
+			continue
+		}
+		paired[j] = true
+		if !containsIndex(source.keyIdx, i) {
+			pairs = append(pairs, columnPair{name: column, source: i, target: j})
+		}
+	}
+	for j, column := range target.columns {
+		if !paired[j] {
+			unmapped.Target = append(unmapped.Target, column)
+		}
+	}
+	if c.unmapped == nil && c.config.UnmappedColumns == UnmappedFlag && len(unmapped.Source)+len(unmapped.Target) > 0 {
+		c.unmapped = unmapped
+	}
+
+	keys := make([]string, 0, len(source.rows))
+	for key := range source.rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sourceRow := source.rows[key]
+		targetRow, exists := target.rows[key]
+		if !exists {
+			c.summary.MissingInTarget++
+			c.add(Difference{Key: source.keyMap(sourceRow), Type: "missing", SourceData: source.rowMap(sourceRow)})
+			continue
+		}
+
+		var mismatched []string
+		var credited [][2]string // column, rule
+		for _, pair := range pairs {
+			equal, rule := c.config.ruleFor(pair.name).equal(sourceRow[pair.source], targetRow[pair.target])
+			if !equal {
+				mismatched = append(mismatched, pair.name)
+			} else if rule != "" {
+				credited = append(credited, [2]string{pair.name, rule})
+			}
+		}
+		if len(mismatched) == 0 {
+			c.summary.MatchedRows++
+			for _, match := range credited {
+				c.rules.add(match[0], match[1], source.keyMap(sourceRow))
+			}
+			continue
+		}
+		c.summary.MismatchedRows++
+		c.add(Difference{
+			Key:        source.keyMap(sourceRow),
+			Type:       "mismatch",
+			SourceData: source.rowMap(sourceRow),
+			TargetData: target.rowMap(targetRow),
+			Columns:    mismatched,
+		})
+	}
+
+	extra := make([]string, 0)
+	for key := range target.rows {
+		if _, exists := source.rows[key]; !exists {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	for _, key := range extra {
+		targetRow := target.rows[key]
+		c.summary.ExtraInTarget++
+		c.add(Difference{Key: target.keyMap(targetRow), Type: "extra", TargetData: target.rowMap(targetRow)})
+	}
+
+	c.addDuplicates(source, true)
+	c.addDuplicates(target, false)
+}
+
+// addDuplicates reports the rows of a side whose key occurred earlier on
+// the same side. Only the first row of a key is compared with the other side.
+func (c *comparison) addDuplicates(set *rowSet, source bool) {
+	keys := make([]string, 0, len(set.duplicates))
+	for key := range set.duplicates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, row := range set.duplicates[key] {
+			c.summary.DuplicateRows++
+			difference := Difference{Key: set.keyMap(row), Type: "duplicate"}
+			if source {
+				difference.SourceData = set.rowMap(row)
+			} else {
+				difference.TargetData = set.rowMap(row)
+			}
+			c.add(difference)
+		}
+	}
+}
+
+// add records a difference unless the limit has been reached
+func (c *comparison) add(difference Difference) {
+	if len(c.differences) >= c.config.Performance.MaxDifferences {
+		c.truncated = true
+		return
+	}
+	c.differences = append(c.differences, difference)
+}
+
+// merge adds the outcome of another comparison, e.g. of one chunk
+func (c *comparison) merge(other *comparison) {
+	c.summary.SourceRowCount += other.summary.SourceRowCount
+	c.summary.TargetRowCount += other.summary.TargetRowCount
+	c.summary.MatchedRows += other.summary.MatchedRows
+	c.summary.MismatchedRows += other.summary.MismatchedRows
+	c.summary.MissingInTarget += other.summary.MissingInTarget
+	c.summary.ExtraInTarget += other.summary.ExtraInTarget
+	c.summary.DuplicateRows += other.summary.DuplicateRows
+	for _, difference := range other.differences {
+		c.add(difference)
+	}
+	if other.truncated {
+		c.truncated = true
+	}
+	if c.unmapped == nil {
+		c.unmapped = other.unmapped
+	}
+	c.rules.merge(other.rules)
+}
+
+// result builds the run result, applying the error margin
+func (c *comparison) result(config *Config) *Result {
+	summary := c.summary
+	summary.SuccessRate = successRate(summary.MatchedRows, summary.SourceRowCount, summary.TargetRowCount)
+
+	status := StatusSuccess
+	differences := summary.MismatchedRows + summary.MissingInTarget + summary.ExtraInTarget + summary.DuplicateRows
+	if !config.ErrorMargin.Allows(differences, summary.SourceRowCount) {
+		status = StatusFailure
+	}
+
+	return &Result{
+		Status:  status,
+		Summary: summary,
+		Details: Details{
+			Strategy:        config.Strategy,
+			Differences:     c.differences,
+			Truncated:       c.truncated,
+			UnmappedColumns: c.unmapped,
+			RuleMatches:     c.rules.list(),
+		},
+	}
+}
+
+func containsIndex(indexes []int, i int) bool {
+	for _, idx := range indexes {
+		if idx == i {
+			return true
+		}
+	}
+	return false
+}
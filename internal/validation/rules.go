@@ -0,0 +1,225 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/values"
+)
+
+// Rule names reported in details.rule_matches
+const (
+	RuleNullEqualsEmpty   = "null_equals_empty"
+	RuleIgnoreWhitespace  = "ignore_whitespace"
+	RuleCaseInsensitive   = "case_insensitive"
+	RuleTruncateTo        = "truncate_to"
+	RuleAbsoluteTolerance = "absolute_tolerance"
+	RuleRelativeTolerance = "relative_tolerance"
+	RuleFloatEpsilon      = "float_epsilon"
+)
+
+// DefaultRuleColumn is the column_rules key whose rule applies to every
+// column without a rule of its own
+const DefaultRuleColumn = "*"
+
+// maxRuleSampleKeys caps the keys recorded per rule match
+const maxRuleSampleKeys = 5
+
+// ColumnRule relaxes how the values of one column are compared
+type ColumnRule struct {
+	// AbsoluteTolerance accepts numbers differing by at most this much
+	AbsoluteTolerance float64 `json:"absolute_tolerance,omitempty"`
+	// RelativeTolerance accepts numbers differing by at most this fraction of the larger magnitude
+	RelativeTolerance float64 `json:"relative_tolerance,omitempty"`
+	// FloatEpsilon compares as 64-bit floats, accepting differences up to epsilon
+	FloatEpsilon float64 `json:"float_epsilon,omitempty"`
+	// TruncateTo compares timestamps truncated to day, hour, minute, second, millisecond or microsecond
+	TruncateTo string `json:"truncate_to,omitempty"`
+	// CaseInsensitive compares text ignoring case
+	CaseInsensitive bool `json:"case_insensitive,omitempty"`
+	// IgnoreWhitespace compares text with leading and trailing whitespace
+	// removed and inner runs collapsed to one space
+	IgnoreWhitespace bool `json:"ignore_whitespace,omitempty"`
+	// NullEqualsEmpty treats NULL and the empty string as equal
+	NullEqualsEmpty bool `json:"null_equals_empty,omitempty"`
+}
+
+// RuleMatch counts the rows that passed only because of a column rule
+type RuleMatch struct {
+	Column     string                   `json:"column"`
+	Rule       string                   `json:"rule"`
+	Rows       int64                    `json:"rows"`
+	SampleKeys []map[string]interface{} `json:"sample_keys,omitempty"`
+}
+
+// truncateUnits are the units accepted by truncate_to
+var truncateUnits = map[string]time.Duration{
+	"day":         24 * time.Hour,
+	"hour":        time.Hour,
+	"minute":      time.Minute,
+	"second":      time.Second,
+	"millisecond": time.Millisecond,
+	"microsecond": time.Microsecond,
+}
+
+// validate checks a rule's settings
+func (r *ColumnRule) validate() error {
+	if r.AbsoluteTolerance < 0 || r.RelativeTolerance < 0 || r.FloatEpsilon < 0 {
+		return fmt.Errorf("tolerances must not be negative")
+	}
+	if r.TruncateTo != "" {
+		if _, exists := truncateUnits[r.TruncateTo]; !exists {
+			return fmt.Errorf("truncate_to must be one of day, hour, minute, second, millisecond or microsecond")
+		}
+	}
+	return nil
+}
+
+// equal compares two values under the rule. When they differ exactly but
+// the rule accepts them, it returns the name of the rule that made them equal.
+func (r *ColumnRule) equal(a, b values.Value) (bool, string) {
+	if values.Equal(a, b) {
+		return true, ""
+	}
+	if r == nil {
+		return false, ""
+	}
+
+	// Normalizing rules apply cumulatively; the first one after which the
+	// values agree is credited
+	if r.NullEqualsEmpty {
+		a, b = nullToEmpty(a), nullToEmpty(b)
+		if values.Equal(a, b) {
+			return true, RuleNullEqualsEmpty
+		}
+	}
+	if a.IsNull() || b.IsNull() {
+		return false, ""
+	}
+	if r.IgnoreWhitespace {
+		a, b = values.String(collapseWhitespace(a.String())), values.String(collapseWhitespace(b.String()))
+		if a.String() == b.String() {
+			return true, RuleIgnoreWhitespace
+		}
+	}
+	if r.CaseInsensitive && strings.EqualFold(a.String(), b.String()) {
+		return true, RuleCaseInsensitive
+	}
+	if r.TruncateTo != "" {
+		if ta, tb, ok := truncatedTimes(a, b, r.TruncateTo); ok && ta.Equal(tb) {
+			return true, RuleTruncateTo
+		}
+	}
+
+	// Tolerances compare numbers
+	if r.AbsoluteTolerance > 0 || r.RelativeTolerance > 0 {
+		ra, errA := toRat(a)
+		rb, errB := toRat(b)
+		if errA == nil && errB == nil {
+			diff := new(big.Rat).Abs(new(big.Rat).Sub(ra, rb))
+			if r.AbsoluteTolerance > 0 && diff.Cmp(new(big.Rat).SetFloat64(r.AbsoluteTolerance)) <= 0 {
+				return true, RuleAbsoluteTolerance
+			}
+			if r.RelativeTolerance > 0 {
+				magnitude := new(big.Rat).Abs(ra)
+				if abs := new(big.Rat).Abs(rb); abs.Cmp(magnitude) > 0 {
+					magnitude = abs
+				}
+				limit := magnitude.Mul(magnitude, new(big.Rat).SetFloat64(r.RelativeTolerance))
+				if diff.Cmp(limit) <= 0 {
+					return true, RuleRelativeTolerance
+				}
+			}
+		}
+	}
+	if r.FloatEpsilon > 0 {
+		ra, errA := toRat(a)
+		rb, errB := toRat(b)
+		if errA == nil && errB == nil {
+			fa, _ := ra.Float64()
+			fb, _ := rb.Float64()
+			if math.Abs(fa-fb) <= r.FloatEpsilon {
+				return true, RuleFloatEpsilon
+			}
+		}
+	}
+	return false, ""
+}
+
+func nullToEmpty(v values.Value) values.Value {
+	if v.IsNull() {
+		return values.String("")
+	}
+	return v
+}
+
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncatedTimes reads both values as timestamps and truncates them in UTC
+func truncatedTimes(a, b values.Value, unit string) (time.Time, time.Time, bool) {
+	ta, okA := a.Time()
+	tb, okB := b.Time()
+	if !okA || !okB {
+		return time.Time{}, time.Time{}, false
+	}
+	d := truncateUnits[unit]
+	return ta.UTC().Truncate(d), tb.UTC().Truncate(d), true
+}
+
+// ruleMatches accumulates rule matches by column and rule
+type ruleMatches map[[2]string]*RuleMatch
+
+// add credits a rule with one row
+func (m ruleMatches) add(column, rule string, key map[string]interface{}) {
+	match, exists := m[[2]string{column, rule}]
+	if !exists {
+		match = &RuleMatch{Column: column, Rule: rule}
+		m[[2]string{column, rule}] = match
+	}
+	match.Rows++
+	if len(match.SampleKeys) < maxRuleSampleKeys {
+		match.SampleKeys = append(match.SampleKeys, key)
+	}
+}
+
+// merge adds the matches of another set
+func (m ruleMatches) merge(other ruleMatches) {
+	for id, match := range other {
+		existing, exists := m[id]
+		if !exists {
+			copied := *match
+			m[id] = &copied
+			continue
+		}
+		existing.Rows += match.Rows
+		for _, key := range match.SampleKeys {
+			if len(existing.SampleKeys) < maxRuleSampleKeys {
+				existing.SampleKeys = append(existing.SampleKeys, key)
+			}
+		}
+	}
+}
+
+// list returns the matches ordered by column and rule
+func (m ruleMatches) list() []RuleMatch {
+	if len(m) == 0 {
+		return nil
+	}
+	matches := make([]RuleMatch, 0, len(m))
+	for _, match := range m {
+		matches = append(matches, *match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Column != matches[j].Column {
+			return matches[i].Column < matches[j].Column
+		}
+		return matches[i].Rule < matches[j].Rule
+	})
+	return matches
+}
@@ -0,0 +1,45 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/values"
+)
+
+func TestColumnRule_Equal(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 30, 15, 123456000, time.UTC)
+
+	tests := []struct {
+		name     string
+		rule     *ColumnRule
+		a, b     interface{}
+		want     bool
+		wantRule string
+	}{
+		{"exact without rule", nil, int64(1), "1", true, ""},
+		{"different without rule", nil, "a", "A", false, ""},
+		{"null is not empty", nil, nil, "", false, ""},
+		{"null equals empty", &ColumnRule{NullEqualsEmpty: true}, nil, "", true, RuleNullEqualsEmpty},
+		{"case insensitive", &ColumnRule{CaseInsensitive: true}, "Alice", "ALICE", true, RuleCaseInsensitive},
+		{"whitespace", &ColumnRule{IgnoreWhitespace: true}, " New  York", "New York ", true, RuleIgnoreWhitespace},
+		{"whitespace then case", &ColumnRule{IgnoreWhitespace: true, CaseInsensitive: true}, " new york", "NEW YORK", true, RuleCaseInsensitive},
+		{"truncate to second", &ColumnRule{TruncateTo: "second"}, ts, ts.Truncate(time.Millisecond), true, RuleTruncateTo},
+		{"truncate to second from text", &ColumnRule{TruncateTo: "second"}, ts, "2024-03-01 12:30:15", true, RuleTruncateTo},
+		{"truncate too fine", &ColumnRule{TruncateTo: "microsecond"}, ts, ts.Truncate(time.Second), false, ""},
+		{"absolute tolerance", &ColumnRule{AbsoluteTolerance: 0.01}, "10.00", []byte("10.01"), true, RuleAbsoluteTolerance},
+		{"absolute tolerance exceeded", &ColumnRule{AbsoluteTolerance: 0.01}, "10.00", "10.02", false, ""},
+		{"relative tolerance", &ColumnRule{RelativeTolerance: 0.001}, int64(100000), int64(100090), true, RuleRelativeTolerance},
+		{"float epsilon", &ColumnRule{FloatEpsilon: 1e-9}, 1.0000000001, 1.0, true, RuleFloatEpsilon},
+		{"tolerance ignores text", &ColumnRule{AbsoluteTolerance: 1}, "abc", "abd", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rule := tt.rule.equal(values.Of(tt.a), values.Of(tt.b))
+			if got != tt.want || rule != tt.wantRule {
+				t.Errorf("equal(%v, %v) = %v, %q; want %v, %q", tt.a, tt.b, got, rule, tt.want, tt.wantRule)
+			}
+		})
+	}
+}
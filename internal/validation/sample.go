@@ -0,0 +1,300 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/values"
+)
+
+// SampleHashMod picks the source rows whose key hash, salted with the seed,
+// falls below a threshold. The same seed picks the same keys on every run.
+const SampleHashMod = "hash_mod"
+
+const (
+	// sampleHashColumn carries the key hash of sampled source rows
+	sampleHashColumn = "cf_sample_hash"
+
+	// maxKeyParams caps the bind parameters of one target query; SQL Server
+	// accepts at most 2100
+	maxKeyParams = 2000
+)
+
+// SampleOptions restricts a data_match to a deterministic sample of source keys
+type SampleOptions struct {
+	// Percent samples about this percentage of the source rows
+	Percent float64 `json:"percent,omitempty"`
+	// Rows samples exactly this many source rows, or all if there are fewer
+	Rows int `json:"rows,omitempty"`
+	// Seed varies which keys are picked
+	Seed   int64  `json:"seed,omitempty"`
+	Method string `json:"method,omitempty"`
+}
+
+// SampleStats records how a sampled run picked its keys
+type SampleStats struct {
+	Method      string  `json:"method"`
+	Seed        int64   `json:"seed"`
+	Percent     float64 `json:"percent,omitempty"`
+	Rows        int     `json:"rows,omitempty"`
+	SampledRows int64   `json:"sampled_rows"`
+}
+
+// Enabled reports whether sampling is configured
+func (s *SampleOptions) Enabled() bool {
+	return s.Percent != 0 || s.Rows != 0
+}
+
+// validate checks the sample settings and applies defaults
+func (s *SampleOptions) validate() error {
+	if s.Percent != 0 && s.Rows != 0 {
+		return fmt.Errorf("sample.percent and sample.rows are mutually exclusive")
+	}
+	if s.Percent < 0 || s.Percent > 100 {
+		return fmt.Errorf("sample.percent must be between 0 and 100")
+	}
+	if s.Rows < 0 {
+		return fmt.Errorf("sample.rows must not be negative")
+	}
+	if s.Method == "" {
+		s.Method = SampleHashMod // Set default
+	}
+	if s.Method != SampleHashMod {
+		return fmt.Errorf("sample.method must be %q", SampleHashMod)
+	}
+	return nil
+}
+
+// compareSampled picks a sample of keys on the source and fetches the target
+// rows with the same keys. Rows that exist only in the target are not seen.
+func compareSampled(ctx context.Context, config *Config, source, target *Side) (*Result, error) {
+	sourceDialect, ok := source.Connector.(connectors.Dialect)
+	if !ok {
+		return nil, fmt.Errorf("source: connector %s does not support sampling", source.Connector.Type())
+	}
+	targetDialect, ok := target.Connector.(connectors.Dialect)
+	if !ok {
+		return nil, fmt.Errorf("target: connector %s does not support sampling", target.Connector.Type())
+	}
+
+	sample := config.Sample
+	sourceRows, err := sampleSource(ctx, config, source, sourceDialect)
+	if err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	targetRows, err := fetchByKeys(ctx, config, target, targetDialect, sourceRows)
+	if err != nil {
+		return nil, fmt.Errorf("target: %w", err)
+	}
+
+	c := newComparison(config)
+	c.summary.SourceRowCount = sourceRows.count()
+	c.summary.TargetRowCount = targetRows.count()
+	c.compare(sourceRows, targetRows)
+
+	result := c.result(config)
+	result.Details.Sample = &SampleStats{
+		Method:      sample.Method,
+		Seed:        sample.Seed,
+		Percent:     sample.Percent,
+		Rows:        sample.Rows,
+		SampledRows: sourceRows.count(),
+	}
+	return result, nil
+}
+
+// sampleSource fetches the source rows whose salted key hash falls below a
+// threshold. For a fixed number of rows the threshold starts from the
+// expected fraction and doubles until enough rows come back, then the rows
+// with the lowest hashes are kept.
+func sampleSource(ctx context.Context, config *Config, source *Side, dialect connectors.Dialect) (*rowSet, error) {
+	columns, err := describeQuery(ctx, source, config.SourceQuery)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name()
+	}
+
+	texts := []string{"'" + strconv.FormatInt(config.Sample.Seed, 10) + "'"}
+	for _, key := range config.KeyColumns {
+		idx := columnIndex(names, key)
+		if idx < 0 {
+			return nil, fmt.Errorf("key column %q not found in query result", key)
+		}
+		expr := dialect.NormalizeExpression(dialect.QuoteIdentifier(names[idx]), columns[idx].DatabaseTypeName())
+		texts = append(texts, "COALESCE("+expr+", "+nullMarker+")")
+	}
+	keyHash := dialect.HashExpression(texts, 0)
+
+	var threshold int64
+	if config.Sample.Percent > 0 {
+		threshold = int64(config.Sample.Percent / 100 * float64(hashSpace))
+	} else {
+		var total int64
+		if err := countRows(ctx, source, config.SourceQuery, &total); err != nil {
+			return nil, err
+		}
+		if total == 0 {
+			threshold = hashSpace
+		} else {
+			// Aim a little high so one query usually suffices
+			threshold = int64(float64(config.Sample.Rows) / float64(total) * 1.2 * float64(hashSpace))
+		}
+		if threshold < 1 {
+			threshold = 1
+		}
+	}
+
+	for {
+		if threshold > hashSpace {
+			threshold = hashSpace
+		}
+		query := fmt.Sprintf("SELECT src.*, %s AS %s FROM (%s) src WHERE %s < %d",
+			keyHash, sampleHashColumn, config.SourceQuery, keyHash, threshold)
+		rows, err := fetchRows(ctx, source, query, config.sourceColumns())
+		if err != nil {
+			return nil, err
+		}
+		hashes := rows.extract(sampleHashColumn)
+		if config.Sample.Rows == 0 {
+			return rows, nil
+		}
+		if len(rows.rows) >= config.Sample.Rows || threshold == hashSpace {
+			rows.keepLowest(hashes, config.Sample.Rows)
+			return rows, nil
+		}
+		threshold *= 2
+	}
+}
+
+// fetchByKeys fetches the target rows with the keys of the source rows, in
+// batches that stay within the bind parameter limit
+func fetchByKeys(ctx context.Context, config *Config, target *Side, dialect connectors.Dialect, source *rowSet) (*rowSet, error) {
+	keys := config.TargetKeyColumns()
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = dialect.QuoteIdentifier(key)
+	}
+
+	sourceKeys := make([]string, 0, len(source.rows))
+	for key := range source.rows {
+		sourceKeys = append(sourceKeys, key)
+	}
+	sort.Strings(sourceKeys)
+
+	result := newRowSet()
+	batch := (maxKeyParams - len(target.args)) / len(keys)
+	for start := 0; start < len(sourceKeys); start += batch {
+		end := start + batch
+		if end > len(sourceKeys) {
+			end = len(sourceKeys)
+		}
+
+		var conditions []string
+		var args []interface{}
+		for _, key := range sourceKeys[start:end] {
+			row := source.rows[key]
+			terms := make([]string, len(quoted))
+			for i, idx := range source.keyIdx {
+				if row[idx].IsNull() {
+					terms[i] = quoted[i] + " IS NULL"
+					continue
+				}
+				args = append(args, bindValue(row[idx]))
+				terms[i] = quoted[i] + " = " + dialect.Placeholder(len(target.args)+len(args))
+			}
+			conditions = append(conditions, "("+strings.Join(terms, " AND ")+")")
+		}
+
+		query := "SELECT * FROM (" + config.TargetQuery + ") src WHERE " + strings.Join(conditions, " OR ")
+		rows, err := fetchRows(ctx, target, query, config.targetColumns(), args...)
+		if err != nil {
+			return nil, err
+		}
+		result.columns, result.keyIdx = rows.columns, rows.keyIdx
+		for key, row := range rows.rows {
+			result.rows[key] = row
+		}
+		for key, duplicates := range rows.duplicates {
+			result.duplicates[key] = duplicates
+		}
+	}
+
+	if result.columns == nil {
+		// Nothing was sampled; describe the target so columns still pair up
+		columns, err := describeQuery(ctx, target, config.TargetQuery)
+		if err != nil {
+			return nil, err
+		}
+		for _, column := range columns {
+			result.columns = append(result.columns, column.Name())
+		}
+	}
+	return result, nil
+}
+
+// bindValue converts a key value into a bind parameter
+func bindValue(v values.Value) interface{} {
+	switch v.Kind() {
+	case values.KindDate, values.KindTimestamp:
+		t, _ := v.Time()
+		return t
+	}
+	return v.Interface()
+}
+
+// extract removes a column from the set and returns its values by row key
+func (s *rowSet) extract(column string) map[string]values.Value {
+	idx := columnIndex(s.columns, column)
+	if idx < 0 {
+		return nil
+	}
+	extracted := make(map[string]values.Value, len(s.rows))
+	for key, row := range s.rows {
+		extracted[key] = row[idx]
+		s.rows[key] = append(row[:idx:idx], row[idx+1:]...)
+	}
+	for _, duplicates := range s.duplicates {
+		for i, row := range duplicates {
+			duplicates[i] = append(row[:idx:idx], row[idx+1:]...)
+		}
+	}
+	s.columns = append(s.columns[:idx:idx], s.columns[idx+1:]...)
+	for i, keyIdx := range s.keyIdx {
+		if keyIdx > idx {
+			s.keyIdx[i]--
+		}
+	}
+	return extracted
+}
+
+// keepLowest keeps the n rows with the lowest hashes, breaking ties by key
+func (s *rowSet) keepLowest(hashes map[string]values.Value, n int) {
+	if len(s.rows) <= n {
+		return
+	}
+	keys := make([]string, 0, len(s.rows))
+	ranks := make(map[string]float64, len(s.rows))
+	for key := range s.rows {
+		keys = append(keys, key)
+		if r, ok := hashes[key].Rat(); ok {
+			ranks[key], _ = r.Float64()
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if ranks[keys[i]] != ranks[keys[j]] {
+			return ranks[keys[i]] < ranks[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys[n:] {
+		delete(s.rows, key)
+		delete(s.duplicates, key)
+	}
+}
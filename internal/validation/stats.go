@@ -0,0 +1,350 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+	"github.com/compareflow/compareflow/internal/values"
+)
+
+func init() {
+	Register(TypeColumnStats, func() Validator {
+		return &ColumnStatsValidator{}
+	})
+}
+
+// Metrics computed by column_stats
+const (
+	MetricSum           = "sum"
+	MetricMin           = "min"
+	MetricMax           = "max"
+	MetricAvg           = "avg"
+	MetricCountDistinct = "count_distinct"
+	MetricNullCount     = "null_count"
+)
+
+// statsMetrics lists every metric in report order
+var statsMetrics = []string{MetricSum, MetricMin, MetricMax, MetricAvg, MetricCountDistinct, MetricNullCount}
+
+// avgPlaces is the number of decimal places averages are compared at, since
+// databases return averages at different scales
+const avgPlaces = 6
+
+// StatsColumn selects a column and the metrics column_stats computes for it
+type StatsColumn struct {
+	Column  string   `json:"column"`
+	Metrics []string `json:"metrics,omitempty"`
+}
+
+// validate checks the column and defaults its metrics to all of them
+func (s *StatsColumn) validate() error {
+	if s.Column == "" {
+		return fmt.Errorf("column is required")
+	}
+	if len(s.Metrics) == 0 {
+		s.Metrics = statsMetrics // Set default
+	}
+	for _, metric := range s.Metrics {
+		if !containsString(statsMetrics, metric) {
+			return fmt.Errorf("unknown metric %q, must be one of %s", metric, strings.Join(statsMetrics, ", "))
+		}
+	}
+	return nil
+}
+
+// ColumnStatsValidator compares aggregates of chosen columns computed in-database
+type ColumnStatsValidator struct{}
+
+// Type returns the comparison type
+func (v *ColumnStatsValidator) Type() string {
+	return TypeColumnStats
+}
+
+// Validate aggregates both sides as a whole and, with group_by, per group.
+// Groups are counted like rows in the summary: without group_by the whole
+// result is a single group.
+func (v *ColumnStatsValidator) Validate(ctx context.Context, config *Config, source, target *Side) (*Result, error) {
+	sourceStats := newStatsQuery(source, config.SourceQuery, config.Stats, nil)
+	targetStats := newStatsQuery(target, config.TargetQuery, config.targetStats(), nil)
+
+	var sourceTotals, targetTotals *rowSet
+	err := config.both(
+		func() (err error) {
+			sourceTotals, err = sourceStats.run(ctx)
+			return err
+		},
+		func() (err error) {
+			targetTotals, err = targetStats.run(ctx)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &statsComparison{config: config, rules: make(ruleMatches)}
+	sourceRow, targetRow := sourceTotals.rows[""], targetTotals.rows[""]
+	c.summary.SourceRowCount = rowCount(sourceTotals, sourceRow)
+	c.summary.TargetRowCount = rowCount(targetTotals, targetRow)
+	totals, mismatched := c.compareGroup(sourceTotals, sourceRow, targetTotals, targetRow)
+
+	sourceGroups, targetGroups := int64(1), int64(1)
+	if len(config.GroupBy) == 0 {
+		if mismatched {
+			c.summary.MismatchedRows = 1
+		} else {
+			c.summary.MatchedRows = 1
+		}
+	} else {
+		sourceStats = newStatsQuery(source, config.SourceQuery, config.Stats, config.GroupBy)
+		targetStats = newStatsQuery(target, config.TargetQuery, config.targetStats(), config.TargetGroupBy())
+
+		var sourceRows, targetRows *rowSet
+		err := config.both(
+			func() (err error) {
+				sourceRows, err = sourceStats.run(ctx)
+				return err
+			},
+			func() (err error) {
+				targetRows, err = targetStats.run(ctx)
+				return err
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		c.compareGroups(sourceRows, targetRows)
+		sourceGroups, targetGroups = int64(len(sourceRows.rows)), int64(len(targetRows.rows))
+	}
+
+	summary := c.summary
+	summary.SuccessRate = successRate(summary.MatchedRows, sourceGroups, targetGroups)
+
+	status := StatusSuccess
+	if !config.ErrorMargin.Allows(summary.MismatchedRows+summary.MissingInTarget+summary.ExtraInTarget, sourceGroups) {
+		status = StatusFailure
+	}
+
+	return &Result{
+		Status:  status,
+		Summary: summary,
+		Details: Details{
+			Differences: []Difference{},
+			Truncated:   c.truncated,
+			RuleMatches: c.rules.list(),
+			ColumnStats: totals,
+			Groups:      c.groups,
+		},
+	}, nil
+}
+
+// statsQuery aggregates the stats columns of one side; without columns it
+// only counts rows
+type statsQuery struct {
+	side    *Side
+	query   string
+	columns []StatsColumn
+	groupBy []string
+}
+
+func newStatsQuery(side *Side, query string, columns []StatsColumn, groupBy []string) *statsQuery {
+	return &statsQuery{side: side, query: query, columns: columns, groupBy: groupBy}
+}
+
+// sql renders the aggregate query. Sums and averages are taken over
+// DECIMAL(38, 6) so integer columns neither overflow nor truncate the average.
+func (q *statsQuery) sql() string {
+	var selects, groups []string
+	for _, column := range q.groupBy {
+		groups = append(groups, quoteIdentifier(q.side, column))
+	}
+	selects = append(selects, groups...)
+	selects = append(selects, "COUNT(*) AS cf_rows")
+
+	for i, column := range q.columns {
+		expr := quoteIdentifier(q.side, column.Column)
+		for _, metric := range column.Metrics {
+			var aggregate string
+			switch metric {
+			case MetricSum:
+				aggregate = "SUM(CAST(" + expr + " AS DECIMAL(38, 6)))"
+			case MetricMin:
+				aggregate = "MIN(" + expr + ")"
+			case MetricMax:
+				aggregate = "MAX(" + expr + ")"
+			case MetricAvg:
+				aggregate = "AVG(CAST(" + expr + " AS DECIMAL(38, 6)))"
+			case MetricCountDistinct:
+				aggregate = "COUNT(DISTINCT " + expr + ")"
+			case MetricNullCount:
+				aggregate = "SUM(CASE WHEN " + expr + " IS NULL THEN 1 ELSE 0 END)"
+			}
+			selects = append(selects, fmt.Sprintf("%s AS %s", aggregate, statsAlias(i, metric)))
+		}
+	}
+
+	query := "SELECT " + strings.Join(selects, ", ") + " FROM (" + q.query + ") src"
+	if len(groups) > 0 {
+		query += " GROUP BY " + strings.Join(groups, ", ")
+	}
+	return query
+}
+
+// run executes the aggregate query and indexes the result by group
+func (q *statsQuery) run(ctx context.Context) (*rowSet, error) {
+	set, err := fetchRows(ctx, q.side, q.sql(), sideColumns{keys: q.groupBy})
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate rows: %w", err)
+	}
+	return set, nil
+}
+
+// statsAlias names the result column of a metric of the i-th stats column
+func statsAlias(i int, metric string) string {
+	return fmt.Sprintf("cf_%d_%s", i, metric)
+}
+
+// quoteIdentifier quotes name with the side's dialect, if it has one
+func quoteIdentifier(side *Side, name string) string {
+	if dialect, ok := side.Connector.(connectors.Dialect); ok {
+		return dialect.QuoteIdentifier(name)
+	}
+	return name
+}
+
+// rowCount reads cf_rows from an aggregated row
+func rowCount(set *rowSet, row []values.Value) int64 {
+	if row == nil {
+		return 0
+	}
+	r, ok := row[columnIndex(set.columns, "cf_rows")].Rat()
+	if !ok {
+		return 0
+	}
+	n, _ := r.Float64()
+	return int64(n)
+}
+
+// statsComparison accumulates the outcome of comparing aggregates
+type statsComparison struct {
+	config    *Config
+	summary   Summary
+	groups    []GroupStats
+	truncated bool
+	rules     ruleMatches
+}
+
+// compareGroup compares the metrics of one group. Rules are credited only
+// when the whole group matches, as with rows.
+func (c *statsComparison) compareGroup(source *rowSet, sourceRow []values.Value, target *rowSet, targetRow []values.Value) (map[string]*ColumnStats, bool) {
+	stats := make(map[string]*ColumnStats, len(c.config.Stats))
+	mismatched := false
+	var credited [][2]string // column, rule
+	for i, column := range c.config.Stats {
+		columnStats := &ColumnStats{}
+		for _, metric := range column.Metrics {
+			s := statsValue(source, sourceRow, i, metric)
+			t := statsValue(target, targetRow, i, metric)
+			columnStats.set(metric, s.Interface(), t.Interface())
+
+			var equal bool
+			var rule string
+			if metric == MetricCountDistinct || metric == MetricNullCount {
+				equal = values.Equal(s, t)
+			} else {
+				equal, rule = c.config.ruleFor(column.Column).equal(s, t)
+			}
+			if !equal {
+				columnStats.Mismatched = append(columnStats.Mismatched, metric)
+				mismatched = true
+			} else if rule != "" {
+				credited = append(credited, [2]string{column.Column, rule})
+			}
+		}
+		stats[column.Column] = columnStats
+	}
+
+	if !mismatched {
+		var key map[string]interface{}
+		if sourceRow != nil {
+			key = source.keyMap(sourceRow)
+		}
+		for _, match := range credited {
+			c.rules.add(match[0], match[1], key)
+		}
+	}
+	return stats, mismatched
+}
+
+// compareGroups compares both sides group by group
+func (c *statsComparison) compareGroups(source, target *rowSet) {
+	keys := make([]string, 0, len(source.rows))
+	for key := range source.rows {
+		keys = append(keys, key)
+	}
+	for key := range target.rows {
+		if _, exists := source.rows[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sourceRow, inSource := source.rows[key]
+		targetRow, inTarget := target.rows[key]
+		group := newGroupStats(rowCount(source, sourceRow), rowCount(target, targetRow))
+		switch {
+		case !inTarget:
+			c.summary.MissingInTarget++
+			group.Group, group.Type = source.keyMap(sourceRow), "missing"
+		case !inSource:
+			c.summary.ExtraInTarget++
+			group.Group, group.Type = target.keyMap(targetRow), "extra"
+		default:
+			columns, mismatched := c.compareGroup(source, sourceRow, target, targetRow)
+			if !mismatched {
+				c.summary.MatchedRows++
+				continue
+			}
+			c.summary.MismatchedRows++
+			group.Group, group.Type, group.Columns = source.keyMap(sourceRow), "mismatch", columns
+		}
+		c.add(group)
+	}
+}
+
+// add records a differing group unless the limit has been reached
+func (c *statsComparison) add(group GroupStats) {
+	if len(c.groups) >= c.config.Performance.MaxDifferences {
+		c.truncated = true
+		return
+	}
+	c.groups = append(c.groups, group)
+}
+
+// statsValue reads a metric from an aggregated row; averages are rounded to avgPlaces
+func statsValue(set *rowSet, row []values.Value, i int, metric string) values.Value {
+	if row == nil {
+		return values.Null()
+	}
+	v := row[columnIndex(set.columns, statsAlias(i, metric))]
+	if metric == MetricAvg {
+		if r, ok := v.Rat(); ok {
+			rounded, _ := values.ParseDecimal(r.FloatString(avgPlaces))
+			return rounded
+		}
+	}
+	return v
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
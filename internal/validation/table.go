@@ -0,0 +1,121 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// tableQuery generates a side's query from source_table or target_table
+type tableQuery struct {
+	side    *Side
+	table   string
+	columns []string // selected columns; nil selects all
+	filter  string
+}
+
+// sql renders SELECT ... FROM table [WHERE filter] with identifiers quoted
+// for the side's dialect
+func (q *tableQuery) sql() string {
+	selects := "*"
+	if q.columns != nil {
+		quoted := make([]string, len(q.columns))
+		for i, column := range q.columns {
+			quoted[i] = quoteIdentifier(q.side, column)
+		}
+		selects = strings.Join(quoted, ", ")
+	}
+
+	query := "SELECT " + selects + " FROM " + quoteIdentifier(q.side, q.table)
+	if q.filter != "" {
+		query += " WHERE " + q.filter
+	}
+	return query
+}
+
+// resolveTables returns a copy of config whose source_query and
+// target_query are generated from source_table and target_table where set.
+// Columns are selected by include_columns or exclude_columns, named as on
+// the source; target columns follow column_mapping.
+func resolveTables(ctx context.Context, config *Config, source, target *Side) (*Config, error) {
+	if config.SourceTable == "" && config.TargetTable == "" {
+		return config, nil
+	}
+
+	resolved := *config
+	if config.SourceTable != "" {
+		columns, err := config.selectColumns(ctx, source, config.SourceTable, func(column string) string { return column })
+		if err != nil {
+			return nil, fmt.Errorf("source: %w", err)
+		}
+		q := &tableQuery{side: source, table: config.SourceTable, columns: columns, filter: config.Filter}
+		resolved.SourceQuery = q.sql()
+	}
+	if config.TargetTable != "" {
+		columns, err := config.selectColumns(ctx, target, config.TargetTable, config.TargetColumn)
+		if err != nil {
+			return nil, fmt.Errorf("target: %w", err)
+		}
+		filter := config.Filter
+		if config.TargetFilter != "" {
+			filter = config.TargetFilter
+		}
+		q := &tableQuery{side: target, table: config.TargetTable, columns: columns, filter: filter}
+		resolved.TargetQuery = q.sql()
+	}
+	return &resolved, nil
+}
+
+// selectColumns returns the columns to select from a table, named for the
+// side by name, or nil to select all of them. include_columns always selects
+// the key, group_by and stats columns too.
+func (c *Config) selectColumns(ctx context.Context, side *Side, table string, name func(string) string) ([]string, error) {
+	if len(c.IncludeColumns) > 0 {
+		selected := append([]string(nil), c.KeyColumns...)
+		selected = append(selected, c.GroupBy...)
+		for _, stat := range c.Stats {
+			selected = append(selected, stat.Column)
+		}
+		selected = append(selected, c.IncludeColumns...)
+
+		var columns, seen []string
+		for _, column := range selected {
+			if !containsFold(seen, column) {
+				seen = append(seen, column)
+				columns = append(columns, name(column))
+			}
+		}
+		return columns, nil
+	}
+	if len(c.ExcludeColumns) == 0 {
+		return nil, nil
+	}
+
+	described, err := describeQuery(ctx, side, "SELECT * FROM "+quoteIdentifier(side, table))
+	if err != nil {
+		return nil, err
+	}
+	excluded := make([]string, len(c.ExcludeColumns))
+	for i, column := range c.ExcludeColumns {
+		excluded[i] = name(column)
+	}
+	var columns []string
+	for _, column := range described {
+		if !containsFold(excluded, column.Name()) {
+			columns = append(columns, column.Name())
+		}
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("exclude_columns excludes every column of %s", table)
+	}
+	return columns, nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
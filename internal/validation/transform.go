@@ -0,0 +1,216 @@
+package validation
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/values"
+)
+
+// transform is a compiled chain of transform steps, written as
+// "step | step(arg, ...) | ..." and applied left to right
+type transform []transformStep
+
+// stepFunc converts one non-NULL value
+type stepFunc func(v values.Value) (values.Value, error)
+
+type transformStep struct {
+	name string
+	fn   stepFunc
+}
+
+// transformFuncs builds a step from its arguments
+var transformFuncs = map[string]func(args []string) (stepFunc, error){
+	"trim":     stringStep(strings.TrimSpace),
+	"ltrim":    stringStep(func(s string) string { return strings.TrimLeft(s, " \t\r\n") }),
+	"rtrim":    stringStep(func(s string) string { return strings.TrimRight(s, " \t\r\n") }),
+	"upper":    stringStep(strings.ToUpper),
+	"lower":    stringStep(strings.ToLower),
+	"scale":    scaleStep,
+	"round":    roundStep,
+	"cast":     castStep,
+	"timezone": timezoneStep,
+}
+
+// parseTransform compiles a transform expression
+func parseTransform(expr string) (transform, error) {
+	var t transform
+	for _, part := range strings.Split(expr, "|") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("empty transform step in %q", expr)
+		}
+
+		name, args := part, []string(nil)
+		if open := strings.Index(part, "("); open >= 0 {
+			if !strings.HasSuffix(part, ")") {
+				return nil, fmt.Errorf("transform step %q is missing a closing parenthesis", part)
+			}
+			name = strings.TrimSpace(part[:open])
+			for _, arg := range strings.Split(part[open+1:len(part)-1], ",") {
+				arg = strings.Trim(strings.TrimSpace(arg), `'"`)
+				if arg != "" {
+					args = append(args, arg)
+				}
+			}
+		}
+
+		build, exists := transformFuncs[strings.ToLower(name)]
+		if !exists {
+			return nil, fmt.Errorf("unknown transform %q", name)
+		}
+		fn, err := build(args)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		t = append(t, transformStep{name: name, fn: fn})
+	}
+	return t, nil
+}
+
+// apply runs the steps on a value; NULL passes through unchanged
+func (t transform) apply(v values.Value) (values.Value, error) {
+	for _, step := range t {
+		if v.IsNull() {
+			return v, nil
+		}
+		var err error
+		if v, err = step.fn(v); err != nil {
+			return values.Value{}, fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+	return v, nil
+}
+
+func stringStep(fn func(string) string) func([]string) (stepFunc, error) {
+	return func(args []string) (stepFunc, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("takes no arguments")
+		}
+		return func(v values.Value) (values.Value, error) {
+			return values.String(fn(v.String())), nil
+		}, nil
+	}
+}
+
+// scaleStep multiplies a number by a factor, e.g. scale(0.01) for cents to dollars
+func scaleStep(args []string) (stepFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expects a factor")
+	}
+	factor, ok := new(big.Rat).SetString(args[0])
+	if !ok {
+		return nil, fmt.Errorf("invalid factor %q", args[0])
+	}
+	return func(v values.Value) (values.Value, error) {
+		r, err := toRat(v)
+		if err != nil {
+			return values.Value{}, err
+		}
+		return values.Decimal(r.Mul(r, factor)), nil
+	}, nil
+}
+
+// roundStep rounds a number half away from zero to a number of decimal places
+func roundStep(args []string) (stepFunc, error) {
+	places := 0
+	if len(args) > 1 {
+		return nil, fmt.Errorf("expects at most one argument")
+	}
+	if len(args) == 1 {
+		var err error
+		if places, err = strconv.Atoi(args[0]); err != nil || places < 0 {
+			return nil, fmt.Errorf("invalid number of decimal places %q", args[0])
+		}
+	}
+	return func(v values.Value) (values.Value, error) {
+		r, err := toRat(v)
+		if err != nil {
+			return values.Value{}, err
+		}
+		return values.ParseDecimal(r.FloatString(places))
+	}, nil
+}
+
+// castStep converts a value to string, int, float, decimal or bool
+func castStep(args []string) (stepFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expects a type")
+	}
+	switch strings.ToLower(args[0]) {
+	case "string", "text":
+		return func(v values.Value) (values.Value, error) {
+			return values.String(v.String()), nil
+		}, nil
+	case "int", "integer":
+		return func(v values.Value) (values.Value, error) {
+			r, err := toRat(v)
+			if err != nil {
+				return values.Value{}, err
+			}
+			return values.BigInt(new(big.Int).Quo(r.Num(), r.Denom())), nil
+		}, nil
+	case "float", "double":
+		return func(v values.Value) (values.Value, error) {
+			r, err := toRat(v)
+			if err != nil {
+				return values.Value{}, err
+			}
+			f, _ := r.Float64()
+			return values.Float(f), nil
+		}, nil
+	case "decimal", "numeric":
+		return func(v values.Value) (values.Value, error) {
+			r, err := toRat(v)
+			if err != nil {
+				return values.Value{}, err
+			}
+			return values.Decimal(r), nil
+		}, nil
+	case "bool", "boolean":
+		return func(v values.Value) (values.Value, error) {
+			b, ok := v.Bool()
+			if !ok {
+				return values.Value{}, fmt.Errorf("%q is not a boolean", v.String())
+			}
+			return values.Bool(b), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %q", args[0])
+}
+
+// timezoneStep reads a timestamp's wall clock in one zone and converts it to
+// another, e.g. timezone(America/New_York, UTC) for local times stored without zone
+func timezoneStep(args []string) (stepFunc, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expects a source and a target time zone")
+	}
+	from, err := time.LoadLocation(args[0])
+	if err != nil {
+		return nil, err
+	}
+	to, err := time.LoadLocation(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return func(v values.Value) (values.Value, error) {
+		t, ok := v.Time()
+		if !ok {
+			return values.Value{}, fmt.Errorf("%q is not a timestamp", v.String())
+		}
+		wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), from)
+		return values.Timestamp(wall.In(to)), nil
+	}, nil
+}
+
+// toRat reads a value as an exact number
+func toRat(v values.Value) (*big.Rat, error) {
+	r, ok := v.Rat()
+	if !ok {
+		return nil, fmt.Errorf("%q is not a number", v.String())
+	}
+	return r, nil
+}
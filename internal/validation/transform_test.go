@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/values"
+)
+
+func TestParseTransform(t *testing.T) {
+	decimal := func(s string) values.Value {
+		v, _ := values.ParseDecimal(s)
+		return v
+	}
+
+	tests := []struct {
+		expr    string
+		input   interface{}
+		want    values.Value
+		wantErr bool
+	}{
+		{"trim | upper", []byte("  ab12 "), values.String("AB12"), false},
+		{"scale(0.01)", int64(1999), decimal("19.99"), false},
+		{"scale(100)", "19.99", decimal("1999"), false},
+		{"round(2)", 2.345, decimal("2.35"), false},
+		{"round", "-2.5", decimal("-3"), false},
+		{"cast(int)", "42.9", values.BigInt(big.NewInt(42)), false},
+		{"cast(bool)", "true", values.Bool(true), false},
+		{"timezone(America/New_York, UTC)", time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC), values.Timestamp(time.Date(2024, 1, 15, 14, 30, 0, 0, time.UTC)), false},
+		{"upper", nil, values.Null(), false},
+		{"scale(0.01)", "abc", values.Null(), true},
+		{"explode", "x", values.Null(), true},
+		{"round(two)", "1", values.Null(), true},
+		{"trim |", "x", values.Null(), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			transform, err := parseTransform(tt.expr)
+			if err == nil {
+				var got values.Value
+				got, err = transform.apply(values.Of(tt.input))
+				if err == nil && (got.Kind() != tt.want.Kind() || !values.Equal(got, tt.want)) {
+					t.Errorf("apply() = %s %q, want %s %q", got.Kind(), got, tt.want.Kind(), tt.want)
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseTransform/apply error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
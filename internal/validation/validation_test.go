@@ -0,0 +1,869 @@
+package validation
+
+import (
+	"context"
+	"crypto/md5"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+)
+
+func init() {
+	// SQLite lacks FLOOR and MD5; register them so the generated SQL runs unchanged
+	sql.Register("sqlite3_validation", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			if err := conn.RegisterFunc("floor", func(v interface{}) int64 {
+				switch v := v.(type) {
+				case int64:
+					return v
+				case float64:
+					return int64(math.Floor(v))
+				}
+				return 0
+			}, true); err != nil {
+				return err
+			}
+			return conn.RegisterFunc("cf_md5", func(part int64, texts ...string) int64 {
+				sum := md5.Sum([]byte(strings.Join(texts, "|")))
+				return int64(binary.BigEndian.Uint32(sum[part*4:]))
+			}, true)
+		},
+	})
+}
+
+// sqliteConnector is a minimal connector with a Dialect for SQLite
+type sqliteConnector struct {
+	connectors.Connector
+}
+
+func (c *sqliteConnector) Type() string { return "sqlite" }
+
+func (c *sqliteConnector) QuoteIdentifier(name string) string {
+	return `"` + name + `"`
+}
+
+func (c *sqliteConnector) Placeholder(n int) string { return "?" }
+
+func (c *sqliteConnector) NormalizeExpression(expr, databaseType string) string {
+	return "CAST(" + expr + " AS TEXT)"
+}
+
+func (c *sqliteConnector) HashExpression(texts []string, part int) string {
+	return fmt.Sprintf("cf_md5(%d, %s)", part, strings.Join(texts, ", "))
+}
+
+func openSide(t *testing.T, rows map[int]string) *Side {
+	t.Helper()
+	db, err := sql.Open("sqlite3_validation", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1) // every connection would get its own in-memory database
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT, qty INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	for id, name := range rows {
+		if _, err := db.Exec("INSERT INTO items VALUES (?, ?, ?)", id, name, id%7); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &Side{Connector: &sqliteConnector{}, DB: db}
+}
+
+func TestRun_DataMatch(t *testing.T) {
+	sourceRows := make(map[int]string)
+	targetRows := make(map[int]string)
+	for id := 1; id <= 500; id++ {
+		sourceRows[id] = fmt.Sprintf("item %d", id)
+		targetRows[id] = fmt.Sprintf("item %d", id)
+	}
+	targetRows[42] = "changed"
+	delete(targetRows, 100)
+	targetRows[501] = "new"
+
+	source := openSide(t, sourceRows)
+	target := openSide(t, targetRows)
+
+	tests := []struct {
+		name        string
+		strategy    string
+		performance map[string]interface{}
+	}{
+		{"full", StrategyFull, nil},
+		{"full chunked", StrategyFull, map[string]interface{}{"chunks": 8, "concurrency": 3}},
+		{"hash", StrategyHash, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseConfig(map[string]interface{}{
+				"comparison_type": TypeDataMatch,
+				"source_query":    "SELECT id, name, qty FROM items",
+				"target_query":    "SELECT id, name, qty FROM items",
+				"key_columns":     []string{"id"},
+				"strategy":        tt.strategy,
+				"hash":            map[string]interface{}{"buckets": 4, "leaf_size": 10},
+				"performance":     tt.performance,
+			})
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+
+			var last Progress
+			ctx := WithProgress(context.Background(), func(p Progress) { last = p })
+			result := Run(ctx, config, source, target)
+			if result.Status != StatusFailure {
+				t.Fatalf("Run() status = %s, want %s (errors: %+v)", result.Status, StatusFailure, result.Errors)
+			}
+
+			want := Summary{
+				SourceRowCount:  500,
+				TargetRowCount:  500,
+				MatchedRows:     498,
+				MismatchedRows:  1,
+				MissingInTarget: 1,
+				ExtraInTarget:   1,
+				SuccessRate:     99.6,
+			}
+			if result.Summary != want {
+				t.Errorf("Run() summary = %+v, want %+v", result.Summary, want)
+			}
+			if len(result.Details.Differences) != 3 {
+				t.Errorf("Run() returned %d differences, want 3", len(result.Details.Differences))
+			}
+
+			if config.Performance.Chunks > 1 {
+				if result.Details.Chunks != 8 {
+					t.Errorf("Run() used %d chunks, want 8", result.Details.Chunks)
+				}
+				if last.ChunksDone != last.ChunksTotal || last.RowsProcessed != 1000 {
+					t.Errorf("final progress = %d/%d chunks, %d rows", last.ChunksDone, last.ChunksTotal, last.RowsProcessed)
+				}
+			}
+
+			if tt.strategy == StrategyHash {
+				stats := result.Details.Hash
+				if stats == nil {
+					t.Fatal("Run() did not report hash stats")
+				}
+				if stats.Levels < 2 {
+					t.Errorf("hash levels = %d, want at least 2", stats.Levels)
+				}
+				if stats.RowsFetched >= 1000 {
+					t.Errorf("hash strategy fetched %d rows, want fewer than both tables", stats.RowsFetched)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_NullKeys(t *testing.T) {
+	open := func() *Side {
+		db, err := sql.Open("sqlite3_validation", ":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { db.Close() })
+		for _, stmt := range []string{
+			"CREATE TABLE codes (code TEXT, name TEXT)",
+			"INSERT INTO codes VALUES (NULL, 'none'), ('', 'empty')",
+		} {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return &Side{Connector: &sqliteConnector{}, DB: db}
+	}
+
+	config, err := ParseConfig(map[string]interface{}{
+		"comparison_type": TypeDataMatch,
+		"source_query":    "SELECT code, name FROM codes",
+		"target_query":    "SELECT code, name FROM codes",
+		"key_columns":     []string{"code"},
+	})
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	// A NULL key and an empty key are different rows
+	result := Run(context.Background(), config, open(), open())
+	if result.Status != StatusSuccess || result.Summary.MatchedRows != 2 {
+		t.Errorf("Run() = %s with summary %+v, want success with 2 matched rows", result.Status, result.Summary)
+	}
+}
+
+func TestRun_DuplicateKeys(t *testing.T) {
+	open := func(duplicate bool) *Side {
+		db, err := sql.Open("sqlite3_validation", ":memory:")
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { db.Close() })
+		if _, err := db.Exec("CREATE TABLE items (id INTEGER, name TEXT)"); err != nil {
+			t.Fatal(err)
+		}
+		for id := 1; id <= 50; id++ {
+			if _, err := db.Exec("INSERT INTO items VALUES (?, ?)", id, fmt.Sprintf("item %d", id)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if duplicate {
+			if _, err := db.Exec("INSERT INTO items VALUES (7, 'again')"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return &Side{Connector: &sqliteConnector{}, DB: db}
+	}
+	source, target := open(true), open(false)
+
+	tests := []struct {
+		name        string
+		strategy    string
+		performance map[string]interface{}
+	}{
+		{"full", StrategyFull, nil},
+		{"full chunked", StrategyFull, map[string]interface{}{"chunks": 4}},
+		{"hash", StrategyHash, nil},
+	}
+
+	// Every strategy counts the duplicate row and reports it
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseConfig(map[string]interface{}{
+				"comparison_type": TypeDataMatch,
+				"source_query":    "SELECT id, name FROM items",
+				"target_query":    "SELECT id, name FROM items",
+				"key_columns":     []string{"id"},
+				"strategy":        tt.strategy,
+				"hash":            map[string]interface{}{"buckets": 4, "leaf_size": 10},
+				"performance":     tt.performance,
+			})
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+
+			result := Run(context.Background(), config, source, target)
+			if result.Status != StatusFailure {
+				t.Fatalf("Run() status = %s, want %s (errors: %+v)", result.Status, StatusFailure, result.Errors)
+			}
+			summary := result.Summary
+			if summary.SourceRowCount != 51 || summary.TargetRowCount != 50 || summary.DuplicateRows != 1 || summary.MatchedRows != 50 {
+				t.Errorf("Run() summary = %+v, want 51 source rows, 50 target rows, 50 matched and 1 duplicate", summary)
+			}
+			differences := result.Details.Differences
+			if len(differences) != 1 || differences[0].Type != "duplicate" || differences[0].SourceData == nil {
+				t.Errorf("Run() differences = %+v, want one source duplicate", differences)
+			}
+		})
+	}
+}
+
+func TestRun_ChunkedTextKey(t *testing.T) {
+	rows := make(map[int]string)
+	for id := 1; id <= 100; id++ {
+		rows[id] = fmt.Sprintf("item %d", id)
+	}
+	config, err := ParseConfig(map[string]interface{}{
+		"comparison_type": TypeDataMatch,
+		"source_query":    "SELECT name, qty FROM items",
+		"target_query":    "SELECT name, qty FROM items",
+		"key_columns":     []string{"name"},
+		"performance":     map[string]interface{}{"chunks": 4},
+	})
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	// Databases may collate text differently, so text keys are not split
+	result := Run(context.Background(), config, openSide(t, rows), openSide(t, rows))
+	if result.Status != StatusSuccess || result.Details.Chunks > 1 {
+		t.Errorf("Run() = %s in %d chunks, want success without chunks (errors: %+v)", result.Status, result.Details.Chunks, result.Errors)
+	}
+}
+
+func TestRun_RowCount(t *testing.T) {
+	source := openSide(t, map[int]string{1: "a", 2: "b", 3: "c"})
+	target := openSide(t, map[int]string{1: "a", 2: "b"})
+
+	config, err := ParseConfig(map[string]interface{}{
+		"comparison_type": TypeRowCount,
+		"source_query":    "SELECT * FROM items",
+		"target_query":    "SELECT COUNT(*) FROM items",
+		"error_margin":    map[string]interface{}{"type": "absolute", "value": 1},
+	})
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	result := Run(context.Background(), config, source, target)
+	if !result.Passed() {
+		t.Fatalf("Run() status = %s, want %s (errors: %+v)", result.Status, StatusSuccess, result.Errors)
+	}
+	if result.Summary.SourceRowCount != 3 || result.Summary.TargetRowCount != 2 || result.Summary.MissingInTarget != 1 {
+		t.Errorf("Run() summary = %+v", result.Summary)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	base := func() map[string]interface{} {
+		return map[string]interface{}{
+			"comparison_type": TypeDataMatch,
+			"source_query":    "SELECT 1",
+			"target_query":    "SELECT 1",
+			"key_columns":     []string{"id"},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(map[string]interface{})
+		wantErr bool
+	}{
+		{"defaults", func(m map[string]interface{}) {}, false},
+		{"unknown comparison type", func(m map[string]interface{}) { m["comparison_type"] = "magic" }, true},
+		{"missing target query", func(m map[string]interface{}) { delete(m, "target_query") }, true},
+		{"query and table", func(m map[string]interface{}) { m["source_table"] = "items" }, true},
+		{"filter without table", func(m map[string]interface{}) { m["filter"] = "id > 1" }, true},
+		{"excluded key", func(m map[string]interface{}) {
+			delete(m, "source_query")
+			m["source_table"] = "items"
+			m["exclude_columns"] = []string{"ID"}
+		}, true},
+		{"excluded group_by column", func(m map[string]interface{}) {
+			m["comparison_type"] = TypeRowCount
+			delete(m, "source_query")
+			m["source_table"] = "items"
+			m["group_by"] = []string{"region"}
+			m["exclude_columns"] = []string{"region"}
+		}, true},
+		{"excluded stats column", func(m map[string]interface{}) {
+			m["comparison_type"] = TypeColumnStats
+			delete(m, "source_query")
+			m["source_table"] = "items"
+			m["stats"] = []map[string]interface{}{{"column": "qty"}}
+			m["exclude_columns"] = []string{"qty"}
+		}, true},
+		{"missing key columns", func(m map[string]interface{}) { delete(m, "key_columns") }, true},
+		{"unknown strategy", func(m map[string]interface{}) { m["strategy"] = "sample" }, true},
+		{"buckets not a power of two", func(m map[string]interface{}) {
+			m["strategy"] = StrategyHash
+			m["hash"] = map[string]interface{}{"buckets": 100}
+		}, true},
+		{"bad error margin", func(m map[string]interface{}) {
+			m["error_margin"] = map[string]interface{}{"type": "relative"}
+		}, true},
+		{"group_by on data_match", func(m map[string]interface{}) { m["group_by"] = []string{"region"} }, true},
+		{"group_by on a count query", func(m map[string]interface{}) {
+			m["comparison_type"] = TypeRowCount
+			m["source_query"] = "SELECT COUNT(*) FROM items"
+			m["group_by"] = []string{"region"}
+		}, true},
+		{"undeclared variable", func(m map[string]interface{}) { m["source_query"] = "SELECT {{region}}" }, true},
+		{"variable inside a literal", func(m map[string]interface{}) {
+			m["source_query"] = "SELECT * FROM items WHERE name LIKE '%{{region}}%'"
+			m["variables"] = map[string]string{"region": "EU"}
+		}, true},
+		{"declared built-in variable", func(m map[string]interface{}) {
+			m["variables"] = map[string]string{"run_date": "2024-01-01"}
+		}, true},
+		{"unknown retry class", func(m map[string]interface{}) {
+			m["retry"] = map[string]interface{}{"max_attempts": 3, "retry_on": []string{"mismatch"}}
+		}, true},
+		{"retry backoff shrinks", func(m map[string]interface{}) {
+			m["retry"] = map[string]interface{}{"max_attempts": 3, "backoff_multiplier": 0.5}
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := base()
+			tt.modify(m)
+			config, err := ParseConfig(m)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (config.Strategy != StrategyFull || config.Hash.Buckets != 256 || config.Performance.MaxDifferences != 1000) {
+				t.Errorf("ParseConfig() did not apply defaults: %+v", config)
+			}
+		})
+	}
+}
+
+func TestParseConfig_ChunkRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		performance map[string]interface{}
+		want        int
+	}{
+		{"unset", map[string]interface{}{}, 2},
+		{"disabled", map[string]interface{}{"chunk_retries": 0}, 0},
+		{"set", map[string]interface{}{"chunk_retries": 5}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseConfig(map[string]interface{}{
+				"comparison_type": TypeRowCount,
+				"source_table":    "items",
+				"target_table":    "items",
+				"performance":     tt.performance,
+			})
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+			if got := *config.Performance.ChunkRetries; got != tt.want {
+				t.Errorf("chunk_retries = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_ColumnMapping(t *testing.T) {
+	rows := map[int]string{1: "a", 2: "b", 3: "c"}
+	source := openSide(t, rows)
+	target := openSide(t, rows)
+
+	config, err := ParseConfig(map[string]interface{}{
+		"comparison_type": TypeDataMatch,
+		"source_query":    "SELECT id, '  ' || name || ' ' AS name, qty FROM items",
+		"target_query":    "SELECT id AS item_id, upper(name) AS label, qty * 100 AS qty_cents, 'x' AS loaded_by FROM items",
+		"key_columns":     []string{"id"},
+		"column_mapping": []map[string]interface{}{
+			{"source": "id", "target": "item_id"},
+			{"source": "name", "target": "label", "source_transform": "trim | upper"},
+			{"source": "qty", "target": "qty_cents", "target_transform": "scale(0.01)"},
+		},
+		"unmapped_columns": UnmappedFlag,
+	})
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	result := Run(context.Background(), config, source, target)
+	if !result.Passed() || result.Summary.MatchedRows != 3 {
+		t.Fatalf("Run() = %s with summary %+v, differences %+v, errors %+v", result.Status, result.Summary, result.Details.Differences, result.Errors)
+	}
+	unmapped := result.Details.UnmappedColumns
+	if unmapped == nil || len(unmapped.Source) != 0 || len(unmapped.Target) != 1 || unmapped.Target[0] != "loaded_by" {
+		t.Errorf("Run() unmapped columns = %+v, want target [loaded_by]", unmapped)
+	}
+}
+
+func TestRun_ColumnRules(t *testing.T) {
+	source := openSide(t, map[int]string{1: "Alice", 2: "Bob", 3: "Carol"})
+	target := openSide(t, map[int]string{1: "ALICE", 2: "Bob", 3: "Karol"})
+
+	config, err := ParseConfig(map[string]interface{}{
+		"comparison_type": TypeDataMatch,
+		"source_query":    "SELECT id, name, qty FROM items",
+		"target_query":    "SELECT id, name, qty + 0.004 AS qty FROM items",
+		"key_columns":     []string{"id"},
+		"column_rules": map[string]interface{}{
+			"name": map[string]interface{}{"case_insensitive": true},
+			"*":    map[string]interface{}{"absolute_tolerance": 0.005},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	result := Run(context.Background(), config, source, target)
+	if result.Summary.MatchedRows != 2 || result.Summary.MismatchedRows != 1 {
+		t.Fatalf("Run() summary = %+v, errors %+v", result.Summary, result.Errors)
+	}
+
+	want := map[string]int64{"name/" + RuleCaseInsensitive: 1, "qty/" + RuleAbsoluteTolerance: 2}
+	got := make(map[string]int64)
+	for _, match := range result.Details.RuleMatches {
+		got[match.Column+"/"+match.Rule] = match.Rows
+	}
+	if len(got) != len(want) || got["name/"+RuleCaseInsensitive] != 1 || got["qty/"+RuleAbsoluteTolerance] != 2 {
+		t.Errorf("Run() rule matches = %+v, want %v", result.Details.RuleMatches, want)
+	}
+}
+
+func TestRun_ColumnStats(t *testing.T) {
+	sourceRows := make(map[int]string)
+	targetRows := make(map[int]string)
+	for id := 1; id <= 100; id++ {
+		sourceRows[id] = fmt.Sprintf("item %d", id)
+		targetRows[id] = fmt.Sprintf("item %d", id)
+	}
+	delete(targetRows, 14) // qty 0
+
+	source := openSide(t, sourceRows)
+	target := openSide(t, targetRows)
+
+	config, err := ParseConfig(map[string]interface{}{
+		"comparison_type": TypeColumnStats,
+		"source_query":    "SELECT id, name, qty FROM items",
+		"target_query":    "SELECT id, name, qty FROM items",
+		"stats": []map[string]interface{}{
+			{"column": "id"},
+			{"column": "name", "metrics": []string{MetricCountDistinct, MetricNullCount}},
+		},
+		"group_by": []string{"qty"},
+	})
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	result := Run(context.Background(), config, source, target)
+	if result.Status != StatusFailure {
+		t.Fatalf("Run() status = %s, want %s (errors: %+v)", result.Status, StatusFailure, result.Errors)
+	}
+	if result.Summary.SourceRowCount != 100 || result.Summary.TargetRowCount != 99 ||
+		result.Summary.MatchedRows != 6 || result.Summary.MismatchedRows != 1 {
+		t.Errorf("Run() summary = %+v", result.Summary)
+	}
+
+	totals := result.Details.ColumnStats["id"]
+	if totals == nil || totals.SourceSum != int64(5050) || totals.TargetSum != int64(5036) || totals.SourceAvg != "50.5" {
+		t.Errorf("Run() column_stats[id] = %+v", totals)
+	}
+	if len(result.Details.Groups) != 1 {
+		t.Fatalf("Run() groups = %+v, want 1", result.Details.Groups)
+	}
+	group := result.Details.Groups[0]
+	if group.Group["qty"] != int64(0) || group.Type != "mismatch" || group.SourceRows != 14 || group.TargetRows != 13 {
+		t.Errorf("Run() group = %+v", group)
+	}
+	if got := group.Columns["name"].Mismatched; len(got) != 1 || got[0] != MetricCountDistinct {
+		t.Errorf("Run() group name mismatched = %v, want [%s]", got, MetricCountDistinct)
+	}
+}
+
+func TestRun_RowCountGroupBy(t *testing.T) {
+	sourceRows := make(map[int]string)
+	targetRows := make(map[int]string)
+	for id := 1; id <= 20; id++ {
+		sourceRows[id] = "a"
+		targetRows[id] = "a"
+	}
+	delete(targetRows, 7) // qty 0
+	targetRows[22] = "a"  // qty 1
+	delete(targetRows, 6) // qty 6
+	delete(targetRows, 13)
+	delete(targetRows, 20)
+
+	config, err := ParseConfig(map[string]interface{}{
+		"comparison_type": TypeRowCount,
+		"source_query":    "SELECT * FROM items",
+		"target_query":    "SELECT * FROM items",
+		"group_by":        []string{"qty"},
+		"error_margin":    map[string]interface{}{"type": "absolute", "value": 2},
+	})
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	result := Run(context.Background(), config, openSide(t, sourceRows), openSide(t, targetRows))
+	if result.Status != StatusFailure {
+		t.Fatalf("Run() status = %s, want %s (errors: %+v)", result.Status, StatusFailure, result.Errors)
+	}
+	want := Summary{SourceRowCount: 20, TargetRowCount: 17, MatchedRows: 16, MissingInTarget: 4, ExtraInTarget: 1, SuccessRate: 80}
+	if result.Summary != want {
+		t.Errorf("Run() summary = %+v, want %+v", result.Summary, want)
+	}
+
+	types := make(map[int64]string)
+	for _, group := range result.Details.Groups {
+		types[group.Group["qty"].(int64)] = fmt.Sprintf("%s %+d", group.Type, group.Difference)
+	}
+	wantTypes := map[int64]string{0: "mismatch -1", 1: "mismatch +1", 6: "missing -3"}
+	if fmt.Sprint(types) != fmt.Sprint(wantTypes) {
+		t.Errorf("Run() groups = %v, want %v", types, wantTypes)
+	}
+}
+
+func TestRun_Sample(t *testing.T) {
+	sourceRows := make(map[int]string)
+	targetRows := make(map[int]string)
+	for id := 1; id <= 500; id++ {
+		sourceRows[id] = fmt.Sprintf("item %d", id)
+		targetRows[id] = fmt.Sprintf("changed %d", id)
+	}
+	source := openSide(t, sourceRows)
+	target := openSide(t, targetRows)
+
+	tests := []struct {
+		name     string
+		sample   map[string]interface{}
+		min, max int64
+	}{
+		{"rows", map[string]interface{}{"rows": 50, "seed": 7}, 50, 50},
+		{"percent", map[string]interface{}{"percent": 20}, 60, 140},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseConfig(map[string]interface{}{
+				"comparison_type": TypeDataMatch,
+				"source_query":    "SELECT id, name FROM items",
+				"target_query":    "SELECT id, name FROM items",
+				"key_columns":     []string{"id"},
+				"sample":          tt.sample,
+			})
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+
+			first := Run(context.Background(), config, source, target)
+			second := Run(context.Background(), config, source, target)
+			if first.Status != StatusFailure {
+				t.Fatalf("Run() status = %s, want %s (errors: %+v)", first.Status, StatusFailure, first.Errors)
+			}
+
+			sampled := first.Details.Sample
+			if sampled == nil || sampled.Method != SampleHashMod || sampled.SampledRows < tt.min || sampled.SampledRows > tt.max {
+				t.Fatalf("Run() sample = %+v, want %d to %d rows", sampled, tt.min, tt.max)
+			}
+			// Every sampled row is found on the target by key and differs
+			if first.Summary.TargetRowCount != sampled.SampledRows || first.Summary.MismatchedRows != sampled.SampledRows {
+				t.Errorf("Run() summary = %+v", first.Summary)
+			}
+			if fmt.Sprint(first.Details.Differences) != fmt.Sprint(second.Details.Differences) {
+				t.Errorf("Run() sampled different keys on a second run")
+			}
+		})
+	}
+}
+
+func TestRun_Tables(t *testing.T) {
+	rows := make(map[int]string)
+	for id := 1; id <= 20; id++ {
+		rows[id] = fmt.Sprintf("item %d", id)
+	}
+	source := openSide(t, rows)
+	rows[15] = "changed"
+	target := openSide(t, rows)
+	if _, err := target.DB.Exec("UPDATE items SET qty = qty + 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		columns   map[string]interface{}
+		wantQuery string
+	}{
+		{"exclude", map[string]interface{}{"exclude_columns": []string{"qty"}}, `SELECT "id", "name" FROM "items" WHERE id <= 10`},
+		{"include", map[string]interface{}{"include_columns": []string{"name"}}, `SELECT "id", "name" FROM "items" WHERE id <= 10`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := map[string]interface{}{
+				"comparison_type": TypeDataMatch,
+				"source_table":    "items",
+				"target_table":    "items",
+				"key_columns":     []string{"id"},
+				"filter":          "id <= 10",
+			}
+			for k, v := range tt.columns {
+				m[k] = v
+			}
+			config, err := ParseConfig(m)
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+
+			result := Run(context.Background(), config, source, target)
+			if !result.Passed() {
+				t.Fatalf("Run() status = %s, want %s (summary: %+v, errors: %+v)", result.Status, StatusSuccess, result.Summary, result.Errors)
+			}
+			if result.Summary.MatchedRows != 10 {
+				t.Errorf("Run() summary = %+v", result.Summary)
+			}
+			if result.Details.SourceQuery != tt.wantQuery || result.Details.TargetQuery != tt.wantQuery {
+				t.Errorf("Run() queries = %q, %q, want %q", result.Details.SourceQuery, result.Details.TargetQuery, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestRun_TablesIncludeStats(t *testing.T) {
+	rows := make(map[int]string)
+	for id := 1; id <= 20; id++ {
+		rows[id] = fmt.Sprintf("item %d", id%4)
+	}
+	source := openSide(t, rows)
+	target := openSide(t, rows)
+
+	// The group_by and stats columns are selected along with include_columns
+	config, err := ParseConfig(map[string]interface{}{
+		"comparison_type": TypeColumnStats,
+		"source_table":    "items",
+		"target_table":    "items",
+		"include_columns": []string{"id"},
+		"group_by":        []string{"name"},
+		"stats":           []map[string]interface{}{{"column": "qty"}, {"column": "ID"}},
+	})
+	if err != nil {
+		t.Fatalf("ParseConfig() error = %v", err)
+	}
+
+	result := Run(context.Background(), config, source, target)
+	if !result.Passed() {
+		t.Fatalf("Run() status = %s, want %s (errors: %+v)", result.Status, StatusSuccess, result.Errors)
+	}
+	if want := `SELECT "name", "qty", "ID" FROM "items"`; result.Details.SourceQuery != want {
+		t.Errorf("Run() source query = %q, want %q", result.Details.SourceQuery, want)
+	}
+}
+
+func TestRun_Variables(t *testing.T) {
+	rows := make(map[int]string)
+	for id := 1; id <= 20; id++ {
+		rows[id] = fmt.Sprintf("item %d", id)
+	}
+	source := openSide(t, rows)
+	rows[15] = "changed"
+	target := openSide(t, rows)
+
+	tests := []struct {
+		name        string
+		parameters  map[string]string
+		wantErr     bool
+		wantMatched int64
+		wantPassed  bool
+	}{
+		{"defaults", nil, false, 10, true},
+		{"override", map[string]string{"max_id": "20"}, false, 19, false},
+		{"other date", map[string]string{"run_date": "2024-02-01"}, false, 0, true},
+		{"unknown variable", map[string]string{"region": "EU"}, true, 0, false},
+		{"bad date", map[string]string{"run_date": "yesterday"}, true, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := "SELECT id, name FROM items WHERE id <= {{max_id}} AND '{{ run_date }}' = '2024-01-31'"
+			config, err := ParseConfig(map[string]interface{}{
+				"comparison_type": TypeDataMatch,
+				"source_query":    query,
+				"target_query":    query,
+				"key_columns":     []string{"id"},
+				"variables":       map[string]string{"max_id": "10"},
+			})
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+			err = config.ResolveVariables(time.Date(2024, 1, 31, 6, 0, 0, 0, time.UTC), tt.parameters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveVariables() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			result := Run(context.Background(), config, source, target)
+			if result.Status == StatusError || result.Passed() != tt.wantPassed || result.Summary.MatchedRows != tt.wantMatched {
+				t.Fatalf("Run() status = %s, summary = %+v, errors = %+v", result.Status, result.Summary, result.Errors)
+			}
+			if result.Variables[VarRunDate] == "" || result.Variables["max_id"] == "" {
+				t.Errorf("Run() variables = %v", result.Variables)
+			}
+		})
+	}
+}
+
+func TestFindVariables(t *testing.T) {
+	tests := []struct {
+		template string
+		want     []string // the spans of the references
+		wantErr  bool
+	}{
+		{"id <= {{max_id}}", []string{"{{max_id}}"}, false},
+		{"d = '{{ run_date }}' AND r = {{region}}", []string{"'{{ run_date }}'", "{{region}}"}, false},
+		{"name = 'it''s' AND r = '{{region}}'", []string{"'{{region}}'"}, false},
+		{"name = '{{region}}''s'", nil, true},
+		{"name LIKE '%{{region}}%'", nil, true},
+		{"name = 'EU-{{region}}'", nil, true},
+		{"name = 'a' || '{{region}}' || 'b'", []string{"'{{region}}'"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			refs, err := findVariables(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findVariables() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, ref := range refs {
+				got = append(got, tt.template[ref.start:ref.end])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findVariables() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_BothSequential(t *testing.T) {
+	config := &Config{Performance: Performance{SequentialSides: true}}
+	var order []string
+	err := config.both(
+		func() error { order = append(order, "source"); return nil },
+		func() error { order = append(order, "target"); return nil },
+	)
+	if err != nil || !reflect.DeepEqual(order, []string{"source", "target"}) {
+		t.Errorf("both() = %v, order %v, want source then target", err, order)
+	}
+
+	// A failing source skips the target
+	order = nil
+	err = config.both(
+		func() error { order = append(order, "source"); return fmt.Errorf("boom") },
+		func() error { order = append(order, "target"); return nil },
+	)
+	if err == nil || err.Error() != "source: boom" || len(order) != 1 {
+		t.Errorf("both() = %v, order %v, want the source error only", err, order)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BackoffSeconds: 10, MaxBackoffSeconds: 25}
+	if err := policy.validate(); err != nil {
+		t.Fatalf("validate() error = %v", err)
+	}
+
+	for attempt, want := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 25 * time.Second} {
+		if got := policy.Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+
+	failed := func(class string) *Result {
+		return &Result{Status: StatusError, Errors: []ErrorEntry{{Message: "boom", Class: class}}}
+	}
+	tests := []struct {
+		name    string
+		result  *Result
+		attempt int
+		want    bool
+	}{
+		{"deadlock", failed(connectors.ErrorClassDeadlock), 1, true},
+		{"timeout on the last attempt", failed(connectors.ErrorClassTimeout), 3, false},
+		{"invalid SQL", failed(connectors.ErrorClassOther), 1, false},
+		{"data mismatch", &Result{Status: StatusFailure}, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Retryable(tt.result, tt.attempt); got != tt.want {
+				t.Errorf("Retryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Package validation compares the results of a source and a target query.
+// Each comparison type is implemented by a Validator registered under the
+// config's comparison_type.
+package validation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+)
+
+// Side is one end of a comparison: an open database and the connector that opened it
+type Side struct {
+	Connector connectors.Connector
+	DB        *sql.DB
+
+	// args are bound to the variables of the side's query; every query built
+	// on it passes them ahead of its own arguments
+	args []interface{}
+}
+
+// queryContext runs a query built on the side's query
+func (s *Side) queryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.DB.QueryContext(ctx, query, s.bind(args)...)
+}
+
+// queryRowContext runs a single-row query built on the side's query
+func (s *Side) queryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.DB.QueryRowContext(ctx, query, s.bind(args)...)
+}
+
+func (s *Side) bind(args []interface{}) []interface{} {
+	return append(s.args[:len(s.args):len(s.args)], args...)
+}
+
+// Validator implements a comparison type
+type Validator interface {
+	// Type returns the comparison_type handled by this validator
+	Type() string
+
+	// Validate compares source and target and returns the summary and details.
+	// The returned result's Status must be StatusSuccess or StatusFailure.
+	Validate(ctx context.Context, config *Config, source, target *Side) (*Result, error)
+}
+
+var (
+	registry = make(map[string]func() Validator)
+	mu       sync.RWMutex
+)
+
+// Register adds a validator to the registry
+func Register(name string, factory func() Validator) {
+	mu.Lock()
+	defer mu.Unlock()
+	registry[name] = factory
+}
+
+// Get returns the validator for a comparison type
+func Get(comparisonType string) (Validator, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	factory, exists := registry[comparisonType]
+	if !exists {
+		return nil, fmt.Errorf("unsupported comparison type: %s", comparisonType)
+	}
+	return factory(), nil
+}
+
+// Run executes a validation and always returns a result; failures to run
+// are reported with StatusError and an entry in Errors
+func Run(ctx context.Context, config *Config, source, target *Side) *Result {
+	start := time.Now().UTC()
+
+	if config.Performance.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(config.Performance.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	result, err := run(ctx, config, source, target)
+	if err != nil {
+		result = &Result{
+			Status: StatusError,
+			Errors: []ErrorEntry{{Timestamp: time.Now().UTC(), Message: err.Error(), Class: classifyError(err, source, target)}},
+		}
+	}
+	if result.Details.Differences == nil {
+		result.Details.Differences = []Difference{}
+	}
+
+	result.Variables = config.Variables
+	result.ExecutionID = uuid.NewString()
+	result.StartTime = start
+	result.EndTime = time.Now().UTC()
+	result.DurationMS = result.EndTime.Sub(start).Milliseconds()
+	return result
+}
+
+// ErrorResult builds the result of a run that could not start
+func ErrorResult(err error) *Result {
+	now := time.Now().UTC()
+	return &Result{
+		ExecutionID: uuid.NewString(),
+		StartTime:   now,
+		EndTime:     now,
+		Status:      StatusError,
+		Details:     Details{Differences: []Difference{}},
+		Errors:      []ErrorEntry{{Timestamp: now, Message: err.Error(), Class: connectors.ClassifyError(nil, err)}},
+	}
+}
+
+// classifyError asks both connectors, as a failed run's error may come from
+// either side
+func classifyError(err error, source, target *Side) string {
+	for _, side := range []*Side{source, target} {
+		if classifier, ok := side.Connector.(connectors.ErrorClassifier); ok {
+			if class := classifier.ClassifyError(err); class != "" {
+				return class
+			}
+		}
+	}
+	return connectors.ClassifyError(nil, err)
+}
+
+func run(ctx context.Context, config *Config, source, target *Side) (*Result, error) {
+	validator, err := Get(config.ComparisonType)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := resolveTables(ctx, config, source, target)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bind variables on copies so the caller's config and sides are untouched
+	bound := *resolved
+	source = &Side{Connector: source.Connector, DB: source.DB}
+	target = &Side{Connector: target.Connector, DB: target.DB}
+	if bound.SourceQuery, err = bindVariables(resolved.SourceQuery, source, config.Variables); err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	if bound.TargetQuery, err = bindVariables(resolved.TargetQuery, target, config.Variables); err != nil {
+		return nil, fmt.Errorf("target: %w", err)
+	}
+
+	result, err := validator.Validate(ctx, &bound, source, target)
+	if err != nil {
+		return nil, err
+	}
+	// Record the generated SQL so the run shows what was compared
+	if config.SourceTable != "" {
+		result.Details.SourceQuery = resolved.SourceQuery
+	}
+	if config.TargetTable != "" {
+		result.Details.TargetQuery = resolved.TargetQuery
+	}
+	return result, nil
+}
+
+// both runs the source and target functions concurrently, or one after the
+// other with performance.SequentialSides
+func (c *Config) both(sourceFn, targetFn func() error) error {
+	if c.Performance.SequentialSides {
+		if err := sourceFn(); err != nil {
+			return fmt.Errorf("source: %w", err)
+		}
+		if err := targetFn(); err != nil {
+			return fmt.Errorf("target: %w", err)
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	var sourceErr, targetErr error
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		sourceErr = sourceFn()
+	}()
+	go func() {
+		defer wg.Done()
+		targetErr = targetFn()
+	}()
+	wg.Wait()
+
+	if sourceErr != nil {
+		return fmt.Errorf("source: %w", sourceErr)
+	}
+	if targetErr != nil {
+		return fmt.Errorf("target: %w", targetErr)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/compareflow/compareflow/internal/connectors"
+)
+
+// Built-in variables, set for every run
+const (
+	// VarRunDate is the run's logical date as YYYY-MM-DD. It can be
+	// overridden to rerun a validation for an earlier date.
+	VarRunDate = "run_date"
+)
+
+// variablePattern matches {{name}} in queries and filters
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// variableReference is a {{name}} in a template. start and end span the
+// reference, including the quotes of a quoted reference.
+type variableReference struct {
+	name       string
+	start, end int
+}
+
+// findVariables returns the variable references in a template. A reference
+// either stands outside string literals or is a whole literal, as in
+// '{{run_date}}', and is bound the same way without the quotes. A reference
+// inside a longer literal, as in 'EU-{{region}}', cannot be bound as a
+// parameter and is an error.
+func findVariables(template string) ([]variableReference, error) {
+	var refs []variableReference
+	for _, match := range variablePattern.FindAllStringSubmatchIndex(template, -1) {
+		ref := variableReference{name: template[match[2]:match[3]], start: match[0], end: match[1]}
+		// An odd number of quotes before the reference puts it inside a
+		// literal; an escaped quote ('') counts twice
+		if strings.Count(template[:ref.start], "'")%2 == 1 {
+			opens := template[ref.start-1] == '\'' && strings.Count(template[:ref.start-1], "'")%2 == 0
+			closes := strings.HasPrefix(template[ref.end:], "'") && !strings.HasPrefix(template[ref.end:], "''")
+			if !opens || !closes {
+				return nil, fmt.Errorf("variable %q is inside a string literal; use '{{%s}}' as the whole literal and build the string in SQL", ref.name, ref.name)
+			}
+			ref.start--
+			ref.end++
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// templates returns the config fields that may reference variables
+func (c *Config) templates() map[string]string {
+	return map[string]string{
+		"source_query":  c.SourceQuery,
+		"target_query":  c.TargetQuery,
+		"filter":        c.Filter,
+		"target_filter": c.TargetFilter,
+	}
+}
+
+// validateVariables checks that every variable referenced by a query or
+// filter is declared in variables or built in
+func (c *Config) validateVariables() error {
+	for name := range c.Variables {
+		if name == VarRunDate {
+			return fmt.Errorf("variables.%s is built in and cannot be declared", name)
+		}
+	}
+	for field, template := range c.templates() {
+		if _, err := findVariables(template); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		for _, name := range referencedVariables(template) {
+			if _, ok := c.Variables[name]; !ok && name != VarRunDate {
+				return fmt.Errorf("%s references undeclared variable %q", field, name)
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveVariables replaces the config's variable defaults with the values
+// for one run: the built-ins for the run's logical date, then the defaults,
+// then overrides. Overrides must name a declared or built-in variable.
+func (c *Config) ResolveVariables(logicalDate time.Time, overrides map[string]string) error {
+	resolved := map[string]string{VarRunDate: logicalDate.Format(time.DateOnly)}
+	for name, value := range c.Variables {
+		resolved[name] = value
+	}
+	for name, value := range overrides {
+		if _, ok := resolved[name]; !ok {
+			return fmt.Errorf("unknown variable %q", name)
+		}
+		resolved[name] = value
+	}
+	if _, err := time.Parse(time.DateOnly, resolved[VarRunDate]); err != nil {
+		return fmt.Errorf("%s must be a date as YYYY-MM-DD", VarRunDate)
+	}
+	c.Variables = resolved
+	return nil
+}
+
+// bindVariables renders the variables into the side's query. Connectors
+// with a Dialect get a bind parameter per reference, collected in the side's
+// args; others get the value inlined as a quoted string literal.
+func bindVariables(query string, side *Side, variables map[string]string) (string, error) {
+	refs, err := findVariables(query)
+	if err != nil {
+		return "", err
+	}
+
+	dialect, bind := side.Connector.(connectors.Dialect)
+	var rendered strings.Builder
+	last := 0
+	for _, ref := range refs {
+		value, ok := variables[ref.name]
+		if !ok {
+			return "", fmt.Errorf("variable %q has no value", ref.name)
+		}
+		rendered.WriteString(query[last:ref.start])
+		if bind {
+			side.args = append(side.args, value)
+			rendered.WriteString(dialect.Placeholder(len(side.args)))
+		} else {
+			rendered.WriteString("'" + strings.ReplaceAll(value, "'", "''") + "'")
+		}
+		last = ref.end
+	}
+	rendered.WriteString(query[last:])
+	return rendered.String(), nil
+}
+
+// referencedVariables returns the sorted names of the variables in a template
+func referencedVariables(template string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, match := range variablePattern.FindAllStringSubmatch(template, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,152 @@
+package values
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Of maps a Go value as returned by a database/sql driver without type
+// information: integers, floats, booleans and times keep their kind, bytes
+// and text become strings
+func Of(v interface{}) Value {
+	switch v := v.(type) {
+	case nil:
+		return Null()
+	case Value:
+		return v
+	case int64:
+		return Int(v)
+	case int:
+		return Int(int64(v))
+	case int32:
+		return Int(int64(v))
+	case int16:
+		return Int(int64(v))
+	case int8:
+		return Int(int64(v))
+	case uint64:
+		return BigInt(new(big.Int).SetUint64(v))
+	case uint32:
+		return Int(int64(v))
+	case uint16:
+		return Int(int64(v))
+	case uint8:
+		return Int(int64(v))
+	case *big.Int:
+		return BigInt(v)
+	case *big.Rat:
+		return Decimal(v)
+	case float64:
+		return Float(v)
+	case float32:
+		return Float(float64(v))
+	case bool:
+		return Bool(v)
+	case time.Time:
+		return Timestamp(v)
+	case []byte:
+		return String(string(v))
+	case string:
+		return String(v)
+	case fmt.Stringer:
+		return String(v.String())
+	}
+	return String(fmt.Sprint(v))
+}
+
+// FromDriver maps a scanned value using the column's database type name as
+// reported by sql.ColumnType.DatabaseTypeName. It covers the type names
+// shared by most databases; connectors handle driver specifics before
+// falling back to it.
+func FromDriver(v interface{}, databaseType string) (Value, error) {
+	if v == nil {
+		return Null(), nil
+	}
+
+	switch normalizeTypeName(databaseType) {
+	case "DECIMAL", "NUMERIC", "NUMBER", "MONEY", "SMALLMONEY":
+		switch v := v.(type) {
+		case []byte:
+			return ParseDecimal(string(v))
+		case string:
+			return ParseDecimal(v)
+		case float64:
+			// Drivers that return decimals as floats have already rounded them
+			// to 64 bits; the shortest decimal form is the best reading of it
+			r, ok := Float(v).Rat()
+			if !ok {
+				return Float(v), nil
+			}
+			return Decimal(r), nil
+		}
+	case "DATE":
+		switch v := v.(type) {
+		case time.Time:
+			return Date(v), nil
+		case []byte, string:
+			t, err := ParseTime(Of(v).String())
+			if err != nil {
+				return Value{}, err
+			}
+			return Date(t), nil
+		}
+	case "TIMESTAMP", "TIMESTAMPTZ", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET", "TIMESTAMP_NTZ":
+		switch v := v.(type) {
+		case time.Time:
+			return Timestamp(v), nil
+		case []byte, string:
+			t, err := ParseTime(Of(v).String())
+			if err != nil {
+				return Value{}, err
+			}
+			return Timestamp(t), nil
+		}
+	case "UUID", "UNIQUEIDENTIFIER":
+		switch v := v.(type) {
+		case []byte:
+			return UUID(string(v)), nil
+		case string:
+			return UUID(v), nil
+		}
+	case "BYTEA", "BINARY", "VARBINARY", "IMAGE", "BLOB":
+		switch v := v.(type) {
+		case []byte:
+			return Binary(v), nil
+		case string:
+			return Binary([]byte(v)), nil
+		}
+	case "BOOL", "BOOLEAN", "BIT":
+		switch v := v.(type) {
+		case bool:
+			return Bool(v), nil
+		case int64:
+			return Bool(v != 0), nil
+		}
+	}
+	return Of(v), nil
+}
+
+// Ordered reports whether values of a database type sort the same way on
+// every database: numbers, dates and timestamps do, while text follows each
+// database's collation
+func Ordered(databaseType string) bool {
+	switch normalizeTypeName(databaseType) {
+	case "INT", "INTEGER", "BIGINT", "SMALLINT", "TINYINT", "INT2", "INT4", "INT8",
+		"DECIMAL", "NUMERIC", "NUMBER", "MONEY", "SMALLMONEY", "REAL", "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE",
+		"DATE", "TIMESTAMP", "TIMESTAMPTZ", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET", "TIMESTAMP_NTZ":
+		return true
+	}
+	return false
+}
+
+// normalizeTypeName upper-cases a type name and drops any length, precision
+// or modifier, e.g. "decimal(10,2)" becomes "DECIMAL"
+func normalizeTypeName(name string) string {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if i := strings.IndexAny(name, "( "); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
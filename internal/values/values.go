@@ -0,0 +1,311 @@
+// Package values defines the canonical value model that scanned database
+// values are mapped into, so that the same data compares equal no matter
+// which driver produced it.
+package values
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the canonical type of a value
+type Kind string
+
+const (
+	KindNull      Kind = "null"
+	KindInt       Kind = "int"
+	KindDecimal   Kind = "decimal"
+	KindFloat     Kind = "float"
+	KindString    Kind = "string"
+	KindBool      Kind = "bool"
+	KindDate      Kind = "date"
+	KindTimestamp Kind = "timestamp"
+	KindBinary    Kind = "binary"
+	KindUUID      Kind = "uuid"
+)
+
+// Value is a database value in canonical form. The zero Value is NULL.
+type Value struct {
+	kind  Kind
+	num   *big.Rat // int and decimal
+	float float64
+	str   string // string and uuid (lower case)
+	b     bool
+	t     time.Time // date (midnight UTC) and timestamp (UTC)
+	bytes []byte
+}
+
+// Null returns the NULL value
+func Null() Value { return Value{} }
+
+// Int returns an integer value
+func Int(i int64) Value { return Value{kind: KindInt, num: new(big.Rat).SetInt64(i)} }
+
+// BigInt returns an integer value of arbitrary size
+func BigInt(i *big.Int) Value { return Value{kind: KindInt, num: new(big.Rat).SetInt(i)} }
+
+// Decimal returns an exact decimal value
+func Decimal(r *big.Rat) Value { return Value{kind: KindDecimal, num: new(big.Rat).Set(r)} }
+
+// Float returns a floating point value
+func Float(f float64) Value { return Value{kind: KindFloat, float: f} }
+
+// String returns a text value
+func String(s string) Value { return Value{kind: KindString, str: s} }
+
+// Bool returns a boolean value
+func Bool(b bool) Value { return Value{kind: KindBool, b: b} }
+
+// Date returns the calendar date of t as seen in t's location
+func Date(t time.Time) Value {
+	return Value{kind: KindDate, t: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// Timestamp returns an instant in time
+func Timestamp(t time.Time) Value { return Value{kind: KindTimestamp, t: t.UTC()} }
+
+// Binary returns a binary value
+func Binary(b []byte) Value { return Value{kind: KindBinary, bytes: append([]byte(nil), b...)} }
+
+// UUID returns a UUID value from its text form
+func UUID(s string) Value { return Value{kind: KindUUID, str: strings.ToLower(strings.TrimSpace(s))} }
+
+// ParseDecimal reads an exact decimal from text such as "19.990"
+func ParseDecimal(s string) (Value, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Value{}, fmt.Errorf("%q is not a decimal", s)
+	}
+	return Decimal(r), nil
+}
+
+// Kind returns the canonical type
+func (v Value) Kind() Kind {
+	if v.kind == "" {
+		return KindNull
+	}
+	return v.kind
+}
+
+// IsNull reports whether v is NULL
+func (v Value) IsNull() bool { return v.Kind() == KindNull }
+
+// String returns the canonical text form: integers and decimals without
+// trailing zeros, floats in shortest form, dates as YYYY-MM-DD, timestamps
+// as RFC 3339 in UTC, binary as lowercase hex. NULL renders as "".
+func (v Value) String() string {
+	switch v.Kind() {
+	case KindInt, KindDecimal:
+		return ratString(v.num)
+	case KindFloat:
+		return strconv.FormatFloat(v.float, 'g', -1, 64)
+	case KindString, KindUUID:
+		return v.str
+	case KindBool:
+		return strconv.FormatBool(v.b)
+	case KindDate:
+		return v.t.Format("2006-01-02")
+	case KindTimestamp:
+		return v.t.Format(time.RFC3339Nano)
+	case KindBinary:
+		return hex.EncodeToString(v.bytes)
+	}
+	return ""
+}
+
+// Rat returns the value as an exact number. Text is parsed; floats use
+// their shortest decimal form so 0.1 stays 0.1.
+func (v Value) Rat() (*big.Rat, bool) {
+	switch v.Kind() {
+	case KindInt, KindDecimal:
+		return new(big.Rat).Set(v.num), true
+	case KindFloat:
+		if math.IsInf(v.float, 0) || math.IsNaN(v.float) {
+			return nil, false
+		}
+		return new(big.Rat).SetString(strconv.FormatFloat(v.float, 'g', -1, 64))
+	case KindString:
+		return new(big.Rat).SetString(strings.TrimSpace(v.str))
+	}
+	return nil, false
+}
+
+// Time returns the value as a time. Text is parsed in the common formats.
+func (v Value) Time() (time.Time, bool) {
+	switch v.Kind() {
+	case KindDate, KindTimestamp:
+		return v.t, true
+	case KindString:
+		t, err := ParseTime(v.str)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// Bool returns the value as a boolean
+func (v Value) Bool() (bool, bool) {
+	switch v.Kind() {
+	case KindBool:
+		return v.b, true
+	case KindInt:
+		return v.num.Sign() != 0, true
+	case KindString:
+		b, err := strconv.ParseBool(strings.TrimSpace(v.str))
+		return b, err == nil
+	}
+	return false, false
+}
+
+// Interface returns a JSON friendly Go value: integers that fit as int64,
+// floats as float64, booleans as bool and everything else as canonical text
+func (v Value) Interface() interface{} {
+	switch v.Kind() {
+	case KindNull:
+		return nil
+	case KindInt:
+		if n := v.num.Num(); n.IsInt64() {
+			return n.Int64()
+		}
+	case KindFloat:
+		if !math.IsInf(v.float, 0) && !math.IsNaN(v.float) {
+			return v.float
+		}
+	case KindBool:
+		return v.b
+	}
+	return v.String()
+}
+
+// MarshalJSON encodes the value as Interface() does
+func (v Value) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Interface())
+}
+
+// Equal compares two values. NULL equals only NULL. Numbers compare by
+// value across int, decimal and float; dates and timestamps compare as
+// instants; text is compared with the other side's type when it parses as
+// one. Anything else compares by canonical text.
+func Equal(a, b Value) bool {
+	if a.IsNull() || b.IsNull() {
+		return a.IsNull() && b.IsNull()
+	}
+	if a.Kind() == b.Kind() {
+		switch a.Kind() {
+		case KindInt, KindDecimal:
+			return a.num.Cmp(b.num) == 0
+		case KindFloat:
+			return a.float == b.float || math.IsNaN(a.float) && math.IsNaN(b.float)
+		case KindBool:
+			return a.b == b.b
+		case KindDate, KindTimestamp:
+			return a.t.Equal(b.t)
+		case KindBinary:
+			return string(a.bytes) == string(b.bytes)
+		}
+		return a.str == b.str
+	}
+
+	if a.numeric() || b.numeric() {
+		ra, okA := a.Rat()
+		rb, okB := b.Rat()
+		if okA && okB {
+			return ra.Cmp(rb) == 0
+		}
+	}
+	if a.temporal() || b.temporal() {
+		ta, okA := a.Time()
+		tb, okB := b.Time()
+		if okA && okB {
+			return ta.Equal(tb)
+		}
+	}
+	if a.Kind() == KindBool || b.Kind() == KindBool {
+		ba, okA := a.Bool()
+		bb, okB := b.Bool()
+		if okA && okB {
+			return ba == bb
+		}
+	}
+	if a.Kind() == KindUUID || b.Kind() == KindUUID {
+		return strings.EqualFold(a.String(), b.String())
+	}
+	return a.String() == b.String()
+}
+
+// Key returns a canonical encoding of v for matching rows by key. NULL is
+// distinct from every other value, including the empty string; numbers
+// match across int, decimal, float and bool, and dates match timestamps at
+// midnight UTC. Keys of different values never collide, so encodings may be
+// concatenated.
+func (v Value) Key() string {
+	var tag, text string
+	switch v.Kind() {
+	case KindNull:
+		return "~"
+	case KindInt, KindDecimal, KindFloat:
+		if r, ok := v.Rat(); ok {
+			tag, text = "n", r.RatString()
+		} else {
+			tag, text = "f", v.String() // NaN and infinities
+		}
+	case KindDate, KindTimestamp:
+		tag, text = "t", v.t.UTC().Format(time.RFC3339Nano)
+	case KindBool:
+		tag, text = "n", "0"
+		if v.b {
+			text = "1"
+		}
+	case KindBinary:
+		tag, text = "x", v.String()
+	default:
+		// UUIDs are already lower-case, so they match their text form
+		tag, text = "s", v.str
+	}
+	return tag + strconv.Itoa(len(text)) + ":" + text
+}
+
+func (v Value) numeric() bool {
+	k := v.Kind()
+	return k == KindInt || k == KindDecimal || k == KindFloat
+}
+
+func (v Value) temporal() bool {
+	k := v.Kind()
+	return k == KindDate || k == KindTimestamp
+}
+
+// ratString renders r without trailing zeros, to at most 18 decimal places
+func ratString(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	s := strings.TrimRight(r.FloatString(18), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// timeLayouts are the text forms accepted for dates and timestamps
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999 -0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// ParseTime reads a date or timestamp; values without a zone are taken as UTC
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not a timestamp", s)
+}
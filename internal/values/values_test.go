@@ -0,0 +1,117 @@
+package values
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFromDriver(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        interface{}
+		databaseType string
+		wantKind     Kind
+		wantString   string
+		wantErr      bool
+	}{
+		{"null", nil, "DECIMAL", KindNull, "", false},
+		{"decimal bytes", []byte("19.9900"), "DECIMAL", KindDecimal, "19.99", false},
+		{"numeric with precision", "-0.50", "numeric(10,2)", KindDecimal, "-0.5", false},
+		{"decimal as float", 19.99, "DECIMAL", KindDecimal, "19.99", false},
+		{"invalid decimal", []byte("abc"), "MONEY", KindNull, "", true},
+		{"small int", int16(-7), "SMALLINT", KindInt, "-7", false},
+		{"float", float32(1.5), "REAL", KindFloat, "1.5", false},
+		{"text bytes", []byte("abc"), "VARCHAR", KindString, "abc", false},
+		{"uuid", []byte("A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11"), "UUID", KindUUID, "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", false},
+		{"bytea", []byte{0xde, 0xad}, "BYTEA", KindBinary, "dead", false},
+		{"date", time.Date(2024, 3, 1, 0, 0, 0, 0, time.FixedZone("", 3600)), "DATE", KindDate, "2024-03-01", false},
+		{"date text", "2024-03-01", "DATE", KindDate, "2024-03-01", false},
+		{"timestamp with zone", time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("", 3600)), "TIMESTAMPTZ", KindTimestamp, "2024-03-01T11:00:00Z", false},
+		{"bit", true, "BIT", KindBool, "true", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromDriver(tt.input, tt.databaseType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromDriver() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (got.Kind() != tt.wantKind || got.String() != tt.wantString) {
+				t.Errorf("FromDriver() = %s %q, want %s %q", got.Kind(), got, tt.wantKind, tt.wantString)
+			}
+		})
+	}
+}
+
+func TestEqual(t *testing.T) {
+	decimal := func(s string) Value {
+		v, _ := ParseDecimal(s)
+		return v
+	}
+	ts := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		a, b Value
+		want bool
+	}{
+		{"null and null", Null(), Null(), true},
+		{"null and empty", Null(), String(""), false},
+		{"decimal scales", decimal("1.50"), decimal("1.5"), true},
+		{"int and decimal", Int(2), decimal("2.000"), true},
+		{"decimal and float", decimal("0.1"), Float(0.1), true},
+		{"decimal and text", decimal("10.5"), String("10.50"), true},
+		{"different numbers", Int(1), Int(2), false},
+		{"timestamp zones", Timestamp(ts), Timestamp(ts.In(time.FixedZone("", -5*3600))), true},
+		{"timestamp and text", Timestamp(ts), String("2024-03-01 12:00:00"), true},
+		{"date and midnight", Date(ts), Timestamp(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)), true},
+		{"uuid case", UUID("A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11"), String("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"), true},
+		{"bool and int", Bool(true), Int(1), true},
+		{"text case", String("a"), String("A"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Equal(tt.a, tt.b); got != tt.want {
+				t.Errorf("Equal(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValue_Key(t *testing.T) {
+	decimal := func(s string) Value {
+		v, _ := ParseDecimal(s)
+		return v
+	}
+	midnight := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		a, b Value
+		want bool
+	}{
+		{"null and empty", Null(), String(""), false},
+		{"null and marker text", Null(), String("~"), false},
+		{"int and decimal", Int(2), decimal("2.000"), true},
+		{"decimal and float", decimal("0.1"), Float(0.1), true},
+		{"number and text", Int(1), String("1"), false},
+		{"date and midnight", Date(midnight), Timestamp(midnight.In(time.FixedZone("", 3600))), true},
+		{"date and later timestamp", Date(midnight), Timestamp(midnight.Add(time.Second)), false},
+		{"uuid and text", UUID("A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11"), String("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"), true},
+		{"bool and int", Bool(true), Int(1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Key() == tt.b.Key(); got != tt.want {
+				t.Errorf("Key(%q) == Key(%q) is %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+
+	// Encodings are length-prefixed, so joined parts cannot collide
+	if String("a").Key()+String("b,c").Key() == String("a,b").Key()+String("c").Key() {
+		t.Error("joined keys collide")
+	}
+}